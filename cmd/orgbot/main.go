@@ -5,6 +5,8 @@ import (
 	"fmt"
 	h "net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -16,6 +18,12 @@ import (
 	"github.com/SEEK-Jobs/orgbot/pkg/http"
 )
 
+const (
+	readTimeout  = 10 * time.Second // Maximum duration for reading a request
+	writeTimeout = 30 * time.Second // Maximum duration for handling a request and writing its response
+	idleTimeout  = 60 * time.Second // Maximum duration a keep-alive connection waits for its next request
+)
+
 // This main runs Orgbot as a GitHub App.
 func main() {
 	log := zerolog.New(os.Stderr).With().Timestamp().Logger()
@@ -25,40 +33,100 @@ func main() {
 		log.Fatal().Err(err).Msg("Could not determine HTTP port")
 	}
 
+	shutdownTimeout, err := cmd.LookupShutdownTimeout()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not determine shutdown timeout")
+	}
+
 	plat, metricsFn, err := cmd.NewPlatform()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Could not create Platform")
 	}
 
+	q, err := cmd.NewQueue(plat.Config())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create queue")
+	}
+
+	dls, err := cmd.NewDeadLetterStore(plat.Config())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create dead letter store")
+	}
+
+	blobs, err := cmd.NewBlobStore(plat.Config())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create blob store")
+	}
+
+	archive, err := cmd.NewArchive(plat.Config())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create archive")
+	}
+
 	m := buildMiddleware(log)
 
-	h.Handle("/health", m.Then(http.NewHealthHandler()))
-	h.Handle("/smoke", m.Then(http.NewSmokeHandler(plat)))
-	h.Handle("/hook", m.Then(http.NewHookHandler(plat)))
+	mux := h.NewServeMux()
+	mux.Handle("/health", m.Then(http.NewHealthHandler()))
+	mux.Handle("/smoke", m.Then(http.NewSmokeHandler(plat)))
+	mux.Handle("/hook", m.Then(http.NewHookHandler(plat, q, archive)))
+
+	server := &h.Server{
+		Addr:         fmt.Sprintf(":%d", httpPort),
+		Handler:      mux,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
 
 	log.Info().Msgf("Reporting metrics every %v", plat.Config().MetricsInterval)
 	go metricsFn()
 
-	ctx := log.WithContext(context.Background())
-
-	errChan := make(chan error)
-	defer close(errChan)
+	ctx, cancel := context.WithCancel(log.WithContext(context.Background()))
+	defer cancel()
 
-	go http.ListenForEvents(ctx, plat, errChan)
+	listenerDone := make(chan error, 1)
+	go func() {
+		listenerDone <- http.ListenForEvents(ctx, plat, q, dls, blobs)
+	}()
 
+	serverDone := make(chan error, 1)
 	go func() {
-		for {
-			e := <-errChan
-			if e != nil {
-				log.Fatal().Err(e).Msg("Received error while processing events")
-			}
-		}
+		log.Info().Msgf("Listening on port %d", httpPort)
+		serverDone <- server.ListenAndServe()
 	}()
 
-	log.Info().Msgf("Listening on port %d", httpPort)
-	if err = h.ListenAndServe(fmt.Sprintf(":%d", httpPort), nil); err != nil {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case sig := <-signals:
+		log.Info().Msgf("Received %v, shutting down", sig)
+	case err := <-listenerDone:
+		log.Fatal().Err(err).Msg("Received error while processing events")
+	case err := <-serverDone:
 		log.Fatal().Err(err).Msg("Server crashed")
 	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+
+	// Stop accepting webhooks first so that no more events are queued, then stop handling events once
+	// the event being handled has finished
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to shut down server gracefully")
+	}
+	cancel()
+
+	select {
+	case err := <-listenerDone:
+		if err != nil {
+			log.Error().Err(err).Msg("Received error while processing events")
+		}
+	case <-shutdownCtx.Done():
+		log.Error().Msg("Timed out waiting for the event being handled to finish")
+	}
+
+	log.Info().Msg("Shut down")
 }
 
 func buildMiddleware(log zerolog.Logger) alice.Chain {
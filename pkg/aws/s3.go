@@ -2,6 +2,8 @@ package aws
 
 import (
 	"bytes"
+	"context"
+	"io/ioutil"
 
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -32,3 +34,43 @@ func (s *S3) GetObjectAsString(bucket, key string) (string, error) {
 
 	return buf.String(), nil
 }
+
+func (s *S3) PutObject(ctx context.Context, bucket, key string, body []byte) error {
+	req := s3.PutObjectInput{Bucket: &bucket, Key: &key, Body: bytes.NewReader(body)}
+	_, err := s.client.PutObjectWithContext(ctx, &req)
+	return err
+}
+
+func (s *S3) GetObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	req := s3.GetObjectInput{Bucket: &bucket, Key: &key}
+	res, err := s.client.GetObjectWithContext(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return ioutil.ReadAll(res.Body)
+}
+
+// ListKeys returns the keys of all objects in the specified bucket that start with the specified prefix.
+func (s *S3) ListKeys(ctx context.Context, bucket, prefix string) ([]string, error) {
+	var keys []string
+	req := s3.ListObjectsV2Input{Bucket: &bucket, Prefix: &prefix}
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &req, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, o := range page.Contents {
+			keys = append(keys, *o.Key)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *S3) DeleteObject(ctx context.Context, bucket, key string) error {
+	req := s3.DeleteObjectInput{Bucket: &bucket, Key: &key}
+	_, err := s.client.DeleteObjectWithContext(ctx, &req)
+	return err
+}
@@ -0,0 +1,351 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
+	"github.com/SEEK-Jobs/orgbot/pkg/http"
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+var (
+	// lazyQueue, lazyDeadLetterStore and lazyArchive provide a means of overriding the concrete
+	// implementations of the event queue, dead letter store and delivery archive used in tests.
+	lazyQueue = func(c *orgbot.Config) (queue.Queue, error) {
+		return cmd.NewQueue(c)
+	}
+	lazyDeadLetterStore = func(c *orgbot.Config) (queue.DeadLetterStore, error) {
+		return cmd.NewDeadLetterStore(c)
+	}
+	lazyArchive = func(c *orgbot.Config) (queue.Archive, error) {
+		return cmd.NewArchive(c)
+	}
+)
+
+// deadLetterReport describes a message that was dead-lettered.
+type deadLetterReport struct {
+	DeliveryID string    `json:"deliveryID" yaml:"deliveryID"`
+	EventType  string    `json:"eventType" yaml:"eventType"`
+	Attempts   int       `json:"attempts" yaml:"attempts"`
+	Error      string    `json:"error" yaml:"error"`
+	Time       time.Time `json:"time" yaml:"time"`
+	Payload    string    `json:"payload,omitempty" yaml:"payload,omitempty"`
+}
+
+// simulateResult describes an event that was handled locally.
+type simulateResult struct {
+	EventType string `json:"eventType" yaml:"eventType"`
+	DryRun    bool   `json:"dryRun" yaml:"dryRun"`
+}
+
+// replayResult lists the delivery IDs of the dead-lettered or archived messages that were replayed.
+type replayResult struct {
+	Replayed []string `json:"replayed" yaml:"replayed"`
+}
+
+// newEventsCommand returns the "orgctl events" sub-command which nests other sub-commands
+// for interacting with the events processed by orgbot.
+func newEventsCommand(ctx context.Context) *cobra.Command {
+	eventsCmd := &cobra.Command{
+		Use:   "events",
+		Short: "Event processing related commands",
+	}
+
+	eventsCmd.AddCommand(
+		newDeadLetterCommand(ctx),
+		newReplayArchiveCommand(ctx),
+		newSimulateEventCommand(ctx))
+
+	return eventsCmd
+}
+
+// newDeadLetterCommand returns the "orgctl events dlq" sub-command which nests other sub-commands
+// for interacting with dead-lettered events.
+func newDeadLetterCommand(ctx context.Context) *cobra.Command {
+	dlqCmd := &cobra.Command{
+		Use:   "dlq",
+		Short: "Dead-lettered event commands",
+	}
+
+	dlqCmd.AddCommand(
+		newListDeadLettersCommand(ctx),
+		newReplayDeadLettersCommand(ctx))
+
+	return dlqCmd
+}
+
+// newListDeadLettersCommand returns the "orgctl events dlq list" sub-command which lists dead-lettered events.
+func newListDeadLettersCommand(ctx context.Context) *cobra.Command {
+	var payload bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the events that could not be processed",
+		RunE: func(c *cobra.Command, args []string) error {
+			plat, err := lazyPlatform()
+			if err != nil {
+				return err
+			}
+
+			dls, err := lazyDeadLetterStore(plat.Config())
+			if err != nil {
+				return err
+			}
+
+			deadLetters, err := dls.List(ctx)
+			if err != nil {
+				return err
+			}
+
+			reports := []*deadLetterReport{}
+			for _, d := range deadLetters {
+				r := &deadLetterReport{
+					DeliveryID: d.Message.DeliveryID,
+					EventType:  d.Message.EventType,
+					Attempts:   d.Attempts,
+					Error:      d.Error,
+					Time:       d.Time,
+				}
+				if payload {
+					r.Payload = d.Message.Payload
+				}
+				reports = append(reports, r)
+			}
+
+			return printer.Print(reports)
+		},
+	}
+
+	listCmd.Flags().BoolVar(&payload, "payload", false, "Include the event payloads")
+
+	return listCmd
+}
+
+// newReplayDeadLettersCommand returns the "orgctl events dlq replay" sub-command which puts dead-lettered
+// events back on the event queue.
+func newReplayDeadLettersCommand(ctx context.Context) *cobra.Command {
+	var all bool
+	replayCmd := &cobra.Command{
+		Use:   "replay [delivery-id...]",
+		Short: "Puts events that could not be processed back on the event queue",
+		RunE: func(c *cobra.Command, args []string) error {
+			if all == (len(args) > 0) {
+				return errors.New("either --all or delivery IDs must be specified but not both")
+			}
+
+			plat, err := lazyPlatform()
+			if err != nil {
+				return err
+			}
+
+			q, err := lazyQueue(plat.Config())
+			if err != nil {
+				return err
+			}
+
+			dls, err := lazyDeadLetterStore(plat.Config())
+			if err != nil {
+				return err
+			}
+
+			res, err := replayDeadLetters(ctx, q, dls, all, args)
+			if err != nil {
+				return err
+			}
+
+			return printer.Print(*res)
+		},
+	}
+
+	replayCmd.Flags().BoolVar(&all, "all", false, "Replay all dead-lettered events")
+
+	return replayCmd
+}
+
+// replayDeadLetters submits the dead-lettered messages with the specified delivery IDs, or all of them,
+// to the specified queue and removes them from the dead letter store.
+func replayDeadLetters(ctx context.Context, q queue.Queue, dls queue.DeadLetterStore, all bool, deliveryIDs []string) (*replayResult, error) {
+	deadLetters, err := dls.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*queue.DeadLetter{}
+	for _, d := range deadLetters {
+		byID[d.Message.DeliveryID] = d
+	}
+
+	if all {
+		deliveryIDs = nil
+		for _, d := range deadLetters {
+			deliveryIDs = append(deliveryIDs, d.Message.DeliveryID)
+		}
+	}
+
+	// Check all the events exist before replaying any of them
+	for _, id := range deliveryIDs {
+		if _, ok := byID[id]; !ok {
+			return nil, errors.Errorf("no dead-lettered event with delivery ID %s", id)
+		}
+	}
+
+	res := replayResult{Replayed: []string{}}
+	for _, id := range deliveryIDs {
+		// The replay must not be deduplicated against the original, or it would be lost once removed below
+		m := byID[id].Message
+		m.Replay(time.Now())
+		if err := q.Submit(ctx, &m); err != nil {
+			return nil, errors.Wrapf(err, "failed to replay event %s", id)
+		}
+
+		if err := dls.Remove(ctx, id); err != nil {
+			return nil, err
+		}
+
+		res.Replayed = append(res.Replayed, id)
+	}
+
+	return &res, nil
+}
+
+// newReplayArchiveCommand returns the "orgctl events replay" sub-command which puts archived events back
+// on the event queue.
+func newReplayArchiveCommand(ctx context.Context) *cobra.Command {
+	var deliveryIDs []string
+	var since string
+	replayCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Puts events delivered to the hook back on the event queue",
+		RunE: func(c *cobra.Command, args []string) error {
+			if (since == "") == (len(deliveryIDs) == 0) {
+				return errors.New("either --since or --delivery must be specified but not both")
+			}
+
+			var sinceTime time.Time
+			if since != "" {
+				var err error
+				if sinceTime, err = parseSince(since); err != nil {
+					return err
+				}
+			}
+
+			plat, err := lazyPlatform()
+			if err != nil {
+				return err
+			}
+
+			q, err := lazyQueue(plat.Config())
+			if err != nil {
+				return err
+			}
+
+			archive, err := lazyArchive(plat.Config())
+			if err != nil {
+				return err
+			}
+
+			res, err := replayArchive(ctx, q, archive, sinceTime, deliveryIDs)
+			if err != nil {
+				return err
+			}
+
+			return printer.Print(*res)
+		},
+	}
+
+	replayCmd.Flags().StringSliceVar(&deliveryIDs, "delivery", nil, "Delivery ID of an event to replay (may be repeated)")
+	replayCmd.Flags().StringVar(&since, "since", "", "Replay the events delivered since this RFC 3339 time or duration ago")
+
+	return replayCmd
+}
+
+// parseSince returns the time described by the specified RFC 3339 time, or by a duration before now.
+func parseSince(since string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(since)
+	if err != nil {
+		return time.Time{}, errors.Errorf("bad time %s: must be an RFC 3339 time or a duration", since)
+	}
+	return time.Now().Add(-d), nil
+}
+
+// replayArchive submits the archived messages with the specified delivery IDs, or those delivered since the
+// specified time if there are none, to the specified queue.
+func replayArchive(ctx context.Context, q queue.Queue, archive queue.Archive, since time.Time, deliveryIDs []string) (*replayResult, error) {
+	var messages []*queue.Message
+	if len(deliveryIDs) > 0 {
+		// Check all the events exist before replaying any of them
+		for _, id := range deliveryIDs {
+			m, err := archive.Get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, m)
+		}
+	} else {
+		var err error
+		if messages, err = archive.List(ctx, since); err != nil {
+			return nil, err
+		}
+	}
+
+	res := replayResult{Replayed: []string{}}
+	replayedAt := time.Now()
+	for _, m := range messages {
+		// Replays are deduplicated separately from the original deliveries so that queues don't drop them
+		m.Replay(replayedAt)
+		if err := q.Submit(ctx, m); err != nil {
+			return nil, errors.Wrapf(err, "failed to replay event %s", m.DeliveryID)
+		}
+
+		res.Replayed = append(res.Replayed, m.DeliveryID)
+	}
+
+	return &res, nil
+}
+
+// newSimulateEventCommand returns the "orgctl events simulate" sub-command which handles an event payload
+// locally rather than queueing it.
+func newSimulateEventCommand(ctx context.Context) *cobra.Command {
+	var file, eventType string
+	var dryRun bool
+	simulateCmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Handles an event payload locally as if it had been delivered to the hook",
+		RunE: func(c *cobra.Command, args []string) error {
+			if file == "" || eventType == "" {
+				return errors.New("--file and --event must be specified")
+			}
+
+			payload, err := ioutil.ReadFile(file)
+			if err != nil {
+				return errors.Wrapf(err, "could not read payload file %s", file)
+			}
+
+			plat, err := newPlatform(ctx, dryRun)
+			if err != nil {
+				return err
+			}
+
+			if err := http.HandleEvent(ctx, plat, eventType, payload); err != nil {
+				return errors.Wrapf(err, "failed to handle %s event", eventType)
+			}
+
+			return printer.Print(simulateResult{EventType: eventType, DryRun: dryRun})
+		},
+	}
+
+	simulateCmd.Flags().StringVar(&file, "file", "", "File containing the event payload")
+	simulateCmd.Flags().StringVar(&eventType, "event", "", "Type of the event, as in the X-GitHub-Event header")
+	simulateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate write operations")
+
+	return simulateCmd
+}
@@ -31,6 +31,7 @@ func newOrgCommand(ctx context.Context) *cobra.Command {
 func newApplyOrgCommand(ctx context.Context) *cobra.Command {
 	var dir string
 	var file string
+	var opts orgbot.ApplyOrgOptions
 	var dryRun bool
 	applyCmd := &cobra.Command{
 		Use:   "apply",
@@ -46,12 +47,13 @@ func newApplyOrgCommand(ctx context.Context) *cobra.Command {
 				return err
 			}
 
-			return applyOrg(ctx, plat, org)
+			return applyOrg(ctx, plat, org, &opts)
 		},
 	}
 
 	applyCmd.Flags().StringVar(&dir, "dir", "", "The org directory to apply")
 	applyCmd.Flags().StringVar(&file, "file", "", "The org config file to apply")
+	applyCmd.Flags().BoolVar(&opts.RefreshTopics, "refresh-topics", false, "Refresh the topics of the repositories of renamed teams")
 	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate write operations")
 
 	return applyCmd
@@ -148,8 +150,8 @@ func readOrgDir(codec orgbot.Codec, dir string) (*orgbot.Org, error) {
 }
 
 // applyOrg applies the specified org configuration against GitHub.
-func applyOrg(ctx context.Context, plat orgbot.Platform, org *orgbot.Org) error {
-	res, err := orgbot.ApplyOrg(ctx, plat, org)
+func applyOrg(ctx context.Context, plat orgbot.Platform, org *orgbot.Org, opts *orgbot.ApplyOrgOptions) error {
+	res, err := orgbot.ApplyOrg(ctx, plat, org, opts)
 	if err != nil {
 		return errors.Wrapf(err, "error applying org")
 	}
@@ -2,6 +2,10 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -18,26 +22,89 @@ func newReposCommand(ctx context.Context) *cobra.Command {
 	}
 
 	dumpCmd.AddCommand(
+		newApplyReposCommand(ctx),
 		newDumpReposCommand(ctx),
+		newReposOwnershipCommand(ctx),
 		newUpdateAdminTopicsCommand(ctx),
 		newUpdateTeamsCommand(ctx))
 
 	return dumpCmd
 }
 
+// newApplyReposCommand returns the "orgctl repos apply" sub-command which applies a repo manifest.
+func newApplyReposCommand(ctx context.Context) *cobra.Command {
+	var dir string
+	var file string
+	var dryRun bool
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Applies repository team and topic configuration",
+		RunE: func(c *cobra.Command, args []string) error {
+			plat, err := newPlatform(ctx, dryRun)
+			if err != nil {
+				return err
+			}
+
+			manifest, err := readRepoManifest(plat.Codec(), file, dir)
+			if err != nil {
+				return err
+			}
+
+			res, err := orgbot.ApplyRepos(ctx, plat, manifest)
+			if err != nil {
+				return err
+			}
+
+			return printer.Print(*res)
+		},
+	}
+
+	applyCmd.Flags().StringVar(&dir, "dir", "", "The org directory containing the repos.yaml file to apply")
+	applyCmd.Flags().StringVar(&file, "file", "", "The repo manifest file to apply")
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate write operations")
+
+	return applyCmd
+}
+
+// readRepoManifest is a helper function for reading a repo manifest from either file or directory.
+func readRepoManifest(codec orgbot.Codec, file string, dir string) (*orgbot.RepoManifest, error) {
+	if dir != "" && file != "" {
+		return nil, fmt.Errorf("either --dir or --file must be specified but not both")
+	}
+
+	if file != "" {
+		return orgbot.ReadRepoManifest(codec, file)
+	} else if dir != "" {
+		manifest, err := orgbot.MergeRepos(codec, dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not merge dir %s", dir)
+		}
+		return manifest, nil
+	}
+
+	return nil, fmt.Errorf("either --dir or --file must be specified")
+}
+
 // newDumpReposCommand returns the "orgctl repos dump" sub-command which dumps repository configuration.
 func newDumpReposCommand(ctx context.Context) *cobra.Command {
 	var orgName string
+	var collaborators bool
+	var selectorFlags repoSelectorFlags
 	orgCmd := &cobra.Command{
 		Use:   "dump",
 		Short: "Dumps repository configuration",
 		RunE: func(c *cobra.Command, args []string) error {
+			selector, err := selectorFlags.selector()
+			if err != nil {
+				return err
+			}
+
 			plat, err := newReadOnlyPlatform(ctx)
 			if err != nil {
 				return err
 			}
 
-			repos, err := orgbot.DumpRepos(ctx, plat, orgName)
+			repos, err := orgbot.DumpRepos(ctx, plat, orgName, selector, collaborators)
 			if err != nil {
 				return err
 			}
@@ -47,6 +114,43 @@ func newDumpReposCommand(ctx context.Context) *cobra.Command {
 	}
 
 	orgCmd.Flags().StringVar(&orgName, "org-name", "", "Name of the GitHub organisation that owns the repositories")
+	orgCmd.Flags().BoolVar(&collaborators, "collaborators", false, "Include the direct collaborators of the repositories (one API call per repository)")
+	selectorFlags.addFlags(orgCmd)
+	_ = orgCmd.MarkFlagRequired("org-name")
+
+	return orgCmd
+}
+
+// newReposOwnershipCommand returns the "orgctl repos ownership" sub-command which reports repositories
+// that have no admin team, more than one admin team or individual collaborators with admin permission.
+func newReposOwnershipCommand(ctx context.Context) *cobra.Command {
+	var orgName string
+	var selectorFlags repoSelectorFlags
+	orgCmd := &cobra.Command{
+		Use:   "ownership",
+		Short: "Reports the ownership of repositories by admin teams",
+		RunE: func(c *cobra.Command, args []string) error {
+			selector, err := selectorFlags.selector()
+			if err != nil {
+				return err
+			}
+
+			plat, err := newReadOnlyPlatform(ctx)
+			if err != nil {
+				return err
+			}
+
+			report, err := orgbot.RepoOwnership(ctx, plat, orgName, selector)
+			if err != nil {
+				return err
+			}
+
+			return printer.Print(*report)
+		},
+	}
+
+	orgCmd.Flags().StringVar(&orgName, "org-name", "", "Name of the GitHub organisation that owns the repositories")
+	selectorFlags.addFlags(orgCmd)
 	_ = orgCmd.MarkFlagRequired("org-name")
 
 	return orgCmd
@@ -56,26 +160,38 @@ func newDumpReposCommand(ctx context.Context) *cobra.Command {
 // topics to each repository in the org that indicate the administrators of the repository.
 func newUpdateAdminTopicsCommand(ctx context.Context) *cobra.Command {
 	var orgName string
-	var dryRun bool
+	var selectorFlags repoSelectorFlags
+	var continueOnError, dryRun bool
 	orgCmd := &cobra.Command{
 		Use:   "update-admin-topics",
 		Short: "Adds topics to repositories to indicate the repository administrators",
 		RunE: func(c *cobra.Command, args []string) error {
+			selector, err := selectorFlags.selector()
+			if err != nil {
+				return err
+			}
+
 			plat, err := newPlatform(ctx, dryRun)
 			if err != nil {
 				return err
 			}
 
-			res, err := orgbot.UpdateAdminTopics(ctx, plat, orgName)
+			res, err := orgbot.UpdateAdminTopics(ctx, plat, orgName, selector, continueOnError)
 			if err != nil {
 				return err
 			}
 
-			return printer.Print(*res)
+			if err := printer.Print(*res); err != nil {
+				return err
+			}
+
+			return reposFailedError(res.ReposFailed)
 		},
 	}
 
 	orgCmd.Flags().StringVar(&orgName, "org-name", "", "Name of the GitHub organisation that owns the repositories")
+	selectorFlags.addFlags(orgCmd)
+	orgCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "Record repos that fail to update and continue with the remaining repos")
 	orgCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate write operations")
 	_ = orgCmd.MarkFlagRequired("org-name")
 
@@ -86,14 +202,30 @@ func newUpdateAdminTopicsCommand(ctx context.Context) *cobra.Command {
 // assigned to all repositories in the org.
 func newUpdateTeamsCommand(ctx context.Context) *cobra.Command {
 	var orgName string
-	var addReadTeams, removeReadTeams, excludeRepos, onlyRepos []string
-	var dryRun bool
+	var addTeams, removeTeams, addReadTeams, removeReadTeams, excludeRepos, onlyRepos []string
+	var selectorFlags repoSelectorFlags
+	var continueOnError, dryRun bool
 	orgCmd := &cobra.Command{
 		Use:   "update-teams",
 		Short: "Updates teams for all repositories in the organisation",
 		RunE: func(c *cobra.Command, args []string) error {
-			if len(addReadTeams) == 0 && len(removeReadTeams) == 0 {
-				return errors.New("at least one of --add-read-teams or --remove-read-teams must be specified")
+			if len(addTeams) == 0 && len(removeTeams) == 0 && len(addReadTeams) == 0 && len(removeReadTeams) == 0 {
+				return errors.New("at least one of --add-team, --remove-team, --add-read-teams or --remove-read-teams must be specified")
+			}
+
+			addTeamPermissions, err := parseTeamPermissions(addTeams, true)
+			if err != nil {
+				return err
+			}
+
+			removeTeamPermissions, err := parseTeamPermissions(removeTeams, false)
+			if err != nil {
+				return err
+			}
+
+			selector, err := selectorFlags.selector()
+			if err != nil {
+				return err
 			}
 
 			plat, err := newPlatform(ctx, dryRun)
@@ -102,10 +234,13 @@ func newUpdateTeamsCommand(ctx context.Context) *cobra.Command {
 			}
 
 			changeSet := orgbot.RepoTeamsChangeSet{
-				RemoveTeams:  teamPermissions(removeReadTeams, orgbot.RepoPermissionRead),
-				AddTeams:     teamPermissions(addReadTeams, orgbot.RepoPermissionRead),
+				RemoveTeams:  append(removeTeamPermissions, teamPermissions(removeReadTeams, orgbot.RepoPermissionRead)...),
+				AddTeams:     append(addTeamPermissions, teamPermissions(addReadTeams, orgbot.RepoPermissionRead)...),
 				ExcludeRepos: excludeRepos,
 				OnlyRepos:    onlyRepos,
+				Selector:     selector,
+
+				ContinueOnError: continueOnError,
 			}
 
 			res, err := orgbot.UpdateRepoTeams(ctx, plat, orgName, &changeSet)
@@ -113,21 +248,38 @@ func newUpdateTeamsCommand(ctx context.Context) *cobra.Command {
 				return err
 			}
 
-			return printer.Print(*res)
+			if err := printer.Print(*res); err != nil {
+				return err
+			}
+
+			return reposFailedError(res.ReposFailed)
 		},
 	}
 
 	orgCmd.Flags().StringVar(&orgName, "org-name", "", "Name of the GitHub organisation that owns the repositories")
+	orgCmd.Flags().StringSliceVar(&addTeams, "add-team", nil, "Team to add or update in the form TEAM=PERMISSION (e.g. Foo=maintain)")
+	orgCmd.Flags().StringSliceVar(&removeTeams, "remove-team", nil, "Team to remove in the form TEAM or TEAM=PERMISSION to only remove it where it has that permission")
 	orgCmd.Flags().StringSliceVar(&addReadTeams, "add-read-teams", nil, "Team to add with read permission")
 	orgCmd.Flags().StringSliceVar(&removeReadTeams, "remove-read-teams", nil, "Team with read permission to remove")
 	orgCmd.Flags().StringSliceVar(&excludeRepos, "exclude", nil, "Repos that should be excluded from the update")
 	orgCmd.Flags().StringSliceVar(&onlyRepos, "only", nil, "Repos that the update should be limited to")
+	selectorFlags.addFlags(orgCmd)
+	orgCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "Record repos that fail to update and continue with the remaining repos")
 	orgCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simulate write operations")
 	_ = orgCmd.MarkFlagRequired("org-name")
 
 	return orgCmd
 }
 
+// reposFailedError returns an error if any repos failed to update so that the command exits with
+// a non-zero status once the result has been printed.
+func reposFailedError(reposFailed int) error {
+	if reposFailed > 0 {
+		return fmt.Errorf("%d repos failed to update", reposFailed)
+	}
+	return nil
+}
+
 // teamPermissions is a helper function that returns a slice of TeamPermissions for the
 // specified team names with the specified RepoPermission.
 func teamPermissions(teamNames []string, permission orgbot.RepoPermission) []*orgbot.TeamPermission {
@@ -138,3 +290,116 @@ func teamPermissions(teamNames []string, permission orgbot.RepoPermission) []*or
 
 	return teamPermissions
 }
+
+// parseTeamPermissions is a helper function that returns a slice of TeamPermissions for the
+// specified TEAM=PERMISSION pairs. If requirePermission is false the permission may be omitted,
+// resulting in a TeamPermission with an empty permission.
+func parseTeamPermissions(specs []string, requirePermission bool) ([]*orgbot.TeamPermission, error) {
+	var teamPermissions []*orgbot.TeamPermission
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, "=", 2)
+		tp := orgbot.TeamPermission{TeamName: parts[0]}
+
+		if len(parts) == 2 {
+			p, err := orgbot.ParseRepoPermission(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			tp.Permission = p
+		} else if requirePermission {
+			return nil, fmt.Errorf("expected TEAM=PERMISSION but got '%s'", spec)
+		}
+
+		teamPermissions = append(teamPermissions, &tp)
+	}
+
+	return teamPermissions, nil
+}
+
+// repoSelectorFlags holds the values of the flags used to select repos for bulk repo commands.
+type repoSelectorFlags struct {
+	names           []string
+	nameRegexps     []string
+	withTopics      []string
+	withoutTopics   []string
+	adminTeams      []string
+	visibilities    []string
+	archived        string
+	pushedWithin    string
+	notPushedWithin string
+}
+
+// addFlags adds the repo selector flags to the specified command.
+func (f *repoSelectorFlags) addFlags(c *cobra.Command) {
+	flags := c.Flags()
+	flags.StringSliceVar(&f.names, "name", nil, "Glob pattern that repo names must match, ignoring case (e.g. '*-infra')")
+	flags.StringSliceVar(&f.nameRegexps, "name-regex", nil, "Regular expression that repo names must match, ignoring case")
+	flags.StringSliceVar(&f.withTopics, "topic", nil, "Topic that repos must have")
+	flags.StringSliceVar(&f.withoutTopics, "without-topic", nil, "Topic that repos must not have")
+	flags.StringSliceVar(&f.adminTeams, "admin-team", nil, "Team that must have admin permission on repos")
+	flags.StringSliceVar(&f.visibilities, "visibility", nil, "Visibility that repos must have (one of 'public', 'private' or 'internal')")
+	flags.StringVar(&f.archived, "archived", "", "Archive state that repos must have ('true' or 'false')")
+	flags.StringVar(&f.pushedWithin, "pushed-within", "", "Maximum time since repos were last pushed to (e.g. '30d' or '12h')")
+	flags.StringVar(&f.notPushedWithin, "not-pushed-within", "", "Minimum time since repos were last pushed to (e.g. '365d')")
+}
+
+// selector returns the orgbot.RepoSelector described by the flags.
+func (f *repoSelectorFlags) selector() (*orgbot.RepoSelector, error) {
+	selector := orgbot.RepoSelector{
+		NameGlobs:     f.names,
+		NameRegexps:   f.nameRegexps,
+		WithTopics:    f.withTopics,
+		WithoutTopics: f.withoutTopics,
+		AdminTeams:    f.adminTeams,
+	}
+
+	for _, v := range f.visibilities {
+		switch visibility := orgbot.RepoVisibility(strings.ToLower(v)); visibility {
+		case orgbot.RepoVisibilityPublic, orgbot.RepoVisibilityPrivate, orgbot.RepoVisibilityInternal:
+			selector.Visibilities = append(selector.Visibilities, visibility)
+		default:
+			return nil, fmt.Errorf("unknown visibility '%s'", v)
+		}
+	}
+
+	if f.archived != "" {
+		archived, err := strconv.ParseBool(f.archived)
+		if err != nil {
+			return nil, fmt.Errorf("bad --archived value '%s'", f.archived)
+		}
+		selector.Archived = &archived
+	}
+
+	var err error
+	if selector.PushedWithin, err = parseAge(f.pushedWithin); err != nil {
+		return nil, err
+	}
+
+	if selector.NotPushedWithin, err = parseAge(f.notPushedWithin); err != nil {
+		return nil, err
+	}
+
+	return &selector, nil
+}
+
+// parseAge parses the specified age which is either a time.Duration string or a whole number
+// of days with a 'd' suffix. An empty string is parsed as zero.
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("bad age '%s'", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil {
+		return 0, fmt.Errorf("bad age '%s'", age)
+	}
+	return d, nil
+}
@@ -50,6 +50,7 @@ func NewRootCommand(ctx context.Context) *cobra.Command {
 
 	// Add sub-commands
 	rootCmd.AddCommand(
+		newEventsCommand(ctx),
 		newOrgCommand(ctx),
 		newReposCommand(ctx),
 		newVersionCommand())
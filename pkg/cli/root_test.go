@@ -3,12 +3,14 @@ package cli
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 
 	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
 )
 
 // These are component level tests that test that Cobra commands are wired up correctly. The
@@ -270,3 +272,241 @@ func TestUpdateRepoTeamsCommandDryRun(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestUpdateRepoTeamsCommandAddTeam(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	lazyPlatform = func() (orgbot.Platform, error) {
+		return plat, nil
+	}
+
+	// Return teams Foo and Bar
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return([]*orgbot.GitHubTeam{
+			{ID: 100, ParentID: 0, Name: "Foo"},
+			{ID: 101, ParentID: 0, Name: "Bar"},
+		}, nil)
+
+	// Return repo1 and repo2
+	plat.MockGitHubService.
+		EXPECT().
+		WalkRepos(gomock.Any(), "SEEK-Jobs", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName string, walkFn orgbot.WalkReposFunc) error {
+			for _, r := range haveRepos {
+				if err := walkFn(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	// Expect team Foo to be upgraded to maintain on repo1 and added with maintain to repo2
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(gomock.Any(), "SEEK-Jobs", "repo1", orgbot.GitHubTeamID(100), orgbot.RepoPermissionMaintain).
+		Return(nil)
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(gomock.Any(), "SEEK-Jobs", "repo2", orgbot.GitHubTeamID(100), orgbot.RepoPermissionMaintain).
+		Return(nil)
+
+	// Build the command
+	args := []string{"repos", "update-teams",
+		"--org-name=SEEK-Jobs",
+		"--add-team=Foo=maintain",
+		"--format=quiet"}
+	rootCmd := NewRootCommand(context.Background())
+	rootCmd.SetArgs(args)
+
+	// Run the SUT
+	err := rootCmd.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayDeadLettersCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	lazyPlatform = func() (orgbot.Platform, error) {
+		return plat, nil
+	}
+
+	ctx := context.Background()
+	q := queue.NewMemoryQueue()
+	dls := queue.NewMemoryDeadLetterStore()
+	lazyQueue = func(c *orgbot.Config) (queue.Queue, error) {
+		return q, nil
+	}
+	lazyDeadLetterStore = func(c *orgbot.Config) (queue.DeadLetterStore, error) {
+		return dls, nil
+	}
+
+	for _, id := range []string{"delivery-1", "delivery-2"} {
+		d := &queue.DeadLetter{
+			Message:  queue.Message{InstallationID: 123, EventType: "team", DeliveryID: id, Payload: "{}"},
+			Attempts: 5,
+			Error:    "boom",
+		}
+		if err := dls.Put(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Build the command
+	args := []string{"events", "dlq", "replay", "delivery-2", "--format=quiet"}
+	rootCmd := NewRootCommand(ctx)
+	rootCmd.SetArgs(args)
+
+	// Run the SUT
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify that the replayed event is back on the queue and no longer dead-lettered
+	m, _, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.DeduplicationID() == m.DeliveryID {
+		t.Errorf("Expected replay to be deduplicated separately from the original delivery")
+	}
+	m.ReplayID = ""
+	m.SentAt = time.Time{}
+	m.ReceivedAt = time.Time{}
+
+	want := &queue.Message{InstallationID: 123, EventType: "team", DeliveryID: "delivery-2", Payload: "{}", Attempts: 1}
+	if diff := cmp.Diff(want, m); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	remaining, err := dls.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].Message.DeliveryID != "delivery-1" {
+		t.Errorf("Expected only delivery-1 to remain dead-lettered, got %d dead letters", len(remaining))
+	}
+}
+
+func TestReplayArchiveCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	lazyPlatform = func() (orgbot.Platform, error) {
+		return plat, nil
+	}
+
+	ctx := context.Background()
+	archive := queue.NewMemoryArchive()
+	lazyArchive = func(c *orgbot.Config) (queue.Archive, error) {
+		return archive, nil
+	}
+
+	now := time.Now().UTC()
+	for i, id := range []string{"delivery-1", "delivery-2", "delivery-3"} {
+		m := &queue.Message{
+			InstallationID: 123,
+			EventType:      "team",
+			DeliveryID:     id,
+			Payload:        "{}",
+			DeliveredAt:    now.Add(time.Duration(i-2) * time.Hour),
+		}
+		if err := archive.Put(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"delivery", []string{"--delivery=delivery-1", "--delivery=delivery-3"}, []string{"delivery-1", "delivery-3"}},
+		{"since", []string{"--since=90m"}, []string{"delivery-2", "delivery-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := queue.NewMemoryQueue()
+			lazyQueue = func(c *orgbot.Config) (queue.Queue, error) {
+				return q, nil
+			}
+
+			// Build the command
+			args := append([]string{"events", "replay", "--format=quiet"}, tt.args...)
+			rootCmd := NewRootCommand(ctx)
+			rootCmd.SetArgs(args)
+
+			// Run the SUT
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Verify that the replayed events are back on the queue in the order they were delivered
+			var got []string
+			for {
+				m, handle, err := q.Receive(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m == nil {
+					break
+				}
+				if m.DeduplicationID() == m.DeliveryID {
+					t.Errorf("Expected replay of %s to be deduplicated separately from the original delivery", m.DeliveryID)
+				}
+				got = append(got, m.DeliveryID)
+				if err := q.Delete(ctx, handle); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSimulateEventCommandDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	lazyPlatform = func() (orgbot.Platform, error) {
+		return plat, nil
+	}
+
+	// Topics are disambiguated against the teams of the org
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return(nil, nil).
+		AnyTimes()
+
+	// Return the repo the team was added to, but don't expect its topics to be updated
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(gomock.Any(), "SEEK-Jobs", "repo1").
+		Return(&orgbot.Repo{
+			Name:  "repo1",
+			Teams: []*orgbot.TeamPermission{{TeamName: "Foo", Permission: orgbot.RepoPermissionAdmin}},
+		}, nil)
+
+	// Build the command
+	args := []string{"events", "simulate", "--file=test_data/team_event.json", "--event=team", "--dry-run", "--format=quiet"}
+	rootCmd := NewRootCommand(context.Background())
+	rootCmd.SetArgs(args)
+
+	// Run the SUT
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
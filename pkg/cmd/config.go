@@ -28,18 +28,132 @@ func loadConfig(sess *session.Session) (*orgbot.Config, error) {
 		return nil, err
 	}
 
+	queueBackend, err := LookupQueueBackend()
+	if err != nil {
+		return nil, err
+	}
+
 	queueURL, err := LookupQueueURL()
 	if err != nil {
 		return nil, err
 	}
 
+	queueDir, err := LookupQueueDir()
+	if err != nil {
+		return nil, err
+	}
+
+	workers, err := LookupWorkers()
+	if err != nil {
+		return nil, err
+	}
+
+	coalesceWindow, err := LookupCoalesceWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts, err := LookupMaxAttempts()
+	if err != nil {
+		return nil, err
+	}
+
+	retryBackoff, err := LookupRetryBackoff()
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetterBucket, err := LookupDeadLetterBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	payloadBucket, err := LookupPayloadBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	payloadThreshold, err := LookupPayloadThreshold()
+	if err != nil {
+		return nil, err
+	}
+
+	archiveBucket, err := LookupArchiveBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	maxUnownedRepos, err := LookupMaxUnownedRepos()
+	if err != nil {
+		return nil, err
+	}
+
+	topicSchemes, err := LookupTopicSchemes()
+	if err != nil {
+		return nil, err
+	}
+
+	orgRepo, err := LookupOrgRepo()
+	if err != nil {
+		return nil, err
+	}
+
+	orgRepoBranch, err := LookupOrgRepoBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	orgRepoDir, err := LookupOrgRepoDir()
+	if err != nil {
+		return nil, err
+	}
+
+	approverTeam, err := LookupApproverTeam()
+	if err != nil {
+		return nil, err
+	}
+
+	defaultRepoTeams, err := LookupDefaultRepoTeams()
+	if err != nil {
+		return nil, err
+	}
+
+	archivedReadOnly, err := LookupArchivedReadOnly()
+	if err != nil {
+		return nil, err
+	}
+
+	enforcedOrgs, err := LookupEnforcedOrgs()
+	if err != nil {
+		return nil, err
+	}
+
 	return &orgbot.Config{
 		Name:              build.Name,
 		Version:           build.Version,
 		MetricsInterval:   metricsInterval,
 		GitHubAuditBucket: gitHubAuditBucket,
 		GitHubAppConfig:   *appConfig,
+		QueueBackend:      queueBackend,
 		QueueURL:          queueURL,
+		QueueDir:          queueDir,
+		Workers:           workers,
+		CoalesceWindow:    coalesceWindow,
+		MaxAttempts:       maxAttempts,
+		RetryBackoff:      retryBackoff,
+		DeadLetterBucket:  deadLetterBucket,
+		PayloadBucket:     payloadBucket,
+		PayloadThreshold:  payloadThreshold,
+		ArchiveBucket:     archiveBucket,
+		MaxUnownedRepos:   maxUnownedRepos,
+		TopicSchemes:      topicSchemes,
+		OrgRepo:           orgRepo,
+		OrgRepoBranch:     orgRepoBranch,
+		OrgRepoDir:        orgRepoDir,
+		ApproverTeam:      approverTeam,
+		DefaultRepoTeams:  defaultRepoTeams,
+		ArchivedReadOnly:  archivedReadOnly,
+		EnforcedOrgs:      enforcedOrgs,
 	}, nil
 }
 
@@ -3,26 +3,61 @@ package cmd
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
 )
 
 const (
-	regionEnvKey          = "REGION"
-	httpPortEnvKey        = "PORT"
-	configSecretEnvKey    = "CONFIG_SECRET_ID"
-	gitHubAuditEnvKey     = "GITHUB_AUDIT_BUCKET"
-	queueURLEnvKey        = "QUEUE_URL"
-	metricsIntervalEnvKey = "METRICS_INTERVAL"
+	regionEnvKey           = "REGION"
+	httpPortEnvKey         = "PORT"
+	shutdownTimeoutEnvKey  = "SHUTDOWN_TIMEOUT"
+	configSecretEnvKey     = "CONFIG_SECRET_ID"
+	gitHubAuditEnvKey      = "GITHUB_AUDIT_BUCKET"
+	queueBackendEnvKey     = "QUEUE_BACKEND"
+	queueURLEnvKey         = "QUEUE_URL"
+	queueDirEnvKey         = "QUEUE_DIR"
+	workersEnvKey          = "WORKERS"
+	coalesceWindowEnvKey   = "COALESCE_WINDOW"
+	maxAttemptsEnvKey      = "MAX_ATTEMPTS"
+	retryBackoffEnvKey     = "RETRY_BACKOFF"
+	deadLetterBucketEnvKey = "DEAD_LETTER_BUCKET"
+	payloadBucketEnvKey    = "PAYLOAD_BUCKET"
+	payloadThresholdEnvKey = "PAYLOAD_THRESHOLD"
+	archiveBucketEnvKey    = "ARCHIVE_BUCKET"
+	metricsIntervalEnvKey  = "METRICS_INTERVAL"
+	maxUnownedReposEnvKey  = "MAX_UNOWNED_REPOS"
+	topicSchemesEnvKey     = "TOPIC_SCHEMES"
+	orgRepoEnvKey          = "ORG_REPO"
+	orgRepoBranchEnvKey    = "ORG_REPO_BRANCH"
+	orgRepoDirEnvKey       = "ORG_REPO_DIR"
+	approverTeamEnvKey     = "APPROVER_TEAM"
+	defaultRepoTeamsEnvKey = "DEFAULT_REPO_TEAMS"
+	archivedReadOnlyEnvKey = "ARCHIVED_READ_ONLY"
+	enforcedOrgsEnvKey     = "ENFORCED_ORGS"
 
 	// Defaults config values
 	defaultRegion            = "ap-southeast-2"
 	defaultHttpPort          = "8000"
+	defaultShutdownTimeout   = "30s"
 	defaultConfigSecretId    = "orgbot/config"
 	defaultGitHubAuditBucket = "sec-github-audit"
+	defaultQueueBackend      = "sqs"
 	defaultQueueURL          = "https://sqs.ap-southeast-2.amazonaws.com/547523876443/orgbot.fifo"
+	defaultQueueDir          = "queue"
+	defaultWorkers           = "4"
+	defaultCoalesceWindow    = "5s"
+	defaultMaxAttempts       = "5"
+	defaultRetryBackoff      = "30s"
+	defaultPayloadThreshold  = "204800"
 	defaultMetricsInterval   = "30s"
+	defaultMaxUnownedRepos   = "-1"
+	defaultOrgRepoBranch     = "master"
+	defaultArchivedReadOnly  = "false"
 )
 
 func LookupRegion() (string, error) {
@@ -39,6 +74,13 @@ func LookupHttpPort() (int, error) {
 	return httpPort, nil
 }
 
+// LookupShutdownTimeout returns how long the server waits for webhook requests and the event being
+// handled to finish when shutting down.
+func LookupShutdownTimeout() (time.Duration, error) {
+	v := configValue(shutdownTimeoutEnvKey, defaultShutdownTimeout)
+	return time.ParseDuration(v)
+}
+
 func LookupConfigSecretID() (string, error) {
 	return configValue(configSecretEnvKey, defaultConfigSecretId), nil
 }
@@ -52,10 +94,157 @@ func LookupMetricsInterval() (time.Duration, error) {
 	return time.ParseDuration(v)
 }
 
+func LookupMaxUnownedRepos() (int, error) {
+	v := configValue(maxUnownedReposEnvKey, defaultMaxUnownedRepos)
+	maxUnownedRepos, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.Errorf("bad maximum number of unowned repos: %s", v)
+	}
+
+	return maxUnownedRepos, nil
+}
+
+// LookupTopicSchemes returns the topic schemes configured as a JSON array, or nil if none
+// have been configured so that the default schemes are used.
+func LookupTopicSchemes() ([]*orgbot.TopicScheme, error) {
+	v := configValue(topicSchemesEnvKey, "")
+	if v == "" {
+		return nil, nil
+	}
+
+	return orgbot.ParseTopicSchemes([]byte(v))
+}
+
+// LookupOrgRepo returns the full name of the repo containing the org configuration in the
+// form "owner/name", or an empty string if org repo events are not handled.
+func LookupOrgRepo() (string, error) {
+	v := configValue(orgRepoEnvKey, "")
+	if v != "" && len(strings.Split(v, "/")) != 2 {
+		return "", errors.Errorf("bad org repo name: %s", v)
+	}
+
+	return v, nil
+}
+
+func LookupOrgRepoBranch() (string, error) {
+	return configValue(orgRepoBranchEnvKey, defaultOrgRepoBranch), nil
+}
+
+func LookupOrgRepoDir() (string, error) {
+	return configValue(orgRepoDirEnvKey, ""), nil
+}
+
+func LookupApproverTeam() (string, error) {
+	return configValue(approverTeamEnvKey, ""), nil
+}
+
+// LookupDefaultRepoTeams returns the teams granted permissions on new repos configured as a JSON
+// array, or nil if none have been configured.
+func LookupDefaultRepoTeams() ([]*orgbot.RepoTeam, error) {
+	v := configValue(defaultRepoTeamsEnvKey, "")
+	if v == "" {
+		return nil, nil
+	}
+
+	return orgbot.ParseRepoTeams([]byte(v))
+}
+
+func LookupArchivedReadOnly() (bool, error) {
+	v := configValue(archivedReadOnlyEnvKey, defaultArchivedReadOnly)
+	archivedReadOnly, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.Errorf("bad archived read-only flag: %s", v)
+	}
+
+	return archivedReadOnly, nil
+}
+
+// LookupEnforcedOrgs returns the names of the orgs whose teams are reverted when changed outside of
+// orgbot, configured as a comma separated list, or nil if enforcement is disabled.
+func LookupEnforcedOrgs() ([]string, error) {
+	var orgs []string
+	for _, o := range strings.Split(configValue(enforcedOrgsEnvKey, ""), ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			orgs = append(orgs, o)
+		}
+	}
+
+	return orgs, nil
+}
+
+// LookupQueueBackend returns the name of the queue implementation, one of "sqs", "memory" or "file".
+func LookupQueueBackend() (string, error) {
+	v := configValue(queueBackendEnvKey, defaultQueueBackend)
+	switch queue.Backend(v) {
+	case queue.BackendSQS, queue.BackendMemory, queue.BackendFile:
+		return v, nil
+	default:
+		return "", errors.Errorf("bad queue backend: %s", v)
+	}
+}
+
 func LookupQueueURL() (string, error) {
 	return configValue(queueURLEnvKey, defaultQueueURL), nil
 }
 
+func LookupQueueDir() (string, error) {
+	return configValue(queueDirEnvKey, defaultQueueDir), nil
+}
+
+func LookupWorkers() (int, error) {
+	v := configValue(workersEnvKey, defaultWorkers)
+	workers, err := strconv.Atoi(v)
+	if err != nil || workers < 1 {
+		return 0, errors.Errorf("bad number of workers: %s", v)
+	}
+
+	return workers, nil
+}
+
+// LookupCoalesceWindow returns the debounce window for coalescing events, which must be well within the
+// visibility timeout of the queue as the first event of a burst stays in flight until the window elapses.
+func LookupCoalesceWindow() (time.Duration, error) {
+	v := configValue(coalesceWindowEnvKey, defaultCoalesceWindow)
+	return time.ParseDuration(v)
+}
+
+func LookupMaxAttempts() (int, error) {
+	v := configValue(maxAttemptsEnvKey, defaultMaxAttempts)
+	maxAttempts, err := strconv.Atoi(v)
+	if err != nil || maxAttempts < 1 {
+		return 0, errors.Errorf("bad maximum number of attempts: %s", v)
+	}
+
+	return maxAttempts, nil
+}
+
+func LookupRetryBackoff() (time.Duration, error) {
+	v := configValue(retryBackoffEnvKey, defaultRetryBackoff)
+	return time.ParseDuration(v)
+}
+
+func LookupDeadLetterBucket() (string, error) {
+	return configValue(deadLetterBucketEnvKey, ""), nil
+}
+
+func LookupPayloadBucket() (string, error) {
+	return configValue(payloadBucketEnvKey, ""), nil
+}
+
+func LookupPayloadThreshold() (int, error) {
+	v := configValue(payloadThresholdEnvKey, defaultPayloadThreshold)
+	threshold, err := strconv.Atoi(v)
+	if err != nil || threshold < 1 {
+		return 0, errors.Errorf("bad payload threshold: %s", v)
+	}
+
+	return threshold, nil
+}
+
+func LookupArchiveBucket() (string, error) {
+	return configValue(archiveBucketEnvKey, ""), nil
+}
+
 func configValue(envKey, defaultValue string) string {
 	if v, ok := os.LookupEnv(envKey); ok {
 		return v
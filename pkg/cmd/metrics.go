@@ -17,6 +17,11 @@ type MetricsReporterFunc func()
 // metricsRegistry provides the metrics.Registry implementation that metrics are reported to.
 var metricsRegistry = metrics.NewRegistry()
 
+// MetricsRegistry returns the metrics.Registry that metrics are reported to.
+func MetricsRegistry() metrics.Registry {
+	return metricsRegistry
+}
+
 // noFilter provides an implementation of go-cloudwatch-metrics config.Filter as the
 // the config.NoFilter provided by that package logs incessantly.
 type noFilter struct{}
@@ -49,7 +49,10 @@ func NewPlatform() (orgbot.Platform, MetricsReporterFunc, error) {
 		return nil, nil, err
 	}
 
-	ruleEngine := orgbot.NewRuleEngine(orgbot.NewReadOnlyGitHubService(gitHubService))
+	ruleEngine := orgbot.NewRuleEngine(orgbot.NewReadOnlyGitHubService(gitHubService), config.TopicSchemes)
+	if config.MaxUnownedRepos >= 0 {
+		ruleEngine.AddRepoRule(orgbot.NewUnownedReposRule(orgbot.NewReadOnlyGitHubService(gitHubService), config.MaxUnownedRepos))
+	}
 
 	return &platform{
 		config:        config,
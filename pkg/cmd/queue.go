@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/aws"
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+const (
+	// deadLetterDir is the directory within the queue directory where the file queue's dead letters are stored
+	deadLetterDir = "dead-letters"
+	// deadLetterPrefix is the key prefix of the objects in the dead letter bucket
+	deadLetterPrefix = "dead-letters"
+	// payloadDir is the directory within the queue directory where the file queue's payloads are stored
+	payloadDir = "payloads"
+	// payloadPrefix is the key prefix of the objects in the payload bucket
+	payloadPrefix = "payloads"
+	// archiveDir is the directory within the queue directory where the file queue's archive is stored
+	archiveDir = "archive"
+	// archivePrefix is the key prefix of the objects in the archive bucket
+	archivePrefix = "archive"
+)
+
+// NewQueue returns the queue.Queue implementation selected by the specified config. The sqs queue puts
+// the payloads of messages above the configured threshold in the blob store returned by NewBlobStore, as
+// SQS limits the size of messages.
+func NewQueue(c *orgbot.Config) (queue.Queue, error) {
+	switch queue.Backend(c.QueueBackend) {
+	case queue.BackendSQS:
+		sess, err := NewAWSSession()
+		if err != nil {
+			return nil, err
+		}
+
+		blobs, err := NewBlobStore(c)
+		if err != nil {
+			return nil, err
+		}
+		return queue.NewOffloadingQueue(queue.NewSQSQueue(sqs.New(sess), c.QueueURL, c.Name), blobs, c.PayloadThreshold), nil
+
+	case queue.BackendMemory:
+		return queue.NewMemoryQueue(), nil
+
+	case queue.BackendFile:
+		return queue.NewFileQueue(c.QueueDir)
+
+	default:
+		return nil, errors.Errorf("bad queue backend: %s", c.QueueBackend)
+	}
+}
+
+// NewDeadLetterStore returns the queue.DeadLetterStore implementation that accompanies the queue
+// selected by the specified config.
+func NewDeadLetterStore(c *orgbot.Config) (queue.DeadLetterStore, error) {
+	switch queue.Backend(c.QueueBackend) {
+	case queue.BackendSQS:
+		if c.DeadLetterBucket == "" {
+			return nil, errors.Errorf("%s must be set when using the sqs queue", deadLetterBucketEnvKey)
+		}
+
+		sess, err := NewAWSSession()
+		if err != nil {
+			return nil, err
+		}
+		return queue.NewS3DeadLetterStore(aws.NewS3(sess), c.DeadLetterBucket, deadLetterPrefix), nil
+
+	case queue.BackendMemory:
+		return queue.NewMemoryDeadLetterStore(), nil
+
+	case queue.BackendFile:
+		return queue.NewFileDeadLetterStore(filepath.Join(c.QueueDir, deadLetterDir))
+
+	default:
+		return nil, errors.Errorf("bad queue backend: %s", c.QueueBackend)
+	}
+}
+
+// NewBlobStore returns the queue.BlobStore implementation that holds the payloads of the large messages
+// of the queue selected by the specified config.
+func NewBlobStore(c *orgbot.Config) (queue.BlobStore, error) {
+	switch queue.Backend(c.QueueBackend) {
+	case queue.BackendSQS:
+		if c.PayloadBucket == "" {
+			return nil, errors.Errorf("%s must be set when using the sqs queue", payloadBucketEnvKey)
+		}
+
+		sess, err := NewAWSSession()
+		if err != nil {
+			return nil, err
+		}
+		return queue.NewS3BlobStore(aws.NewS3(sess), c.PayloadBucket, payloadPrefix), nil
+
+	case queue.BackendMemory:
+		return queue.NewMemoryBlobStore(), nil
+
+	case queue.BackendFile:
+		return queue.NewFileBlobStore(filepath.Join(c.QueueDir, payloadDir))
+
+	default:
+		return nil, errors.Errorf("bad queue backend: %s", c.QueueBackend)
+	}
+}
+
+// NewArchive returns the queue.Archive implementation that archives the events submitted to the queue
+// selected by the specified config.
+func NewArchive(c *orgbot.Config) (queue.Archive, error) {
+	switch queue.Backend(c.QueueBackend) {
+	case queue.BackendSQS:
+		if c.ArchiveBucket == "" {
+			return nil, errors.Errorf("%s must be set when using the sqs queue", archiveBucketEnvKey)
+		}
+
+		sess, err := NewAWSSession()
+		if err != nil {
+			return nil, err
+		}
+		return queue.NewS3Archive(aws.NewS3(sess), c.ArchiveBucket, archivePrefix), nil
+
+	case queue.BackendMemory:
+		return queue.NewMemoryArchive(), nil
+
+	case queue.BackendFile:
+		return queue.NewFileArchive(filepath.Join(c.QueueDir, archiveDir))
+
+	default:
+		return nil, errors.Errorf("bad queue backend: %s", c.QueueBackend)
+	}
+}
@@ -107,6 +107,101 @@ func (s *service) editTeam(ctx context.Context, teamID int64, team github.NewTea
 	return t, resp, nil
 }
 
+// checkRunAnnotation is the current form of a check run annotation. The CheckRunAnnotation type in
+// github.com/google/go-github/github/checks.go predates the stable checks API and uses the obsolete
+// filename, blob_href and warning_level properties which are no longer accepted.
+type checkRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Message         string `json:"message"`
+	Title           string `json:"title,omitempty"`
+}
+
+// checkRunOutput is the output of a check run that uses checkRunAnnotation.
+type checkRunOutput struct {
+	Title       string                `json:"title"`
+	Summary     string                `json:"summary"`
+	Text        string                `json:"text,omitempty"`
+	Annotations []*checkRunAnnotation `json:"annotations,omitempty"`
+}
+
+// checkRunOptions is the body used for both creating and updating check runs.
+type checkRunOptions struct {
+	Name        string            `json:"name"`
+	HeadSHA     string            `json:"head_sha,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Conclusion  string            `json:"conclusion,omitempty"`
+	CompletedAt *github.Timestamp `json:"completed_at,omitempty"`
+	Output      *checkRunOutput   `json:"output,omitempty"`
+}
+
+// createCheckRun is a modified copy of CreateCheckRun in github.com/google/go-github/github/checks.go
+// that uses checkRunOptions so that annotations are sent in their current form. When checkRunID is
+// non-zero the existing check run is updated instead, as per UpdateCheckRun.
+func (s *service) createCheckRun(ctx context.Context, owner, repo string, checkRunID int64, opt checkRunOptions) (*github.CheckRun, *github.Response, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	method, u := "POST", fmt.Sprintf("repos/%v/%v/check-runs", owner, repo)
+	if checkRunID != 0 {
+		method, u = "PATCH", fmt.Sprintf("repos/%v/%v/check-runs/%v", owner, repo, checkRunID)
+	}
+
+	req, err := v3.NewRequest(method, u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.antiope-preview+json")
+
+	checkRun := new(github.CheckRun)
+	resp, err := v3.Do(ctx, req, checkRun)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return checkRun, resp, nil
+}
+
+// pullRequestFile is a file changed by a pull request. The CommitFile type in
+// github.com/google/go-github/github/repos_commits.go lacks the previous_filename property of
+// renamed files.
+type pullRequestFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename"`
+}
+
+// listPullRequestFiles is a modified copy of ListFiles in github.com/google/go-github/github/pulls.go
+// that uses pullRequestFile so that the previous paths of renamed files are available.
+func (s *service) listPullRequestFiles(ctx context.Context, owner, repo string, number int, opt *github.ListOptions) ([]*pullRequestFile, *github.Response, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := addOptions(fmt.Sprintf("repos/%v/%v/pulls/%d/files", owner, repo, number), opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := v3.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []*pullRequestFile
+	resp, err := v3.Do(ctx, req, &files)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return files, resp, nil
+}
+
 // addOptions is a copy of addOptions in github.com/google/go-github/github/github.go
 // which we need to support listTeamMembers above but which is private.
 func addOptions(s string, opt interface{}) (string, error) {
@@ -1,9 +1,17 @@
 package github
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +29,10 @@ const (
 	// pageSize is the number of items to return per page in paged responses
 	pageSize = 100
 
+	// maxCheckRunAnnotations is the maximum number of annotations that can be sent in a single check
+	// run request; further annotations must be sent by updating the check run
+	maxCheckRunAnnotations = 50
+
 	// privacyClosed specifies the repository is visible to all members of the organisation
 	privacyClosed = "closed"
 	// privacySecret specifies the repository can only be seen by its members and may not be nested
@@ -48,6 +60,8 @@ type service struct {
 	userCacheMu   sync.Mutex
 	userCacheTime time.Time
 	userCache     userCache
+	loginMu       sync.Mutex
+	login         string
 }
 
 // walkTeamsFunc is the type of the function called for each team in the GitHub org
@@ -78,7 +92,16 @@ type pageInfo struct {
 type repoNode struct {
 	Id               string
 	Name             string
+	Visibility       string
 	IsArchived       bool
+	CreatedAt        githubv4.DateTime
+	PushedAt         *githubv4.DateTime
+	DefaultBranchRef *struct {
+		Name string
+	}
+	PrimaryLanguage *struct {
+		Name string
+	}
 	RepositoryTopics struct {
 		Nodes []topicNode
 	} `graphql:"repositoryTopics(first: $first)"`
@@ -86,14 +109,7 @@ type repoNode struct {
 
 // repoQuery is used to retrieve a single repo from the given org with the name repoName.
 type repoQuery struct {
-	Data struct {
-		Id               string
-		Name             string
-		IsArchived       bool
-		RepositoryTopics struct {
-			Nodes []topicNode
-		} `graphql:"repositoryTopics(first: $first)"`
-	} `graphql:"repository(owner: $org, name: $repoName)"`
+	Data repoNode `graphql:"repository(owner: $org, name: $repoName)"`
 }
 
 // topicNode is the topic information returned by the Graphql API.
@@ -347,11 +363,7 @@ func (s *service) WalkRepos(ctx context.Context, orgName string, walkFn orgbot.W
 		}
 
 		for _, repo := range q.Org.Repositories.Nodes {
-			if repo.IsArchived {
-				continue // Skip archived repositories
-			}
-
-			orgRepo, err := s.asDomainRepo(ctx, orgName, repo.Name, topicNodeToStringArray(repo.RepositoryTopics.Nodes))
+			orgRepo, err := s.withRepoTeams(ctx, orgName, asDomainRepo(repo))
 			if err != nil {
 				return err
 			}
@@ -370,7 +382,7 @@ func (s *service) WalkRepos(ctx context.Context, orgName string, walkFn orgbot.W
 	return nil
 }
 
-// WalkRepos implements orgbot.GithubService
+// WalkReposByTeam implements orgbot.GithubService
 func (s *service) WalkReposByTeam(ctx context.Context, orgName string, teamID orgbot.GitHubTeamID, walkFn orgbot.WalkReposFunc) error {
 	opts := github.ListOptions{
 		PerPage: pageSize,
@@ -383,11 +395,7 @@ func (s *service) WalkReposByTeam(ctx context.Context, orgName string, teamID or
 		}
 
 		for _, repo := range repos {
-			if *repo.Archived {
-				continue // Skip archived repositories
-			}
-
-			orgRepo, err := s.asDomainRepo(ctx, orgName, *repo.Name, repo.Topics)
+			orgRepo, err := s.withRepoTeams(ctx, orgName, asDomainRESTRepo(repo))
 			if err != nil {
 				return err
 			}
@@ -425,7 +433,7 @@ func (s *service) RepoByName(ctx context.Context, orgName, repoName string) (*or
 		return nil, err
 	}
 
-	orgRepo, err := s.asDomainRepo(ctx, orgName, q.Data.Name, topicNodeToStringArray(q.Data.RepositoryTopics.Nodes))
+	orgRepo, err := s.withRepoTeams(ctx, orgName, asDomainRepo(q.Data))
 	if err != nil {
 		return nil, err
 	}
@@ -433,6 +441,41 @@ func (s *service) RepoByName(ctx context.Context, orgName, repoName string) (*or
 	return orgRepo, nil
 }
 
+// ListRepoCollaborators implements orgbot.GithubService
+func (s *service) ListRepoCollaborators(ctx context.Context, orgName, repoName string) ([]*orgbot.CollaboratorPermission, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := github.ListCollaboratorsOptions{
+		Affiliation: "direct",
+		ListOptions: github.ListOptions{PerPage: pageSize},
+	}
+
+	var collaborators []*orgbot.CollaboratorPermission
+	for {
+		users, r, err := v3.Repositories.ListCollaborators(ctx, orgName, repoName, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range users {
+			collaborators = append(collaborators, &orgbot.CollaboratorPermission{
+				Login:      u.GetLogin(),
+				Permission: restPermissionsToDomain(u.Permissions),
+			})
+		}
+
+		if r.NextPage == 0 {
+			break
+		}
+		opts.Page = r.NextPage
+	}
+
+	return collaborators, nil
+}
+
 // UpdateRepoTopics implements orgbot.GithubService
 func (s *service) UpdateRepoTopics(ctx context.Context, orgName, repoName string, topics []string) error {
 	v3, err := s.V3Client(ctx)
@@ -514,15 +557,544 @@ func (s *service) UserByLogin(ctx context.Context, orgName, login string) (*orgb
 	return nil, &orgbot.GitHubUserNotFoundError{OrgName: orgName, Login: login}
 }
 
-// asDomainRepo converts the specified repository information to an orgbot.Repo.
-func (s *service) asDomainRepo(ctx context.Context, orgName, repoName string, topics []string) (*orgbot.Repo, error) {
+// BranchSHA implements orgbot.GitHubService.
+func (s *service) BranchSHA(ctx context.Context, orgName, repoName, branch string) (string, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ref, _, err := v3.Git.GetRef(ctx, orgName, repoName, "heads/"+branch)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not get branch %s of %s/%s", branch, orgName, repoName)
+	}
+
+	return ref.GetObject().GetSHA(), nil
+}
+
+// DownloadDir implements orgbot.GitHubService. The repo is downloaded as a single tarball rather than
+// blob by blob so that the number of requests doesn't grow with the number of files.
+func (s *service) DownloadDir(ctx context.Context, orgName, repoName, ref, dirPath, dir string) error {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	link, _, err := v3.Repositories.GetArchiveLink(ctx, orgName, repoName, github.Tarball, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return errors.Wrapf(err, "could not get archive link of %s/%s at %s", orgName, repoName, ref)
+	}
+
+	// The link is pre-authorised so it's fetched without the credentials of the client
+	req, err := http.NewRequest("GET", link.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrapf(err, "could not download archive of %s/%s at %s", orgName, repoName, ref)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not download archive of %s/%s at %s: %s", orgName, repoName, ref, resp.Status)
+	}
+
+	if err := extractTarball(resp.Body, dirPath, dir); err != nil {
+		return errors.Wrapf(err, "could not extract archive of %s/%s at %s", orgName, repoName, ref)
+	}
+	return nil
+}
+
+// extractTarball writes the regular files beneath the specified path of the gzipped repo archive
+// read from r to the local directory dir, relative to that path. Archive entries are nested within
+// a single top-level directory named after the repo and commit, which is ignored.
+func extractTarball(r io.Reader, dirPath, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	prefix := strings.Trim(path.Clean("/"+dirPath), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		// Strip the top-level directory and only keep files beneath the prefix
+		parts := strings.SplitN(path.Clean(hdr.Name), "/", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[1], prefix) {
+			continue
+		}
+		rel := strings.TrimPrefix(parts[1], prefix)
+		if rel == ".." || strings.HasPrefix(rel, "../") {
+			return fmt.Errorf("archive entry %s is outside of the archive", hdr.Name)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := writeFile(target, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// writeFile writes the contents of r to the specified file, creating or truncating it.
+func writeFile(name string, r io.Reader) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// CreateCommitStatus implements orgbot.GitHubService.
+func (s *service) CreateCommitStatus(ctx context.Context, orgName, repoName, sha string, status *orgbot.CommitStatus) error {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	repoStatus := github.RepoStatus{
+		State:       github.String(string(status.State)),
+		Context:     github.String(status.Context),
+		Description: github.String(status.Description),
+	}
+	if status.TargetURL != "" {
+		repoStatus.TargetURL = github.String(status.TargetURL)
+	}
+
+	_, _, err = v3.Repositories.CreateStatus(ctx, orgName, repoName, sha, &repoStatus)
+	return err
+}
+
+// CreateCheckRun implements orgbot.GitHubService.
+func (s *service) CreateCheckRun(ctx context.Context, orgName, repoName string, run *orgbot.CheckRun) error {
+	var annotations []*checkRunAnnotation
+	for _, a := range run.Annotations {
+		annotations = append(annotations, &checkRunAnnotation{
+			Path:            a.Path,
+			StartLine:       a.Line,
+			EndLine:         a.Line,
+			AnnotationLevel: string(a.Level),
+			Message:         a.Message,
+			Title:           a.Title,
+		})
+	}
+
+	// nextAnnotations returns the next batch of annotations to be sent
+	nextAnnotations := func() []*checkRunAnnotation {
+		n := len(annotations)
+		if n > maxCheckRunAnnotations {
+			n = maxCheckRunAnnotations
+		}
+		batch := annotations[:n]
+		annotations = annotations[n:]
+		return batch
+	}
+
+	output := checkRunOutput{
+		Title:       run.Title,
+		Summary:     run.Summary,
+		Text:        run.Text,
+		Annotations: nextAnnotations(),
+	}
+
+	opt := checkRunOptions{
+		Name:        run.Name,
+		HeadSHA:     run.HeadSHA,
+		Status:      "completed",
+		Conclusion:  string(run.Conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      &output,
+	}
+
+	checkRun, _, err := s.createCheckRun(ctx, orgName, repoName, 0, opt)
+	if err != nil {
+		return errors.Wrapf(err, "could not create check run '%s' for %s", run.Name, run.HeadSHA)
+	}
+
+	// Annotations are appended to the existing ones when the check run is updated
+	for len(annotations) > 0 {
+		output.Annotations = nextAnnotations()
+		update := checkRunOptions{Name: run.Name, Output: &output}
+		if _, _, err := s.createCheckRun(ctx, orgName, repoName, checkRun.GetID(), update); err != nil {
+			return errors.Wrapf(err, "could not annotate check run '%s' for %s", run.Name, run.HeadSHA)
+		}
+	}
+
+	return nil
+}
+
+// Login implements orgbot.GitHubService. The login doesn't change so it's only looked up once.
+func (s *service) Login(ctx context.Context) (string, error) {
+	s.loginMu.Lock()
+	defer s.loginMu.Unlock()
+
+	if s.login != "" {
+		return s.login, nil
+	}
+
+	if s.token != "" {
+		v3, err := s.V3Client(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		u, _, err := v3.Users.Get(ctx, "")
+		if err != nil {
+			return "", errors.Wrap(err, "could not get authenticated user")
+		}
+		s.login = u.GetLogin()
+		return s.login, nil
+	}
+
+	// The slug of the app isn't exposed by the client's App type so the response is decoded directly
+	client, err := s.NewAppClient()
+	if err != nil {
+		return "", err
+	}
+	req, err := client.NewRequest("GET", "app", nil)
+	if err != nil {
+		return "", err
+	}
+	var app struct {
+		Slug string `json:"slug"`
+	}
+	if _, err := client.Do(ctx, req, &app); err != nil {
+		return "", errors.Wrap(err, "could not get authenticated app")
+	}
+
+	// Changes made by an app are attributed to its bot user
+	s.login = app.Slug + "[bot]"
+	return s.login, nil
+}
+
+// ListIssueComments implements orgbot.GitHubService.
+func (s *service) ListIssueComments(ctx context.Context, orgName, repoName string, number int) ([]*orgbot.IssueComment, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []*orgbot.IssueComment
+	opts := github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: pageSize}}
+	for {
+		page, resp, err := v3.Issues.ListComments(ctx, orgName, repoName, number, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range page {
+			comments = append(comments, &orgbot.IssueComment{
+				ID:     c.GetID(),
+				Author: c.GetUser().GetLogin(),
+				Body:   c.GetBody(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return comments, nil
+}
+
+// ListOpenIssues implements orgbot.GitHubService.
+func (s *service) ListOpenIssues(ctx context.Context, orgName, repoName string) ([]*orgbot.Issue, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*orgbot.Issue
+	opts := github.IssueListByRepoOptions{State: "open", ListOptions: github.ListOptions{PerPage: pageSize}}
+	for {
+		page, resp, err := v3.Issues.ListByRepo(ctx, orgName, repoName, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range page {
+			// The issues API also returns pull requests
+			if i.IsPullRequest() {
+				continue
+			}
+
+			issues = append(issues, &orgbot.Issue{
+				Number: i.GetNumber(),
+				Title:  i.GetTitle(),
+				Body:   i.GetBody(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return issues, nil
+}
+
+// CreateIssue implements orgbot.GitHubService.
+func (s *service) CreateIssue(ctx context.Context, orgName, repoName, title, body string) (int, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	issue, _, err := v3.Issues.Create(ctx, orgName, repoName, &github.IssueRequest{Title: &title, Body: &body})
+	if err != nil {
+		return 0, err
+	}
+
+	return issue.GetNumber(), nil
+}
+
+// UpdateIssue implements orgbot.GitHubService.
+func (s *service) UpdateIssue(ctx context.Context, orgName, repoName string, number int, body string) error {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = v3.Issues.Edit(ctx, orgName, repoName, number, &github.IssueRequest{Body: &body})
+	return err
+}
+
+// ProposeFileChanges implements orgbot.GitHubService.
+func (s *service) ProposeFileChanges(ctx context.Context, orgName, repoName string, proposal *orgbot.FileChangeProposal) (int, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	base, _, err := v3.Git.GetRef(ctx, orgName, repoName, "heads/"+proposal.Base)
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not get branch %s of %s/%s", proposal.Base, orgName, repoName)
+	}
+
+	branchRef := "refs/heads/" + proposal.Branch
+	if _, resp, err := v3.Git.CreateRef(ctx, orgName, repoName, &github.Reference{
+		Ref:    &branchRef,
+		Object: &github.GitObject{SHA: base.GetObject().SHA},
+	}); err != nil {
+		// The branch already exists if the same changes have been proposed before
+		if resp == nil || resp.StatusCode != http.StatusUnprocessableEntity {
+			return 0, errors.Wrapf(err, "could not create branch %s of %s/%s", proposal.Branch, orgName, repoName)
+		}
+
+		prs, _, err := v3.PullRequests.List(ctx, orgName, repoName, &github.PullRequestListOptions{
+			State: "open",
+			Head:  orgName + ":" + proposal.Branch,
+			Base:  proposal.Base,
+		})
+		if err != nil {
+			return 0, errors.Wrapf(err, "could not list pull requests of branch %s of %s/%s", proposal.Branch, orgName, repoName)
+		}
+		if len(prs) > 0 {
+			return prs[0].GetNumber(), nil
+		}
+	}
+
+	// Commit the files in a consistent order, one commit per file as per the contents API
+	var paths []string
+	for p := range proposal.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		opts := github.RepositoryContentFileOptions{
+			Message: &proposal.Title,
+			Content: proposal.Files[p],
+			Branch:  &proposal.Branch,
+		}
+
+		// Existing files can only be updated given the SHA of their current content
+		existing, _, resp, err := v3.Repositories.GetContents(ctx, orgName, repoName, p, &github.RepositoryContentGetOptions{Ref: proposal.Branch})
+		switch {
+		case err == nil:
+			// Reused branches may already have the changes
+			if content, err := existing.GetContent(); err == nil && content == string(proposal.Files[p]) {
+				continue
+			}
+			opts.SHA = existing.SHA
+			_, _, err = v3.Repositories.UpdateFile(ctx, orgName, repoName, p, &opts)
+		case resp != nil && resp.StatusCode == http.StatusNotFound:
+			_, _, err = v3.Repositories.CreateFile(ctx, orgName, repoName, p, &opts)
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "could not commit %s to %s/%s", p, orgName, repoName)
+		}
+	}
+
+	pr, _, err := v3.PullRequests.Create(ctx, orgName, repoName, &github.NewPullRequest{
+		Title: &proposal.Title,
+		Head:  &proposal.Branch,
+		Base:  &proposal.Base,
+		Body:  &proposal.Body,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not open pull request for %s/%s", orgName, repoName)
+	}
+
+	return pr.GetNumber(), nil
+}
+
+// CreateIssueComment implements orgbot.GitHubService.
+func (s *service) CreateIssueComment(ctx context.Context, orgName, repoName string, number int, body string) error {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = v3.Issues.CreateComment(ctx, orgName, repoName, number, &github.IssueComment{Body: &body})
+	return err
+}
+
+// UpdateIssueComment implements orgbot.GitHubService.
+func (s *service) UpdateIssueComment(ctx context.Context, orgName, repoName string, commentID int64, body string) error {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = v3.Issues.EditComment(ctx, orgName, repoName, commentID, &github.IssueComment{Body: &body})
+	return err
+}
+
+// PullRequestByNumber implements orgbot.GitHubService.
+func (s *service) PullRequestByNumber(ctx context.Context, orgName, repoName string, number int) (*orgbot.PullRequest, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := v3.PullRequests.Get(ctx, orgName, repoName, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return &orgbot.PullRequest{
+		Number:  pr.GetNumber(),
+		Author:  pr.GetUser().GetLogin(),
+		Open:    pr.GetState() == "open",
+		HeadSHA: pr.GetHead().GetSHA(),
+		BaseRef: pr.GetBase().GetRef(),
+	}, nil
+}
+
+// ListPullRequestReviews implements orgbot.GitHubService.
+func (s *service) ListPullRequestReviews(ctx context.Context, orgName, repoName string, number int) ([]*orgbot.PullRequestReview, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []*orgbot.PullRequestReview
+	opts := github.ListOptions{PerPage: pageSize}
+	for {
+		page, resp, err := v3.PullRequests.ListReviews(ctx, orgName, repoName, number, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range page {
+			reviews = append(reviews, &orgbot.PullRequestReview{
+				Author:   r.GetUser().GetLogin(),
+				State:    orgbot.PullRequestReviewState(r.GetState()),
+				CommitID: r.GetCommitID(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return reviews, nil
+}
+
+// MergePullRequest implements orgbot.GitHubService.
+func (s *service) MergePullRequest(ctx context.Context, orgName, repoName string, number int, sha string) (string, error) {
+	v3, err := s.V3Client(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	res, _, err := v3.PullRequests.Merge(ctx, orgName, repoName, number, "", &github.PullRequestOptions{SHA: sha})
+	if err != nil {
+		return "", errors.Wrapf(err, "could not merge pull request #%d of %s/%s", number, orgName, repoName)
+	}
+	if !res.GetMerged() {
+		return "", fmt.Errorf("pull request #%d of %s/%s was not merged: %s", number, orgName, repoName, res.GetMessage())
+	}
+
+	return res.GetSHA(), nil
+}
+
+// ListPullRequestFiles implements orgbot.GitHubService.
+func (s *service) ListPullRequestFiles(ctx context.Context, orgName, repoName string, number int) ([]*orgbot.PullRequestFile, error) {
+	var files []*orgbot.PullRequestFile
+	opts := github.ListOptions{PerPage: pageSize}
+	for {
+		page, resp, err := s.listPullRequestFiles(ctx, orgName, repoName, number, &opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range page {
+			files = append(files, &orgbot.PullRequestFile{
+				Path:         f.Filename,
+				PreviousPath: f.PreviousFilename,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return files, nil
+}
+
+// withRepoTeams returns the specified orgbot.Repo after populating it with the teams that
+// have access to the repository.
+func (s *service) withRepoTeams(ctx context.Context, orgName string, orgRepo *orgbot.Repo) (*orgbot.Repo, error) {
 	v3, err := s.V3Client(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Assume there aren't more than 100 teams to a repo
-	teams, r, err := v3.Repositories.ListTeams(ctx, orgName, repoName, &github.ListOptions{
+	teams, r, err := v3.Repositories.ListTeams(ctx, orgName, orgRepo.Name, &github.ListOptions{
 		PerPage: pageSize,
 	})
 	if err != nil {
@@ -530,12 +1102,7 @@ func (s *service) asDomainRepo(ctx context.Context, orgName, repoName string, to
 	}
 
 	if r.NextPage != 0 {
-		return nil, fmt.Errorf("found more than 100 teams for repo %s", repoName)
-	}
-
-	orgRepo := &orgbot.Repo{
-		Name:   repoName,
-		Topics: topics,
+		return nil, fmt.Errorf("found more than 100 teams for repo %s", orgRepo.Name)
 	}
 
 	for _, team := range teams {
@@ -548,6 +1115,87 @@ func (s *service) asDomainRepo(ctx context.Context, orgName, repoName string, to
 	return orgRepo, nil
 }
 
+// asDomainRepo converts the specified repository information returned by the Graphql API
+// to an orgbot.Repo. Neither the teams nor the collaborators of the repository are populated.
+func asDomainRepo(n repoNode) *orgbot.Repo {
+	createdAt := n.CreatedAt.Time
+
+	orgRepo := &orgbot.Repo{
+		Name:       n.Name,
+		Visibility: orgbot.RepoVisibility(strings.ToLower(n.Visibility)),
+		Archived:   n.IsArchived,
+		CreatedAt:  &createdAt,
+		Topics:     topicNodeToStringArray(n.RepositoryTopics.Nodes),
+	}
+
+	if n.PushedAt != nil {
+		pushedAt := n.PushedAt.Time
+		orgRepo.PushedAt = &pushedAt
+	}
+
+	if n.DefaultBranchRef != nil {
+		orgRepo.DefaultBranch = n.DefaultBranchRef.Name
+	}
+
+	if n.PrimaryLanguage != nil {
+		orgRepo.PrimaryLanguage = n.PrimaryLanguage.Name
+	}
+
+	return orgRepo
+}
+
+// asDomainRESTRepo converts the specified github.Repository returned by the REST API to an
+// orgbot.Repo. Neither the teams nor the collaborators of the repository are populated.
+func asDomainRESTRepo(r *github.Repository) *orgbot.Repo {
+	orgRepo := &orgbot.Repo{
+		Name:            r.GetName(),
+		Visibility:      orgbot.RepoVisibilityPublic,
+		Archived:        r.GetArchived(),
+		DefaultBranch:   r.GetDefaultBranch(),
+		PrimaryLanguage: r.GetLanguage(),
+		Topics:          r.Topics,
+	}
+
+	// The REST API doesn't distinguish between private and internal repositories
+	if r.GetPrivate() {
+		orgRepo.Visibility = orgbot.RepoVisibilityPrivate
+	}
+
+	if r.CreatedAt != nil {
+		createdAt := r.CreatedAt.Time
+		orgRepo.CreatedAt = &createdAt
+	}
+
+	if r.PushedAt != nil {
+		pushedAt := r.PushedAt.Time
+		orgRepo.PushedAt = &pushedAt
+	}
+
+	return orgRepo
+}
+
+// restPermissionsToDomain converts the specified map of permissions returned by the REST API
+// to the highest orgbot.RepoPermission it grants.
+func restPermissionsToDomain(permissions *map[string]bool) orgbot.RepoPermission {
+	if permissions == nil {
+		return ""
+	}
+
+	// Permissions are checked from most to least privileged
+	for _, p := range []orgbot.RepoPermission{
+		orgbot.RepoPermissionAdmin,
+		orgbot.RepoPermissionMaintain,
+		orgbot.RepoPermissionWrite,
+		orgbot.RepoPermissionTriage,
+		orgbot.RepoPermissionRead,
+	} {
+		if (*permissions)[string(p)] {
+			return p
+		}
+	}
+	return ""
+}
+
 // topicNodeToStringArray turns an array of topicNodes, returned by a Graphql query, into an array of strings
 func topicNodeToStringArray(nodes []topicNode) []string {
 	var topics []string
@@ -0,0 +1,287 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+// approvalsCheckName is the name of the check run that verifies that the owners of the teams changed
+// by pull requests to the org repo have approved them
+const approvalsCheckName = "orgbot/approvals"
+
+// handlePullRequestReviewEvent re-evaluates the owner approvals of pull requests to the org repo
+// whenever they are reviewed or reviews are dismissed. Other reviews are ignored.
+func handlePullRequestReviewEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*hub.PullRequestReviewEvent)
+	fullName := event.GetRepo().GetFullName()
+	if !isOrgRepo(p.Config(), fullName) || event.GetPullRequest().GetState() != "open" {
+		log.Ctx(ctx).Debug().Msgf("Ignoring pull request review %s event for repo %s", event.GetAction(), fullName)
+		return nil
+	}
+
+	return publishApprovalsCheck(ctx, p, newPullRequest(event.GetPullRequest()))
+}
+
+// newPullRequest returns the orgbot.PullRequest equivalent of the specified pull request payload.
+func newPullRequest(pr *hub.PullRequest) *orgbot.PullRequest {
+	return &orgbot.PullRequest{
+		Number:  pr.GetNumber(),
+		Author:  pr.GetUser().GetLogin(),
+		Open:    pr.GetState() == "open",
+		HeadSHA: pr.GetHead().GetSHA(),
+		BaseRef: pr.GetBase().GetRef(),
+	}
+}
+
+// publishApprovalsCheck evaluates the owner approvals of the specified pull request to the org repo and
+// publishes the outcome as a check run on its head commit.
+func publishApprovalsCheck(ctx context.Context, p orgbot.Platform, pr *orgbot.PullRequest) error {
+	owner, name, _ := orgRepo(p.Config())
+	log.Ctx(ctx).Info().Msgf("Checking owner approvals of %s of pull request #%d", pr.HeadSHA, pr.Number)
+
+	run, err := checkApprovals(ctx, p, pr)
+	if err != nil {
+		// Let contributors know that the outcome is unknown rather than leaving the check missing
+		run = &orgbot.CheckRun{
+			Name:       approvalsCheckName,
+			HeadSHA:    pr.HeadSHA,
+			Conclusion: orgbot.CheckConclusionNeutral,
+			Title:      "Owner approvals could not be checked",
+			Summary:    err.Error(),
+		}
+		if runErr := p.GitHubService().CreateCheckRun(ctx, owner, name, run); runErr != nil {
+			log.Ctx(ctx).Error().Err(runErr).Msg("Failed to report approvals check failure")
+		}
+		return errors.Wrapf(err, "failed to check approvals of pull request #%d", pr.Number)
+	}
+
+	return p.GitHubService().CreateCheckRun(ctx, owner, name, run)
+}
+
+// orgLevelApproval is the name under which the approval of the changes to files that can't be traced
+// to an existing team, such as org.yaml or the directories of new top-level teams, is described
+const orgLevelApproval = "(org)"
+
+// teamApproval is the outcome of checking the approvals of a team changed by a pull request.
+type teamApproval struct {
+	team       string   // Name of the team as of the base branch, or orgLevelApproval
+	owners     []string // Email addresses of the users that may approve changes to the team
+	approvedBy string   // Login of the user that approved the changes (empty if unapproved)
+}
+
+// isOrgLevel returns whether the approval is of changes that can't be traced to an existing team, which
+// always require the approval of a member of the approver team.
+func (t *teamApproval) isOrgLevel() bool {
+	return t.team == orgLevelApproval
+}
+
+// checkApprovals returns a check run that succeeds only if an owner of each team whose files are changed
+// by the specified pull request, or of one of its ancestors, has approved its head commit. Teams and owners are taken
+// from the base branch so that pull requests can't approve themselves by adding owners. Teams without
+// owners require the approval of a member of the approver team, if one has been configured. Changes to
+// files that can't be traced to an existing team always require the approval of a member of the approver
+// team, so can't be approved if none has been configured.
+func checkApprovals(ctx context.Context, p orgbot.Platform, pr *orgbot.PullRequest) (*orgbot.CheckRun, error) {
+	c := p.Config()
+	owner, name, _ := orgRepo(c)
+	svc := p.GitHubService()
+
+	teams, err := changedTeams(ctx, p, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	run := orgbot.CheckRun{Name: approvalsCheckName, HeadSHA: pr.HeadSHA}
+	if len(teams) == 0 {
+		run.Conclusion = orgbot.CheckConclusionSuccess
+		run.Title = "No org configuration is changed"
+		run.Summary = "This pull request doesn't change the org configuration so no owner approvals are required."
+		return &run, nil
+	}
+
+	reviews, err := svc.ListPullRequestReviews(ctx, owner, name, pr.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the latest review of each reviewer counts, as subsequent reviews supersede it, and only if it
+	// approves the head commit as later commits haven't been reviewed
+	var approvers []string
+	for login, r := range latestReviews(pr, reviews) {
+		if r.State == orgbot.ReviewApproved && r.CommitID == pr.HeadSHA {
+			approvers = append(approvers, login)
+		}
+	}
+	sort.Strings(approvers)
+
+	approverEmails := map[string]string{}
+	for _, login := range approvers {
+		u, err := svc.UserByLogin(ctx, owner, login)
+		if err != nil {
+			if _, ok := err.(*orgbot.GitHubUserNotFoundError); ok {
+				continue
+			}
+			return nil, err
+		}
+		approverEmails[login] = strings.ToLower(u.Email)
+	}
+
+	var unapproved int
+	for _, t := range teams {
+		if len(t.owners) > 0 {
+			t.approvedBy = approvedByOwner(approvers, approverEmails, t.owners)
+		} else if c.ApproverTeam != "" {
+			for _, login := range approvers {
+				member, err := isTeamMember(ctx, p, owner, c.ApproverTeam, login)
+				if err != nil {
+					return nil, err
+				}
+				if member {
+					t.approvedBy = login
+					break
+				}
+			}
+		} else if !t.isOrgLevel() {
+			// Nobody is responsible for the team so there's nobody whose approval is required
+			continue
+		}
+
+		if t.approvedBy == "" {
+			unapproved++
+		}
+	}
+
+	if unapproved > 0 {
+		run.Conclusion = orgbot.CheckConclusionFailure
+		run.Title = fmt.Sprintf("%d team(s) awaiting owner approval", unapproved)
+	} else {
+		run.Conclusion = orgbot.CheckConclusionSuccess
+		run.Title = "All changed teams are approved by their owners"
+	}
+	run.Summary = describeTeamApprovals(teams, c.ApproverTeam)
+
+	return &run, nil
+}
+
+// approvedByOwner returns the login of the first of the specified approvers whose email address is one
+// of the specified owners, or an empty string if there is no such approver.
+func approvedByOwner(approvers []string, approverEmails map[string]string, owners []string) string {
+	for _, login := range approvers {
+		for _, o := range owners {
+			if approverEmails[login] != "" && approverEmails[login] == strings.ToLower(o) {
+				return login
+			}
+		}
+	}
+	return ""
+}
+
+// changedTeams returns the teams of the base branch of the specified pull request whose files are changed
+// by it, sorted by name. Files of new teams are attributed to their nearest existing ancestor, and files
+// without one, such as org.yaml or those of new top-level teams, to an org-level approval.
+func changedTeams(ctx context.Context, p orgbot.Platform, pr *orgbot.PullRequest) ([]*teamApproval, error) {
+	c := p.Config()
+	owner, name, _ := orgRepo(c)
+
+	files, err := p.GitHubService().ListPullRequestFiles(ctx, owner, name, pr.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := checkoutOrgRepo(ctx, p, pr.BaseRef)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	org, err := orgbot.MergeOrg(p.Codec(), dir)
+	if err != nil {
+		return nil, errors.Wrapf(relativiseMergeError(err, dir), "failed to merge base branch %s", pr.BaseRef)
+	}
+
+	teamFiles, err := orgbot.TeamFiles(p.Codec(), dir)
+	if err != nil {
+		return nil, err
+	}
+
+	teamsByFile := map[string]string{}
+	for t, paths := range teamFiles {
+		for _, f := range paths {
+			teamsByFile[f] = t
+		}
+	}
+
+	owners := orgbot.TeamOwners(org)
+	approvals := map[string]*teamApproval{}
+	for _, f := range files {
+		for _, repoPath := range []string{f.Path, f.PreviousPath} {
+			rel, ok := orgDirPath(c, repoPath)
+			if !ok || repoPath == "" {
+				continue
+			}
+
+			// Walk up the directory hierarchy until a team that exists in the base branch is found
+			t := orgLevelApproval
+			for d := path.Dir(rel); d != "." && d != "/"; d = path.Dir(d) {
+				if team, ok := teamsByFile[path.Join(d, "team.yaml")]; ok {
+					t = team
+					break
+				}
+			}
+
+			if _, ok := approvals[t]; !ok {
+				approvals[t] = &teamApproval{team: t, owners: owners[t]}
+			}
+		}
+	}
+
+	var teams []*teamApproval
+	for _, t := range approvals {
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].team < teams[j].team
+	})
+
+	return teams, nil
+}
+
+// describeTeamApprovals returns a markdown description of the specified team approvals.
+func describeTeamApprovals(teams []*teamApproval, approverTeam string) string {
+	var b strings.Builder
+	b.WriteString("| Team | Approvers | Approved by |\n|---|---|---|\n")
+	for _, t := range teams {
+		approvers := strings.Join(t.owners, ", ")
+		if len(t.owners) == 0 {
+			switch {
+			case approverTeam != "":
+				approvers = fmt.Sprintf("members of `%s`", approverTeam)
+			case t.isOrgLevel():
+				approvers = "(no approver team configured)"
+			default:
+				approvers = "(no owners)"
+			}
+		}
+
+		approvedBy := ":x:"
+		switch {
+		case t.approvedBy != "":
+			approvedBy = "@" + t.approvedBy
+		case len(t.owners) == 0 && approverTeam == "" && !t.isOrgLevel():
+			approvedBy = "not required"
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", t.team, approvers, approvedBy)
+	}
+	return b.String()
+}
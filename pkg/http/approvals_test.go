@@ -0,0 +1,185 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+// writeOrgRepo returns a DownloadDir implementation that writes the specified files of the org repo.
+func writeOrgRepo(files map[string]string) func(context.Context, string, string, string, string, string) error {
+	return func(ctx context.Context, orgName, repoName, ref, path, dir string) error {
+		for name, content := range files {
+			target := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(target, []byte(content), 0644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestCheckApprovalsOnlyCountsHeadApprovals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	ctx := context.Background()
+
+	pr := &orgbot.PullRequest{Number: 7, Author: "author", Open: true, HeadSHA: "head", BaseRef: "master"}
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListPullRequestFiles(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.PullRequestFile{{Path: "team-a/team.yaml"}}, nil).
+		Times(2)
+	plat.MockGitHubService.
+		EXPECT().
+		DownloadDir(gomock.Any(), "SEEK-Jobs", "org", "master", "", gomock.Any()).
+		DoAndReturn(writeOrgRepo(map[string]string{
+			"org.yaml":         "name: SEEK-Jobs\n",
+			"team-a/team.yaml": "name: Team A\nowners:\n- owner@seek.com.au\n",
+		})).
+		Times(2)
+
+	// The owner approved a commit that has since been superseded
+	plat.MockGitHubService.
+		EXPECT().
+		ListPullRequestReviews(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.PullRequestReview{{Author: "owner", State: orgbot.ReviewApproved, CommitID: "older"}}, nil)
+
+	run, err := checkApprovals(ctx, plat, pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Conclusion != orgbot.CheckConclusionFailure {
+		t.Errorf("Expected stale approval not to count, got %s: %s", run.Conclusion, run.Title)
+	}
+
+	// The owner approves the head commit
+	plat.MockGitHubService.
+		EXPECT().
+		ListPullRequestReviews(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.PullRequestReview{
+			{Author: "owner", State: orgbot.ReviewApproved, CommitID: "older"},
+			{Author: "owner", State: orgbot.ReviewApproved, CommitID: "head"},
+		}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		UserByLogin(gomock.Any(), "SEEK-Jobs", "owner").
+		Return(&orgbot.GitHubUser{Login: "owner", Email: "Owner@seek.com.au"}, nil)
+
+	run, err = checkApprovals(ctx, plat, pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Conclusion != orgbot.CheckConclusionSuccess {
+		t.Errorf("Expected head approval to count, got %s: %s", run.Conclusion, run.Title)
+	}
+}
+
+func TestCheckApprovalsRequiresApproverTeamForUntracedFiles(t *testing.T) {
+	tests := []struct {
+		name           string
+		file           string
+		approverTeam   string
+		approver       string
+		wantConclusion orgbot.CheckConclusion
+	}{
+		{
+			name:           "new top-level team",
+			file:           "team-b/team.yaml",
+			approverTeam:   "Org Admins",
+			wantConclusion: orgbot.CheckConclusionFailure,
+		},
+		{
+			name:           "new top-level team approved by approver team",
+			file:           "team-b/team.yaml",
+			approverTeam:   "Org Admins",
+			approver:       "admin",
+			wantConclusion: orgbot.CheckConclusionSuccess,
+		},
+		{
+			name:           "org file approved by approver team",
+			file:           "org.yaml",
+			approverTeam:   "Org Admins",
+			approver:       "admin",
+			wantConclusion: orgbot.CheckConclusionSuccess,
+		},
+		{
+			name:           "org file without approver team",
+			file:           "repos.yaml",
+			approver:       "admin",
+			wantConclusion: orgbot.CheckConclusionFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			plat := orgbot.NewTestPlatform(ctrl)
+			plat.Config().OrgRepo = "SEEK-Jobs/org"
+			plat.Config().ApproverTeam = tt.approverTeam
+			ctx := context.Background()
+
+			pr := &orgbot.PullRequest{Number: 7, Author: "author", Open: true, HeadSHA: "head", BaseRef: "master"}
+
+			plat.MockGitHubService.
+				EXPECT().
+				ListPullRequestFiles(gomock.Any(), "SEEK-Jobs", "org", 7).
+				Return([]*orgbot.PullRequestFile{{Path: tt.file}}, nil)
+			plat.MockGitHubService.
+				EXPECT().
+				DownloadDir(gomock.Any(), "SEEK-Jobs", "org", "master", "", gomock.Any()).
+				DoAndReturn(writeOrgRepo(map[string]string{
+					"org.yaml":         "name: SEEK-Jobs\n",
+					"team-a/team.yaml": "name: Team A\nowners:\n- owner@seek.com.au\n",
+				}))
+
+			var reviews []*orgbot.PullRequestReview
+			if tt.approver != "" {
+				reviews = append(reviews, &orgbot.PullRequestReview{Author: tt.approver, State: orgbot.ReviewApproved, CommitID: "head"})
+				plat.MockGitHubService.
+					EXPECT().
+					UserByLogin(gomock.Any(), "SEEK-Jobs", tt.approver).
+					Return(&orgbot.GitHubUser{Login: tt.approver, Email: tt.approver + "@seek.com.au"}, nil)
+			}
+			plat.MockGitHubService.
+				EXPECT().
+				ListPullRequestReviews(gomock.Any(), "SEEK-Jobs", "org", 7).
+				Return(reviews, nil)
+
+			// The approver is a maintainer of the approver team
+			if tt.approverTeam != "" && tt.approver != "" {
+				plat.MockGitHubService.
+					EXPECT().
+					ListTeams(gomock.Any(), "SEEK-Jobs").
+					Return([]*orgbot.GitHubTeam{{ID: 9, Name: tt.approverTeam}}, nil)
+				plat.MockGitHubService.
+					EXPECT().
+					ListTeamMembers(gomock.Any(), "SEEK-Jobs", orgbot.GitHubTeamID(9), orgbot.RoleMaintainer).
+					Return([]*orgbot.GitHubUser{{Login: tt.approver}}, nil)
+			}
+
+			run, err := checkApprovals(ctx, plat, pr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if run.Conclusion != tt.wantConclusion {
+				t.Errorf("Expected %s, got %s: %s\n%s", tt.wantConclusion, run.Conclusion, run.Title, run.Summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	hub "github.com/google/go-github/github"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+// coalescedMessagesMetric is the name of the counter of messages that weren't handled because handling an
+// earlier message with the same coalesce key had already covered them
+const coalescedMessagesMetric = "events.coalesced"
+
+// coalesceKey returns the key of the events that are all handled by recomputing the same target from its
+// latest state, or an empty string if the specified event can't be coalesced. Handling any one of the
+// events with a key covers all of them that were sent to the queue before handling started.
+func coalesceKey(c *orgbot.Config, m *queue.Message) string {
+	switch m.EventType {
+	case teamEvent:
+		var event hub.TeamEvent
+		if err := json.Unmarshal([]byte(m.Payload), &event); err != nil {
+			return ""
+		}
+
+		switch {
+		case event.Repo != nil:
+			// Team changes on a repo all recompute the repo's topics
+			return "repo-topics:" + event.Repo.GetFullName()
+		case event.GetAction() == "edited" && event.Changes != nil && event.Changes.Name != nil:
+			// Team renames all recompute the topics of the team's repos and enforce the team
+			return fmt.Sprintf("team-rename:%s/%d", event.Org.GetLogin(), event.Team.GetID())
+		case isEnforcementTeamEvent(&event) && isEnforced(c, event.GetOrg().GetLogin()):
+			return enforcementKey(event.GetOrg().GetLogin())
+		}
+
+	case membershipEvent:
+		var event hub.MembershipEvent
+		if err := json.Unmarshal([]byte(m.Payload), &event); err != nil {
+			return ""
+		}
+
+		// Membership changes in enforced orgs all enforce the whole org
+		if event.GetScope() == "team" && isEnforced(c, event.GetOrg().GetLogin()) {
+			return enforcementKey(event.GetOrg().GetLogin())
+		}
+	}
+
+	return ""
+}
+
+// enforcementKey returns the coalesce key of the events whose handling only enforces the org with the
+// specified name.
+func enforcementKey(orgName string) string {
+	return "enforce:" + strings.ToLower(orgName)
+}
+
+// isEnforcementTeamEvent returns whether handling the specified team event does nothing but enforce its
+// org, such that it can be coalesced with other events that enforce the org.
+func isEnforcementTeamEvent(event *hub.TeamEvent) bool {
+	if event.Repo != nil {
+		return false
+	}
+
+	switch event.GetAction() {
+	case "created":
+		return true
+	case "edited":
+		return event.Changes == nil || event.Changes.Name == nil
+	}
+	return false
+}
+
+// coalescer coalesces bursts of events with the same coalesce key. The first event of a burst is handled
+// once a debounce window has elapsed, and events that were sent to the queue before handling started are
+// redundant so are skipped rather than recomputing the same target again.
+type coalescer struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	handled map[string]coalescedHandling // Last successful handling of each coalesce key
+	pending map[*time.Timer]func()
+	wg      sync.WaitGroup
+}
+
+// coalescedHandling describes a successful handling of a coalesce key.
+type coalescedHandling struct {
+	cutoff time.Time // Time handling started by the clock of the queue, before which messages are covered
+	at     time.Time // Time handling finished by the clock of the worker, used to prune old handlings
+}
+
+// newCoalescer returns a coalescer that defers handling by the specified debounce window.
+func newCoalescer(window time.Duration) *coalescer {
+	return &coalescer{
+		window:  window,
+		handled: map[string]coalescedHandling{},
+		pending: map[*time.Timer]func(){},
+	}
+}
+
+// isRedundant returns whether handling the specified message with the specified coalesce key started after
+// the message was sent to the queue, so that it has already been covered. Times are compared by the clock
+// of the queue so that they're unaffected by skew between the clocks of the hook and the workers.
+func (c *coalescer) isRedundant(key string, m *queue.Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Messages sent at an unknown time can't be known to be covered
+	if m.SentAt.IsZero() {
+		return false
+	}
+
+	h, ok := c.handled[key]
+	return ok && m.SentAt.Before(h.cutoff)
+}
+
+// schedule calls the specified function to handle the specified message with the specified coalesce key
+// once the debounce window has elapsed, given the time the message was received by the clock of the worker.
+// Events with the key are recorded as covered if the function succeeds.
+func (c *coalescer) schedule(key string, m *queue.Message, received time.Time, handleFn func() error) {
+	run := func() {
+		defer c.wg.Done()
+
+		cutoff := handlingCutoff(m, received)
+		if err := handleFn(); err != nil {
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		now := time.Now()
+		for k, h := range c.handled {
+			// Later events of a burst are received as soon as its first is handled, so older handlings
+			// won't cover any more
+			if now.Sub(h.at) > c.window {
+				delete(c.handled, k)
+			}
+		}
+		if !cutoff.IsZero() && cutoff.After(c.handled[key].cutoff) {
+			c.handled[key] = coalescedHandling{cutoff: cutoff, at: now}
+		}
+	}
+
+	c.wg.Add(1)
+	if c.window <= 0 {
+		run()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		delete(c.pending, timer)
+		c.mu.Unlock()
+
+		run()
+	})
+	c.pending[timer] = run
+}
+
+// handlingCutoff returns the time by the clock of the queue at which handling of the specified message starts
+// now, given the time it was received by the clock of the worker, or zero if it can't be known. Only the first
+// receipt of a message is timed by the queue, so retried messages only cover those sent before them.
+func handlingCutoff(m *queue.Message, received time.Time) time.Time {
+	if m.Attempts <= 1 && !m.ReceivedAt.IsZero() {
+		return m.ReceivedAt.Add(time.Since(received))
+	}
+	return m.SentAt
+}
+
+// flush handles all the deferred messages immediately and waits for their handling to finish.
+func (c *coalescer) flush() {
+	c.mu.Lock()
+	var runs []func()
+	for timer, run := range c.pending {
+		// Timers that have already fired are running
+		if timer.Stop() {
+			runs = append(runs, run)
+		}
+		delete(c.pending, timer)
+	}
+	c.mu.Unlock()
+
+	for _, run := range runs {
+		run()
+	}
+	c.wg.Wait()
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+func TestCoalesceKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		payload   string
+		want      string
+	}{
+		{
+			name:      "repo",
+			eventType: teamEvent,
+			payload:   `{"action": "added_to_repository", "team": {"id": 1}, "repository": {"full_name": "SEEK-Jobs/repo1"}}`,
+			want:      "repo-topics:SEEK-Jobs/repo1",
+		},
+		{
+			name:      "rename",
+			eventType: teamEvent,
+			payload:   `{"action": "edited", "team": {"id": 1}, "organization": {"login": "SEEK-Jobs"}, "changes": {"name": {"from": "Foo"}}}`,
+			want:      "team-rename:SEEK-Jobs/1",
+		},
+		{
+			name:      "deleted",
+			eventType: teamEvent,
+			payload:   `{"action": "deleted", "team": {"id": 1}, "organization": {"login": "SEEK-Jobs"}}`,
+			want:      "",
+		},
+		{
+			name:      "created in enforced org",
+			eventType: teamEvent,
+			payload:   `{"action": "created", "team": {"id": 1}, "organization": {"login": "SEEK-Jobs"}}`,
+			want:      "enforce:seek-jobs",
+		},
+		{
+			name:      "created in unenforced org",
+			eventType: teamEvent,
+			payload:   `{"action": "created", "team": {"id": 1}, "organization": {"login": "Other"}}`,
+			want:      "",
+		},
+		{
+			name:      "membership in enforced org",
+			eventType: membershipEvent,
+			payload:   `{"action": "added", "scope": "team", "team": {"id": 1}, "organization": {"login": "SEEK-Jobs"}}`,
+			want:      "enforce:seek-jobs",
+		},
+		{
+			name:      "membership in unenforced org",
+			eventType: membershipEvent,
+			payload:   `{"action": "added", "scope": "team", "team": {"id": 1}, "organization": {"login": "Other"}}`,
+			want:      "",
+		},
+		{
+			name:      "other event type",
+			eventType: pushEvent,
+			payload:   `{"ref": "refs/heads/master"}`,
+			want:      "",
+		},
+	}
+
+	c := &orgbot.Config{EnforcedOrgs: []string{"SEEK-Jobs"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &queue.Message{EventType: tt.eventType, Payload: tt.payload}
+			if got := coalesceKey(c, m); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListenForEventsCoalescesBursts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().MaxAttempts = 1
+	plat.Config().CoalesceWindow = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Topics are disambiguated against the teams of the org
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return(nil, nil).
+		AnyTimes()
+
+	q := queue.NewMemoryQueue()
+	dls := queue.NewMemoryDeadLetterStore()
+
+	// A burst of team changes on repo1 and a single change on repo2, delivered by a hook whose clock is
+	// ahead of the worker's
+	for i, repoName := range []string{"repo1", "repo1", "repo2", "repo1"} {
+		m := queue.Message{
+			InstallationID: 123,
+			EventType:      teamEvent,
+			DeliveryID:     fmt.Sprintf("delivery-%d", i),
+			GroupKey:       "repo:SEEK-Jobs/" + repoName,
+			DeliveredAt:    time.Now().Add(time.Hour),
+			Payload: fmt.Sprintf(`{
+				"action": "added_to_repository",
+				"team": {"id": %d, "name": "Team%d"},
+				"repository": {"name": "%s", "full_name": "SEEK-Jobs/%s", "fork": false, "owner": {"login": "SEEK-Jobs"}},
+				"organization": {"login": "SEEK-Jobs"}
+			}`, i, i, repoName, repoName),
+		}
+		if err := q.Submit(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Expect the topics of each repo to be recomputed once
+	for _, repoName := range []string{"repo1", "repo2"} {
+		plat.MockGitHubService.
+			EXPECT().
+			RepoByName(gomock.Any(), "SEEK-Jobs", repoName).
+			Return(&orgbot.Repo{Name: repoName}, nil)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := ListenForEvents(ctx, plat, q, dls, queue.NewMemoryBlobStore()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expect the coalesced events to have been deleted
+	m, _, err := q.Receive(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Errorf("Expected all events to be handled or coalesced, but %s is still queued", m.DeliveryID)
+	}
+}
+
+func TestCoalescerPrunesOldHandlings(t *testing.T) {
+	c := newCoalescer(10 * time.Millisecond)
+	now := time.Now()
+
+	schedule := func(key string) {
+		m := &queue.Message{SentAt: now, ReceivedAt: now, Attempts: 1}
+		c.schedule(key, m, time.Now(), func() error { return nil })
+		c.flush()
+	}
+
+	redundant := &queue.Message{SentAt: now}
+
+	schedule("repo-topics:SEEK-Jobs/repo1")
+	if !c.isRedundant("repo-topics:SEEK-Jobs/repo1", redundant) {
+		t.Error("Expected a message sent before handling started to be redundant")
+	}
+
+	// Handling another key once the window has elapsed prunes the first
+	time.Sleep(20 * time.Millisecond)
+	schedule("repo-topics:SEEK-Jobs/repo2")
+	if c.isRedundant("repo-topics:SEEK-Jobs/repo1", redundant) {
+		t.Error("Expected the handling of repo1 to have been pruned")
+	}
+	if len(c.handled) != 1 {
+		t.Errorf("Expected 1 handling to be kept, got %d", len(c.handled))
+	}
+}
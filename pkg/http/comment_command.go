@@ -0,0 +1,256 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+const (
+	// commandPrefix is the prefix of the comments on org repo pull requests that orgbot acts upon
+	commandPrefix = "/orgbot"
+
+	commandPlan     = "plan"     // Replies with the plan of the pull request
+	commandValidate = "validate" // Replies with the rule violations of the pull request
+	commandApply    = "apply"    // Applies the pull request and replies with the result
+
+	commandUsage = "Usage: `/orgbot plan`, `/orgbot validate` or `/orgbot apply`"
+)
+
+// handleIssueCommentEvent acts upon orgbot commands in new comments on org repo pull requests, replying
+// to each command with its outcome. All other comments are ignored.
+func handleIssueCommentEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*hub.IssueCommentEvent)
+	fullName := event.GetRepo().GetFullName()
+	if !isOrgRepo(p.Config(), fullName) || event.GetAction() != "created" || !event.GetIssue().IsPullRequest() {
+		return nil
+	}
+
+	// Ignore bots, not least of all ourselves
+	if event.GetSender().GetType() == "Bot" {
+		return nil
+	}
+
+	command, ok := parseCommand(event.GetComment().GetBody())
+	if !ok {
+		return nil
+	}
+
+	number := event.GetIssue().GetNumber()
+	login := event.GetSender().GetLogin()
+	log.Ctx(ctx).Info().Msgf("Received '%s' command from %s on pull request #%d of repo %s", command, login, number, fullName)
+
+	var reply string
+	var err error
+	switch command {
+	case commandPlan, commandValidate:
+		reply, err = validateCommand(ctx, p, number, command)
+	case commandApply:
+		reply, err = applyCommand(ctx, p, number, login)
+	default:
+		reply = fmt.Sprintf("Unknown command `%s`. %s", command, commandUsage)
+	}
+
+	if err != nil {
+		// Let the commenter know that the command failed rather than leaving them hanging
+		reply = fmt.Sprintf("Failed to %s: %s", command, err.Error())
+		err = errors.Wrapf(err, "failed to %s pull request #%d of repo %s", command, number, fullName)
+	}
+
+	owner, name, _ := orgRepo(p.Config())
+	body := fmt.Sprintf("> %s %s\n\n@%s %s", commandPrefix, command, login, reply)
+	if replyErr := p.GitHubService().CreateIssueComment(ctx, owner, name, number, body); replyErr != nil {
+		if err != nil {
+			log.Ctx(ctx).Error().Err(replyErr).Msg("Failed to reply to command")
+			return err
+		}
+		return replyErr
+	}
+
+	return err
+}
+
+// parseCommand returns the orgbot command in the first line of the specified comment body. False is
+// returned as the second return parameter if the comment isn't an orgbot command.
+func parseCommand(body string) (string, bool) {
+	line := strings.SplitN(strings.TrimSpace(body), "\n", 2)[0]
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != commandPrefix {
+		return "", false
+	}
+
+	if len(fields) == 1 {
+		return "", true
+	}
+	return strings.ToLower(fields[1]), true
+}
+
+// validateCommand validates the head commit of the specified pull request and returns a reply that
+// describes either its plan or its rule violations depending on the command.
+func validateCommand(ctx context.Context, p orgbot.Platform, number int, command string) (string, error) {
+	pr, err := pullRequest(ctx, p, number)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := validateOrgAt(ctx, p, pr.HeadSHA)
+	if err != nil {
+		return "", err
+	}
+
+	if command == commandPlan {
+		plan, err := planComment(ctx, p, v)
+		if err != nil {
+			return "", err
+		}
+		return "\n\n" + plan, nil
+	}
+
+	return fmt.Sprintf("%s at %s.\n\n%s", v.run.Title, shortSHA(pr.HeadSHA), v.run.Summary), nil
+}
+
+// applyCommand merges the specified pull request on behalf of the user with the specified login and returns
+// a reply that describes the changes it's expected to make. Only members of the approver team may apply pull
+// requests to the org repo branch, and only once their head commit is valid and has been approved, including
+// by the owners of the teams it changes. The pull request is merged rather than its head applied directly, as
+// the head may lack commits of the branch that a later push would then revert. The push of the merge commit
+// to the branch is what applies it, and its commit status reports the outcome. Merging fails if the head has
+// moved on since it was approved and validated.
+func applyCommand(ctx context.Context, p orgbot.Platform, number int, login string) (string, error) {
+	c := p.Config()
+	owner, name, _ := orgRepo(c)
+
+	if c.ApproverTeam == "" {
+		return "Applying pull requests is disabled as no approver team has been configured.", nil
+	}
+
+	approver, err := isTeamMember(ctx, p, owner, c.ApproverTeam, login)
+	if err != nil {
+		return "", err
+	}
+	if !approver {
+		return fmt.Sprintf("Only members of the `%s` team may apply pull requests.", c.ApproverTeam), nil
+	}
+
+	pr, err := pullRequest(ctx, p, number)
+	if err != nil {
+		return "", err
+	}
+	if !pr.Open {
+		return "Only open pull requests may be applied.", nil
+	}
+	if pr.BaseRef != c.OrgRepoBranch {
+		return fmt.Sprintf("Only pull requests to `%s` may be applied.", c.OrgRepoBranch), nil
+	}
+
+	reviews, err := p.GitHubService().ListPullRequestReviews(ctx, owner, name, number)
+	if err != nil {
+		return "", err
+	}
+	if !isApproved(pr, reviews) {
+		return fmt.Sprintf("Pull requests must be approved at their head commit %s before they are applied.", shortSHA(pr.HeadSHA)), nil
+	}
+
+	// The approvals check may not have been published for the head yet so it's evaluated afresh
+	approvals, err := checkApprovals(ctx, p, pr)
+	if err != nil {
+		return "", err
+	}
+	if approvals.Conclusion != orgbot.CheckConclusionSuccess {
+		return fmt.Sprintf("Pull requests must be approved by the owners of the teams they change before they are applied. %s.\n\n%s",
+			approvals.Title, approvals.Summary), nil
+	}
+
+	v, err := validateOrgAt(ctx, p, pr.HeadSHA)
+	if err != nil {
+		return "", err
+	}
+	if v.plan == nil {
+		return fmt.Sprintf("Pull requests must be valid before they are applied. %s at %s.", v.run.Title, shortSHA(pr.HeadSHA)), nil
+	}
+
+	sha, err := p.GitHubService().MergePullRequest(ctx, owner, name, number, pr.HeadSHA)
+	if err != nil {
+		return "", err
+	}
+
+	reply := fmt.Sprintf("Merged %s as %s, which is applied once it's pushed to `%s`. Its commit status reports the outcome.",
+		shortSHA(pr.HeadSHA), shortSHA(sha), c.OrgRepoBranch)
+	if !v.plan.HasChanges() {
+		return reply + " No changes to the org are expected.", nil
+	}
+	return fmt.Sprintf("%s\n\n%s", reply, describeTeamReports(v.plan.Teams)), nil
+}
+
+// pullRequest returns the pull request of the org repo with the specified number.
+func pullRequest(ctx context.Context, p orgbot.Platform, number int) (*orgbot.PullRequest, error) {
+	owner, name, _ := orgRepo(p.Config())
+	return p.GitHubService().PullRequestByNumber(ctx, owner, name, number)
+}
+
+// isApproved returns whether at least one reviewer other than the author of the specified pull request has
+// approved its head commit and no reviewer's latest review requests changes. Approvals of earlier commits
+// don't count as the changes since then haven't been reviewed.
+func isApproved(pr *orgbot.PullRequest, reviews []*orgbot.PullRequestReview) bool {
+	approved := false
+	for _, r := range latestReviews(pr, reviews) {
+		switch {
+		case r.State == orgbot.ReviewChangesRequested:
+			return false
+		case r.State == orgbot.ReviewApproved && r.CommitID == pr.HeadSHA:
+			approved = true
+		}
+	}
+	return approved
+}
+
+// latestReviews returns a map of the logins of the reviewers of the specified pull request, other than its
+// author, to their latest review. Comments don't change the verdict of a reviewer so are ignored.
+func latestReviews(pr *orgbot.PullRequest, reviews []*orgbot.PullRequestReview) map[string]*orgbot.PullRequestReview {
+	latest := map[string]*orgbot.PullRequestReview{}
+	for _, r := range reviews {
+		if r.Author == pr.Author || r.State == orgbot.ReviewCommented {
+			continue
+		}
+		latest[r.Author] = r
+	}
+	return latest
+}
+
+// isTeamMember returns whether the user with the specified login is a maintainer or member of the
+// team with the specified name.
+func isTeamMember(ctx context.Context, p orgbot.Platform, orgName, teamName, login string) (bool, error) {
+	svc := p.GitHubService()
+
+	teams, err := svc.ListTeams(ctx, orgName)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range teams {
+		if t.Name != teamName {
+			continue
+		}
+
+		for _, role := range []orgbot.GitHubTeamRole{orgbot.RoleMaintainer, orgbot.RoleMember} {
+			users, err := svc.ListTeamMembers(ctx, orgName, t.ID, role)
+			if err != nil {
+				return false, err
+			}
+
+			for _, u := range users {
+				if strings.EqualFold(u.Login, login) {
+					return true, nil
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
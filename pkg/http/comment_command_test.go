@@ -0,0 +1,185 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+func TestIsApproved(t *testing.T) {
+	pr := &orgbot.PullRequest{Number: 1, Author: "author", HeadSHA: "head"}
+
+	tests := []struct {
+		name    string
+		reviews []*orgbot.PullRequestReview
+		want    bool
+	}{
+		{
+			name:    "Approved at head",
+			reviews: []*orgbot.PullRequestReview{{Author: "reviewer", State: orgbot.ReviewApproved, CommitID: "head"}},
+			want:    true,
+		},
+		{
+			name:    "Approved at an older commit",
+			reviews: []*orgbot.PullRequestReview{{Author: "reviewer", State: orgbot.ReviewApproved, CommitID: "older"}},
+			want:    false,
+		},
+		{
+			name:    "Approved by the author",
+			reviews: []*orgbot.PullRequestReview{{Author: "author", State: orgbot.ReviewApproved, CommitID: "head"}},
+			want:    false,
+		},
+		{
+			name: "Changes requested at an older commit",
+			reviews: []*orgbot.PullRequestReview{
+				{Author: "reviewer1", State: orgbot.ReviewChangesRequested, CommitID: "older"},
+				{Author: "reviewer2", State: orgbot.ReviewApproved, CommitID: "head"},
+			},
+			want: false,
+		},
+		{
+			name: "Comment after approval",
+			reviews: []*orgbot.PullRequestReview{
+				{Author: "reviewer", State: orgbot.ReviewApproved, CommitID: "head"},
+				{Author: "reviewer", State: orgbot.ReviewCommented, CommitID: "head"},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isApproved(pr, tt.reviews); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyCommandRefusesStaleApprovals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	plat.Config().ApproverTeam = "Approvers"
+	ctx := context.Background()
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return([]*orgbot.GitHubTeam{{ID: 1, Name: "Approvers"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeamMembers(gomock.Any(), "SEEK-Jobs", orgbot.GitHubTeamID(1), orgbot.RoleMaintainer).
+		Return([]*orgbot.GitHubUser{{Login: "approver"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		PullRequestByNumber(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return(&orgbot.PullRequest{Number: 7, Author: "author", Open: true, HeadSHA: "head", BaseRef: "master"}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		ListPullRequestReviews(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.PullRequestReview{{Author: "reviewer", State: orgbot.ReviewApproved, CommitID: "older"}}, nil)
+
+	// Nothing is merged
+	reply, err := applyCommand(ctx, plat, 7, "approver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(reply, "must be approved") {
+		t.Errorf("Expected approval to be required, got %q", reply)
+	}
+}
+
+func TestApplyCommandRefusesOtherBranches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	plat.Config().ApproverTeam = "Approvers"
+	ctx := context.Background()
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return([]*orgbot.GitHubTeam{{ID: 1, Name: "Approvers"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeamMembers(gomock.Any(), "SEEK-Jobs", orgbot.GitHubTeamID(1), orgbot.RoleMaintainer).
+		Return([]*orgbot.GitHubUser{{Login: "approver"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		PullRequestByNumber(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return(&orgbot.PullRequest{Number: 7, Author: "author", Open: true, HeadSHA: "head", BaseRef: "feature"}, nil)
+
+	reply, err := applyCommand(ctx, plat, 7, "approver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(reply, "Only pull requests to `master`") {
+		t.Errorf("Expected pull requests to other branches to be refused, got %q", reply)
+	}
+}
+
+func TestApplyCommandRequiresOwnerApprovals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	plat.Config().ApproverTeam = "Approvers"
+	ctx := context.Background()
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return([]*orgbot.GitHubTeam{{ID: 1, Name: "Approvers"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeamMembers(gomock.Any(), "SEEK-Jobs", orgbot.GitHubTeamID(1), orgbot.RoleMaintainer).
+		Return([]*orgbot.GitHubUser{{Login: "approver"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		PullRequestByNumber(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return(&orgbot.PullRequest{Number: 7, Author: "author", Open: true, HeadSHA: "head", BaseRef: "master"}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		ListPullRequestReviews(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.PullRequestReview{{Author: "reviewer", State: orgbot.ReviewApproved, CommitID: "head"}}, nil).
+		Times(2)
+
+	// The reviewer isn't an owner of the changed team
+	plat.MockGitHubService.
+		EXPECT().
+		ListPullRequestFiles(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.PullRequestFile{{Path: "team-a/team.yaml"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		DownloadDir(gomock.Any(), "SEEK-Jobs", "org", "master", "", gomock.Any()).
+		DoAndReturn(writeOrgRepo(map[string]string{
+			"org.yaml":         "name: SEEK-Jobs\n",
+			"team-a/team.yaml": "name: Team A\nowners:\n- owner@seek.com.au\n",
+		}))
+	plat.MockGitHubService.
+		EXPECT().
+		UserByLogin(gomock.Any(), "SEEK-Jobs", "reviewer").
+		Return(&orgbot.GitHubUser{Login: "reviewer", Email: "reviewer@seek.com.au"}, nil)
+
+	// Nothing is merged
+	reply, err := applyCommand(ctx, plat, 7, "approver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(reply, "must be approved by the owners") {
+		t.Errorf("Expected owner approval to be required, got %q", reply)
+	}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"strings"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+// enforcementRevertsMetric is the name of the counter of the manual changes reverted by enforcement
+const enforcementRevertsMetric = "enforcement.reverts"
+
+// isEnforced returns whether manual changes to the teams of the org with the specified name are reverted.
+func isEnforced(c *orgbot.Config, orgName string) bool {
+	for _, o := range c.EnforcedOrgs {
+		if strings.EqualFold(o, orgName) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelf returns whether the specified user is the one that orgbot acts as, i.e. whether a change made by
+// the user was made by orgbot itself. Other GitHub Apps are bots too but their changes aren't orgbot's.
+func isSelf(ctx context.Context, p orgbot.Platform, u *hub.User) (bool, error) {
+	login, err := p.GitHubService().Login(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get login of orgbot")
+	}
+	return strings.EqualFold(u.GetLogin(), login), nil
+}
+
+// enforceOrg reverts the teams of the specified org to the desired state on the org repo branch if
+// the org is enforced. The specified sender and change describe the manual change being reverted.
+func enforceOrg(ctx context.Context, p orgbot.Platform, orgName, sender, change string) error {
+	c := p.Config()
+	if !isEnforced(c, orgName) {
+		return nil
+	}
+
+	if _, _, ok := orgRepo(c); !ok {
+		log.Ctx(ctx).Warn().Msgf("Can't enforce org %s as no org repo has been configured", orgName)
+		return nil
+	}
+
+	org, err := mergeBranchOrg(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(org.Name, orgName) {
+		log.Ctx(ctx).Warn().Msgf("Can't enforce org %s as the org repo describes org %s", orgName, org.Name)
+		return nil
+	}
+
+	res, err := orgbot.ApplyOrg(ctx, p, org, &orgbot.ApplyOrgOptions{RefreshTopics: true})
+	if err != nil {
+		return err
+	}
+
+	if !res.HasChanges() {
+		log.Ctx(ctx).Debug().Msgf("No changes to revert after %s by %s", change, sender)
+		return nil
+	}
+
+	metrics.GetOrRegisterCounter(enforcementRevertsMetric, cmd.MetricsRegistry()).Inc(1)
+	log.Ctx(ctx).Warn().
+		Str("org", orgName).
+		Str("sender", sender).
+		Str("change", change).
+		Interface("teams", res.Teams).
+		Msgf("Reverted %s by %s: %s", change, sender, describeApplyOrgResult(res))
+
+	return nil
+}
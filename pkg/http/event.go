@@ -3,41 +3,109 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	hub "github.com/google/go-github/github"
 	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
 
+	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
 	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
 )
 
+// archiveFailuresMetric is the name of the counter of events that couldn't be archived
+const archiveFailuresMetric = "events.archive_failures"
+
+// repoEvents are the types of the events that are scoped to a repo rather than to the org.
+var repoEvents = map[string]bool{
+	pushEvent:              true,
+	pullRequestEvent:       true,
+	pullRequestReviewEvent: true,
+	issueCommentEvent:      true,
+}
+
+// installationEvent is the subset of an event payload that identifies the GitHub App installation
+// that the event was delivered to and the repo, team and org it relates to. All event types are decoded to it.
+type installationEvent struct {
+	Installation *hub.Installation `json:"installation,omitempty"`
+	Repo         *hub.Repository   `json:"repository,omitempty"`
+	Team         *hub.Team         `json:"team,omitempty"`
+	Org          *hub.Organization `json:"organization,omitempty"`
+}
+
+// GetInstallation implements githubapp.InstallationSource.
+func (e *installationEvent) GetInstallation() *hub.Installation {
+	return e.Installation
+}
+
+// eventHandler archives the events delivered to the hook and queues them for processing by ListenForEvents.
 type eventHandler struct {
-	plat orgbot.Platform
+	plat    orgbot.Platform
+	queue   queue.Queue
+	archive queue.Archive
 }
 
-func newEventHandler(plat orgbot.Platform) githubapp.EventHandler {
-	return &eventHandler{plat: plat}
+func newEventHandler(plat orgbot.Platform, q queue.Queue, archive queue.Archive) githubapp.EventHandler {
+	return &eventHandler{plat: plat, queue: q, archive: archive}
 }
 
 // Handles implements githubapp.EventHandler
 func (h *eventHandler) Handles() []string {
-	return []string{teamEvent}
+	return events.eventTypes()
 }
 
 // Handle implements githubapp.EventHandler
 func (h *eventHandler) Handle(ctx context.Context, eventType, deliveryID string, payload []byte) error {
-	var event hub.TeamEvent
+	var event installationEvent
 	if err := json.Unmarshal(payload, &event); err != nil {
 		return errors.Wrap(err, "failed to parse event payload")
 	}
 
+	// Pushes, pull requests, reviews and comments are only of interest for the org repo so there's no point queueing the rest
+	if repoEvents[eventType] && !isOrgRepo(h.plat.Config(), event.Repo.GetFullName()) {
+		return nil
+	}
+
 	id := githubapp.GetInstallationIDFromEvent(&event)
-	c := message{
+	m := queue.Message{
+		Version:        queue.MessageVersion,
 		InstallationID: id,
 		EventType:      eventType,
 		DeliveryID:     deliveryID,
 		Payload:        string(payload),
+		GroupKey:       groupKey(&event),
+		DeliveredAt:    time.Now().UTC(),
+	}
+
+	// Failing to archive the event shouldn't stop it from being processed
+	if err := h.archive.Put(ctx, &m); err != nil {
+		metrics.GetOrRegisterCounter(archiveFailuresMetric, cmd.MetricsRegistry()).Inc(1)
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("Failed to archive %s event %s", eventType, deliveryID)
+	}
+
+	if err := h.queue.Submit(ctx, &m); err != nil {
+		return errors.Wrap(err, "failed to submit command")
 	}
 
-	return sendMessage(h.plat, c)
+	return nil
+}
+
+// groupKey returns the key of the events that must be handled in the order they were delivered as the
+// specified event: events for the same repo, or failing that the same team or org, are handled in order
+// while unrelated events are handled in parallel. An empty string is returned if the event has no scope.
+func groupKey(event *installationEvent) string {
+	switch {
+	case event.Repo.GetFullName() != "":
+		return "repo:" + event.Repo.GetFullName()
+	case event.Team.GetID() != 0:
+		return fmt.Sprintf("team:%s/%d", event.Org.GetLogin(), event.Team.GetID())
+	case event.Org.GetLogin() != "":
+		return "org:" + event.Org.GetLogin()
+	default:
+		return ""
+	}
 }
@@ -6,17 +6,24 @@ import (
 	"github.com/palantir/go-githubapp/githubapp"
 
 	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
 )
 
 const (
-	pushEvent        = "push"
-	pullRequestEvent = "pull_request"
-	teamEvent        = "team"
+	issueCommentEvent      = "issue_comment"
+	membershipEvent        = "membership"
+	organizationEvent      = "organization"
+	pushEvent              = "push"
+	pullRequestEvent       = "pull_request"
+	pullRequestReviewEvent = "pull_request_review"
+	repositoryEvent        = "repository"
+	teamEvent              = "team"
 )
 
-// NewHookHandler returns the handler used for the event hooks
-func NewHookHandler(plat orgbot.Platform) http.Handler {
+// NewHookHandler returns the handler used for the event hooks, which archives events in the specified
+// archive and submits them to the specified queue
+func NewHookHandler(plat orgbot.Platform, q queue.Queue, archive queue.Archive) http.Handler {
 	return githubapp.NewEventDispatcher(
-		[]githubapp.EventHandler{newEventHandler(plat)},
+		[]githubapp.EventHandler{newEventHandler(plat, q, archive)},
 		plat.Config().GitHubWebhookSecret)
 }
@@ -0,0 +1,214 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	h "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+const testWebhookSecret = "secret"
+
+// postEvent delivers an event with the specified type and payload to the specified hook handler,
+// failing the test unless it's accepted.
+func postEvent(t *testing.T, handler h.Handler, eventType, deliveryID, payload string) {
+	mac := hmac.New(sha1.New, []byte(testWebhookSecret))
+	mac.Write([]byte(payload))
+
+	req := httptest.NewRequest(h.MethodPost, "/hook", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-GitHub-Delivery", deliveryID)
+	req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != h.StatusOK {
+		t.Fatalf("Hook responded with status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHookQueuesEventsForHandling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().GitHubWebhookSecret = testWebhookSecret
+	ctx := context.Background()
+
+	// Topics are disambiguated against the teams of the org
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return(nil, nil).
+		AnyTimes()
+
+	q := queue.NewMemoryQueue()
+	archive := queue.NewMemoryArchive()
+	handler := NewHookHandler(plat, q, archive)
+
+	payload := `{
+		"action": "added_to_repository",
+		"team": {"id": 1, "name": "Foo"},
+		"repository": {"name": "repo1", "full_name": "SEEK-Jobs/repo1", "fork": false, "owner": {"login": "SEEK-Jobs"}},
+		"organization": {"login": "SEEK-Jobs"},
+		"sender": {"login": "someone", "type": "User"},
+		"installation": {"id": 123}
+	}`
+	postEvent(t, handler, teamEvent, "delivery-1", payload)
+
+	m, handle, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.DeliveredAt.IsZero() {
+		t.Error("Expected the delivery time to be recorded")
+	}
+	delivered := *m
+	delivered.DeliveredAt = time.Time{}
+	delivered.SentAt = time.Time{}
+	delivered.ReceivedAt = time.Time{}
+
+	want := &queue.Message{
+		Version:        queue.MessageVersion,
+		InstallationID: 123,
+		EventType:      teamEvent,
+		DeliveryID:     "delivery-1",
+		Payload:        payload,
+		GroupKey:       "repo:SEEK-Jobs/repo1",
+		Attempts:       1,
+	}
+	if diff := cmp.Diff(want, &delivered); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	// Expect the event to have been archived as it was queued
+	archived, err := archive.Get(ctx, "delivery-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued := *m
+	queued.Attempts = 0
+	queued.SentAt = time.Time{}
+	queued.ReceivedAt = time.Time{}
+	if diff := cmp.Diff(&queued, archived); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(ctx, "SEEK-Jobs", "repo1").
+		Return(&orgbot.Repo{
+			Name:  "repo1",
+			Teams: []*orgbot.TeamPermission{{TeamName: "Foo", Permission: orgbot.RepoPermissionAdmin}},
+		}, nil)
+
+	// Expect the topics of the repo the team was added to to be updated
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateRepoTopics(ctx, "SEEK-Jobs", "repo1", []string{"admin-foo"}).
+		Return(nil)
+
+	if err := handleMessage(ctx, plat, m); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Delete(ctx, handle); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHookSkipsEventsForOtherRepos(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().GitHubWebhookSecret = testWebhookSecret
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	ctx := context.Background()
+
+	q := queue.NewMemoryQueue()
+	archive := queue.NewMemoryArchive()
+	handler := NewHookHandler(plat, q, archive)
+
+	postEvent(t, handler, pushEvent, "delivery-1", `{
+		"ref": "refs/heads/master",
+		"repository": {"name": "repo1", "full_name": "SEEK-Jobs/repo1"},
+		"installation": {"id": 123}
+	}`)
+	postEvent(t, handler, pushEvent, "delivery-2", `{
+		"ref": "refs/heads/master",
+		"repository": {"name": "org", "full_name": "SEEK-Jobs/org"},
+		"installation": {"id": 123}
+	}`)
+
+	// Only the push to the org repo is queued
+	m, _, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.DeliveryID != "delivery-2" {
+		t.Fatalf("Expected org repo push delivery-2 to be queued, received %v", m)
+	}
+
+	// Only the queued push is archived
+	if _, err := archive.Get(ctx, "delivery-1"); err == nil {
+		t.Error("Expected skipped delivery-1 not to be archived")
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{
+			name:    "repo",
+			payload: `{"repository": {"full_name": "SEEK-Jobs/repo1"}, "team": {"id": 1}, "organization": {"login": "SEEK-Jobs"}}`,
+			want:    "repo:SEEK-Jobs/repo1",
+		},
+		{
+			name:    "team",
+			payload: `{"team": {"id": 1}, "organization": {"login": "SEEK-Jobs"}}`,
+			want:    "team:SEEK-Jobs/1",
+		},
+		{
+			name:    "org",
+			payload: `{"organization": {"login": "SEEK-Jobs"}}`,
+			want:    "org:SEEK-Jobs",
+		},
+		{
+			name:    "none",
+			payload: `{}`,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event installationEvent
+			if err := json.Unmarshal([]byte(tt.payload), &event); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := groupKey(&event); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
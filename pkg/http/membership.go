@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+// membershipDriftMetric is the name of the counter of team membership changes made outside of orgbot
+// that depart from the org configuration
+const membershipDriftMetric = "drift.memberships"
+
+// handleOrganizationEvent opens an issue against the org repo listing the team files that still reference
+// users that have left the org. Users joining or being invited to the org are only logged as they can't be
+// added to teams until they have joined.
+func handleOrganizationEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*hub.OrganizationEvent)
+	orgName := event.GetOrganization().GetLogin()
+
+	switch event.GetAction() {
+	case "member_removed":
+		return handleMemberRemoved(ctx, p, orgName, event.GetMembership().GetUser().GetLogin())
+
+	case "member_added":
+		log.Ctx(ctx).Info().Msgf("User %s joined org %s", event.GetMembership().GetUser().GetLogin(), orgName)
+
+	case "member_invited":
+		log.Ctx(ctx).Info().Msgf("User %s was invited to org %s", event.GetInvitation().GetLogin(), orgName)
+	}
+
+	return nil
+}
+
+// departedUsersMarker identifies the issue listing the team files that reference departed users, of which
+// there's only ever one open for the whole org
+const departedUsersMarker = "<!-- orgbot:departed -->"
+
+// handleMemberRemoved opens an issue against the org repo listing the team files that reference the
+// user with the specified login, or any other user that has left the org. If one is already open, it's
+// updated to list them instead so that departures close together don't open overlapping issues.
+func handleMemberRemoved(ctx context.Context, p orgbot.Platform, orgName, login string) error {
+	c := p.Config()
+	owner, name, ok := orgRepo(c)
+	if !ok {
+		log.Ctx(ctx).Info().Msgf("Ignoring departure of user %s as no org repo has been configured", login)
+		return nil
+	}
+
+	svc := p.GitHubService()
+
+	issues, err := svc.ListOpenIssues(ctx, owner, name)
+	if err != nil {
+		return errors.Wrap(err, "failed to list org repo issues")
+	}
+	var openIssue *orgbot.Issue
+	for _, i := range issues {
+		if strings.Contains(i.Body, departedUsersMarker) {
+			openIssue = i
+			break
+		}
+	}
+
+	// The user information may still have the departed user, in which case they're known by email
+	var departed []string
+	u, err := svc.UserByLogin(ctx, orgName, login)
+	if err == nil {
+		departed = append(departed, u.Email)
+	} else if _, ok := err.(*orgbot.GitHubUserNotFoundError); !ok {
+		return err
+	}
+
+	dir, err := checkoutOrgRepo(ctx, p, c.OrgRepoBranch)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	org, err := orgbot.MergeOrg(p.Codec(), dir)
+	if err != nil {
+		return relativiseMergeError(err, dir)
+	}
+
+	users, err := orgbot.DepartedUsers(ctx, svc, org, departed...)
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		log.Ctx(ctx).Info().Msgf("No teams reference user %s or any other departed user", login)
+		return nil
+	}
+
+	files, err := orgbot.TeamFiles(p.Codec(), dir)
+	if err != nil {
+		return err
+	}
+
+	body := departedUsersMarker + "\n" + describeDepartedUsers(c, login, users, files)
+	if openIssue != nil {
+		if err := svc.UpdateIssue(ctx, owner, name, openIssue.Number, body); err != nil {
+			return errors.Wrapf(err, "failed to update departed users issue #%d", openIssue.Number)
+		}
+
+		log.Ctx(ctx).Info().Msgf("Updated issue #%d for the departure of user %s", openIssue.Number, login)
+		return nil
+	}
+
+	number, err := svc.CreateIssue(ctx, owner, name, "Remove departed users from teams", body)
+	if err != nil {
+		return errors.Wrap(err, "failed to open departed users issue")
+	}
+
+	log.Ctx(ctx).Info().Msgf("Opened issue #%d for the departure of user %s", number, login)
+	return nil
+}
+
+// describeDepartedUsers returns a markdown description of the team files that reference the specified
+// departed users.
+func describeDepartedUsers(c *orgbot.Config, login string, users map[string][]string, files map[string][]string) string {
+	var teamNames []string
+	for t := range users {
+		teamNames = append(teamNames, t)
+	}
+	sort.Strings(teamNames)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@%s has left the org most recently. The following team files still reference users that "+
+		"are no longer members of the org and should be updated:\n\n", login)
+	b.WriteString("| File | Team | Departed users |\n|---|---|---|\n")
+	for _, t := range teamNames {
+		for _, f := range files[t] {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", orgRepoPath(c, f), t, strings.Join(users[t], ", "))
+		}
+	}
+	return b.String()
+}
+
+// handleMembershipEvent records drift when a user is added to or removed from a team outside of orgbot
+// such that the team's membership no longer matches the org configuration on the org repo branch. The
+// org is enforced after every membership change, not only drift, as the change may have been coalesced
+// with others that did drift.
+func handleMembershipEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*hub.MembershipEvent)
+	c := p.Config()
+	if event.GetScope() != "team" {
+		return nil
+	}
+
+	if _, _, ok := orgRepo(c); !ok {
+		log.Ctx(ctx).Debug().Msg("Ignoring membership event as no org repo has been configured")
+		return nil
+	}
+
+	// Changes made by orgbot itself are never drift
+	self, err := isSelf(ctx, p, event.GetSender())
+	if err != nil {
+		return err
+	}
+	if !self {
+		if err := recordMembershipDrift(ctx, p, event); err != nil {
+			return err
+		}
+	}
+
+	orgName := event.GetOrg().GetLogin()
+	change := fmt.Sprintf("membership of %s in team %s %s", event.GetMember().GetLogin(), event.GetTeam().GetName(), event.GetAction())
+	return enforceOrg(ctx, p, orgName, event.GetSender().GetLogin(), change)
+}
+
+// recordMembershipDrift records drift if the specified membership change departs from the org configuration
+// on the org repo branch. Users that can't be mapped to an email address can't be in the org configuration
+// so adding them to any team is drift.
+func recordMembershipDrift(ctx context.Context, p orgbot.Platform, event *hub.MembershipEvent) error {
+	orgName := event.GetOrg().GetLogin()
+	login := event.GetMember().GetLogin()
+	teamName := event.GetTeam().GetName()
+	added := event.GetAction() == "added"
+
+	var drift bool
+	u, err := p.GitHubService().UserByLogin(ctx, orgName, login)
+	if err == nil {
+		org, err := mergeBranchOrg(ctx, p)
+		if err != nil {
+			return err
+		}
+		drift = orgbot.IsMembershipDrift(org, teamName, u.Email, added)
+	} else if _, ok := err.(*orgbot.GitHubUserNotFoundError); ok {
+		log.Ctx(ctx).Warn().Msgf("Membership of unknown user %s in team %s %s", login, teamName, event.GetAction())
+		drift = added
+	} else {
+		return err
+	}
+
+	if !drift {
+		return nil
+	}
+
+	metrics.GetOrRegisterCounter(membershipDriftMetric, cmd.MetricsRegistry()).Inc(1)
+	log.Ctx(ctx).Warn().
+		Str("team", teamName).
+		Str("user", login).
+		Str("action", event.GetAction()).
+		Str("sender", event.GetSender().GetLogin()).
+		Msgf("Team %s drifted from the org configuration: %s %s %s outside of orgbot",
+			teamName, event.GetSender().GetLogin(), event.GetAction(), login)
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+func TestHandleMembershipEventIgnoresOwnChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	ctx := context.Background()
+
+	// Nothing but the login of orgbot is expected of the GitHub service
+	plat.MockGitHubService.
+		EXPECT().
+		Login(gomock.Any()).
+		Return("orgbot[bot]", nil)
+
+	payload := `{
+		"action": "added",
+		"scope": "team",
+		"member": {"login": "user1"},
+		"team": {"name": "Team A"},
+		"organization": {"login": "SEEK-Jobs"},
+		"sender": {"login": "orgbot[bot]", "type": "Bot"}
+	}`
+	if err := HandleEvent(ctx, plat, membershipEvent, []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandleMembershipEventUnmanagedTeam(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	ctx := context.Background()
+
+	drift := metrics.GetOrRegisterCounter(membershipDriftMetric, cmd.MetricsRegistry())
+	before := drift.Count()
+
+	plat.MockGitHubService.
+		EXPECT().
+		Login(gomock.Any()).
+		Return("orgbot[bot]", nil).
+		Times(2)
+	plat.MockGitHubService.
+		EXPECT().
+		UserByLogin(gomock.Any(), "SEEK-Jobs", "user1").
+		Return(&orgbot.GitHubUser{Login: "user1", Email: "user1@seek.com.au"}, nil).
+		Times(2)
+	plat.MockGitHubService.
+		EXPECT().
+		BranchSHA(gomock.Any(), "SEEK-Jobs", "org", "master").
+		Return("unmanaged-team-sha", nil).
+		Times(2)
+
+	// The org repo is only downloaded once as the branch hasn't moved between the events
+	resetBranchOrgs()
+	plat.MockGitHubService.
+		EXPECT().
+		DownloadDir(gomock.Any(), "SEEK-Jobs", "org", "unmanaged-team-sha", "", gomock.Any()).
+		DoAndReturn(writeOrgRepo(map[string]string{
+			"org.yaml":         "name: SEEK-Jobs\n",
+			"team-a/team.yaml": "name: Team A\nmembers:\n- user1@seek.com.au\n",
+		}))
+
+	// Anyone added to a team that isn't in the org repo has drifted, but the org isn't enforced
+	payload := `{
+		"action": "added",
+		"scope": "team",
+		"member": {"login": "user1"},
+		"team": {"name": "Unmanaged"},
+		"organization": {"login": "SEEK-Jobs"},
+		"sender": {"login": "admin", "type": "User"}
+	}`
+	for i := 0; i < 2; i++ {
+		if err := HandleEvent(ctx, plat, membershipEvent, []byte(payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := drift.Count() - before; got != 2 {
+		t.Errorf("Expected 2 drifted memberships to be recorded, got %d", got)
+	}
+}
+
+func TestHandleMembershipEventUnknownUserByOtherApp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	ctx := context.Background()
+
+	drift := metrics.GetOrRegisterCounter(membershipDriftMetric, cmd.MetricsRegistry())
+	before := drift.Count()
+
+	plat.MockGitHubService.
+		EXPECT().
+		Login(gomock.Any()).
+		Return("orgbot[bot]", nil)
+
+	// Users that can't be mapped to an email address can't be in any team of the org repo
+	plat.MockGitHubService.
+		EXPECT().
+		UserByLogin(gomock.Any(), "SEEK-Jobs", "stranger").
+		Return(nil, &orgbot.GitHubUserNotFoundError{OrgName: "SEEK-Jobs", Login: "stranger"})
+
+	payload := `{
+		"action": "added",
+		"scope": "team",
+		"member": {"login": "stranger"},
+		"team": {"name": "Team A"},
+		"organization": {"login": "SEEK-Jobs"},
+		"sender": {"login": "other-app[bot]", "type": "Bot"}
+	}`
+	if err := HandleEvent(ctx, plat, membershipEvent, []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := drift.Count() - before; got != 1 {
+		t.Errorf("Expected 1 drifted membership to be recorded, got %d", got)
+	}
+}
+
+func TestHandleOrganizationEventUpdatesOpenDepartedUsersIssue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	ctx := context.Background()
+
+	// An issue is already open for the departure of user2
+	plat.MockGitHubService.
+		EXPECT().
+		ListOpenIssues(gomock.Any(), "SEEK-Jobs", "org").
+		Return([]*orgbot.Issue{
+			{Number: 4, Title: "Unrelated", Body: "Something else"},
+			{Number: 5, Title: "Remove departed users from teams", Body: departedUsersMarker + "\n@user2 has left the org"},
+		}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		UserByLogin(gomock.Any(), "SEEK-Jobs", "user1").
+		Return(&orgbot.GitHubUser{Login: "user1", Email: "user1@seek.com.au"}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		UserByEmail(gomock.Any(), "SEEK-Jobs", "user2@seek.com.au").
+		Return(nil, &orgbot.GitHubUserNotFoundError{OrgName: "SEEK-Jobs", Email: "user2@seek.com.au"})
+	plat.MockGitHubService.
+		EXPECT().
+		DownloadDir(gomock.Any(), "SEEK-Jobs", "org", "master", "", gomock.Any()).
+		DoAndReturn(writeOrgRepo(map[string]string{
+			"org.yaml":         "name: SEEK-Jobs\n",
+			"team-a/team.yaml": "name: Team A\nmembers:\n- user1@seek.com.au\n- user2@seek.com.au\n",
+		}))
+
+	// Expect the open issue to be updated to list both departed users rather than another being opened
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateIssue(gomock.Any(), "SEEK-Jobs", "org", 5, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName, repoName string, number int, body string) error {
+			for _, want := range []string{departedUsersMarker, "@user1", "user1@seek.com.au", "user2@seek.com.au"} {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected issue body to contain %q, got %s", want, body)
+				}
+			}
+			return nil
+		})
+
+	payload := `{
+		"action": "member_removed",
+		"membership": {"user": {"login": "user1"}},
+		"organization": {"login": "SEEK-Jobs"}
+	}`
+	if err := HandleEvent(ctx, plat, organizationEvent, []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resetBranchOrgs clears the org cached from the org repo branch by earlier tests.
+func resetBranchOrgs() {
+	branchOrgs.mu.Lock()
+	defer branchOrgs.mu.Unlock()
+
+	branchOrgs.key = ""
+	branchOrgs.org = nil
+}
@@ -0,0 +1,244 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+const (
+	// applyStatusContext is the context of the commit statuses reported when applying the org repo
+	applyStatusContext = "orgbot/apply"
+
+	// maxStatusDescriptionLength is the maximum length of a commit status description accepted by GitHub
+	maxStatusDescriptionLength = 140
+)
+
+// orgRepo returns the owner and name of the configured org repo. False is returned as the third return
+// parameter if no org repo has been configured.
+func orgRepo(c *orgbot.Config) (string, string, bool) {
+	parts := strings.Split(c.OrgRepo, "/")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// isOrgRepo returns whether the repo with the specified full name is the configured org repo.
+func isOrgRepo(c *orgbot.Config, fullName string) bool {
+	return c.OrgRepo != "" && strings.EqualFold(c.OrgRepo, fullName)
+}
+
+// checkoutOrgRepo downloads the org directory of the org repo at the specified commit SHA to a new
+// temporary directory and returns its path. The caller is responsible for removing the directory.
+func checkoutOrgRepo(ctx context.Context, p orgbot.Platform, sha string) (string, error) {
+	owner, name, ok := orgRepo(p.Config())
+	if !ok {
+		return "", errors.New("no org repo has been configured")
+	}
+
+	dir, err := ioutil.TempDir("", "orgbot")
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.GitHubService().DownloadDir(ctx, owner, name, sha, p.Config().OrgRepoDir, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// mergeOrgAt downloads the org directory of the org repo at the specified commit SHA and merges it
+// into an Org.
+func mergeOrgAt(ctx context.Context, p orgbot.Platform, sha string) (*orgbot.Org, error) {
+	dir, err := checkoutOrgRepo(ctx, p, sha)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	org, err := orgbot.MergeOrg(p.Codec(), dir)
+	if err != nil {
+		return nil, relativiseMergeError(err, dir)
+	}
+
+	return org, nil
+}
+
+// branchOrgCache holds the org most recently merged from the org repo branch, keyed by the commit it was
+// merged from, so that bursts of events don't each download and merge the same commit.
+type branchOrgCache struct {
+	mu  sync.Mutex
+	key string      // Full name of the org repo and SHA of the commit the org was merged from
+	org *orgbot.Org // Org merged from the commit
+}
+
+// branchOrgs caches the org merged from the org repo branch across events.
+var branchOrgs branchOrgCache
+
+// mergeBranchOrg returns the org merged from the head of the org repo branch, only downloading and
+// merging the org repo when the branch has moved on since it was last merged. The returned org is a
+// copy so may be modified.
+func mergeBranchOrg(ctx context.Context, p orgbot.Platform) (*orgbot.Org, error) {
+	c := p.Config()
+	owner, name, ok := orgRepo(c)
+	if !ok {
+		return nil, errors.New("no org repo has been configured")
+	}
+
+	sha, err := p.GitHubService().BranchSHA(ctx, owner, name, c.OrgRepoBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Concurrent events wait for the first to merge the commit rather than merging it themselves
+	branchOrgs.mu.Lock()
+	defer branchOrgs.mu.Unlock()
+
+	key := strings.ToLower(c.OrgRepo) + "@" + sha
+	if branchOrgs.key != key {
+		org, err := mergeOrgAt(ctx, p, sha)
+		if err != nil {
+			return nil, err
+		}
+		branchOrgs.key = key
+		branchOrgs.org = org
+	}
+
+	return copyOrg(branchOrgs.org), nil
+}
+
+// copyOrg returns a deep copy of the specified org.
+func copyOrg(org *orgbot.Org) *orgbot.Org {
+	var copyTeams func([]*orgbot.Team) []*orgbot.Team
+	copyTeams = func(teams []*orgbot.Team) []*orgbot.Team {
+		if teams == nil {
+			return nil
+		}
+
+		copies := make([]*orgbot.Team, len(teams))
+		for i, t := range teams {
+			c := *t
+			c.Previously = append([]string(nil), t.Previously...)
+			c.Maintainers = append([]string(nil), t.Maintainers...)
+			c.Members = append([]string(nil), t.Members...)
+			c.RestrictMembers = append([]string(nil), t.RestrictMembers...)
+			c.Owners = append([]string(nil), t.Owners...)
+			c.Children = copyTeams(t.Children)
+			copies[i] = &c
+		}
+		return copies
+	}
+
+	return &orgbot.Org{Name: org.Name, Teams: copyTeams(org.Teams)}
+}
+
+// orgRepoPath returns the path within the org repo of the specified path relative to the org directory.
+func orgRepoPath(c *orgbot.Config, rel string) string {
+	return strings.TrimPrefix(path.Join(c.OrgRepoDir, rel), "/")
+}
+
+// orgDirPath is the inverse of orgRepoPath, returning the path relative to the org directory of the
+// specified path within the org repo. False is returned as the second return parameter if the path
+// is outside of the org directory.
+func orgDirPath(c *orgbot.Config, repoPath string) (string, bool) {
+	dir := strings.Trim(path.Clean("/"+c.OrgRepoDir), "/")
+	if dir == "" {
+		return repoPath, true
+	}
+
+	if !strings.HasPrefix(repoPath, dir+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(repoPath, dir+"/"), true
+}
+
+// isInvalidOrgError returns whether the specified error was caused by invalid org configuration
+// rather than a failure to process it.
+func isInvalidOrgError(err error) bool {
+	switch errors.Cause(err).(type) {
+	case *orgbot.CompositeRuleError, *orgbot.MissingControlFileError, *orgbot.UnexpectedFileError, *orgbot.InvalidTeamDirNameError,
+		*orgbot.DecodeError:
+		return true
+	}
+	return false
+}
+
+// relativiseMergeError rewrites the paths within the specified MergeOrg error to be relative to
+// the specified directory so that they refer to paths within the org repo.
+func relativiseMergeError(err error, dir string) error {
+	rel := func(path string) string {
+		if r, err := filepath.Rel(dir, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return path
+	}
+
+	switch e := errors.Cause(err).(type) {
+	case *orgbot.MissingControlFileError:
+		e.Dir = rel(e.Dir)
+	case *orgbot.UnexpectedFileError:
+		e.Path = rel(e.Path)
+	case *orgbot.InvalidTeamDirNameError:
+		e.Dir = rel(e.Dir)
+		e.ValidDir = rel(e.ValidDir)
+	case *orgbot.DecodeError:
+		e.Path = rel(e.Path)
+	default:
+		return &relativeMergeError{msg: strings.Replace(err.Error(), dir+string(filepath.Separator), "", -1), cause: err}
+	}
+
+	return err
+}
+
+// relativeMergeError is a MergeOrg error whose message has had the paths within it made relative
+// to the org repo. The original error is retained as its cause so that it can still be classified.
+type relativeMergeError struct {
+	msg   string
+	cause error
+}
+
+// Error implements error.
+func (e *relativeMergeError) Error() string {
+	return e.msg
+}
+
+// Cause implements the causer interface of github.com/pkg/errors.
+func (e *relativeMergeError) Cause() error {
+	return e.cause
+}
+
+// createCommitStatus reports the specified state of the specified commit on the org repo. Descriptions
+// that are too long for GitHub are truncated.
+func createCommitStatus(ctx context.Context, p orgbot.Platform, sha string, state orgbot.CommitState, description string) error {
+	owner, name, ok := orgRepo(p.Config())
+	if !ok {
+		return errors.New("no org repo has been configured")
+	}
+
+	if len(description) > maxStatusDescriptionLength {
+		description = description[:maxStatusDescriptionLength-3] + "..."
+	}
+
+	status := orgbot.CommitStatus{
+		Context:     applyStatusContext,
+		State:       state,
+		Description: description,
+	}
+
+	if err := p.GitHubService().CreateCommitStatus(ctx, owner, name, sha, &status); err != nil {
+		return errors.Wrapf(err, "failed to create commit status for %s", sha)
+	}
+
+	return nil
+}
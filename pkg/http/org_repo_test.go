@@ -0,0 +1,50 @@
+package http
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/yaml"
+)
+
+func TestRelativiseMergeErrorDecodeError(t *testing.T) {
+	dir := filepath.Join("..", "orgbot", "test_data", "has-maintainers")
+
+	_, err := orgbot.MergeOrg(yaml.NewCodec(), dir)
+	if err == nil {
+		t.Fatal("Expected merge error")
+	}
+
+	err = relativiseMergeError(err, dir)
+	decodeErr, ok := errors.Cause(err).(*orgbot.DecodeError)
+	if !ok {
+		t.Fatalf("Expected decode error, got %v", err)
+	}
+	if strings.Contains(decodeErr.Path, "test_data") {
+		t.Errorf("Expected path relative to the org directory, got %s", decodeErr.Path)
+	}
+	if !isInvalidOrgError(err) {
+		t.Errorf("Expected decode error to be an invalid org error")
+	}
+	if isRetryable(err) {
+		t.Errorf("Expected decode error to be unretryable")
+	}
+}
+
+func TestRelativiseMergeErrorKeepsCause(t *testing.T) {
+	dir := filepath.Join("tmp", "org")
+	cause := errors.New("permission denied")
+	err := errors.Wrapf(cause, "could not read %s", filepath.Join(dir, "team-a", "team.yaml"))
+
+	got := relativiseMergeError(err, dir)
+	if want := "could not read team-a/team.yaml: permission denied"; got.Error() != want {
+		t.Errorf("Expected message %q, got %q", want, got)
+	}
+	if errors.Cause(got) != cause {
+		t.Errorf("Expected cause to be kept, got %v", errors.Cause(got))
+	}
+}
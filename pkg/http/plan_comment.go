@@ -0,0 +1,215 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+// planCommentMarker is a hidden marker that identifies the plan comment on a pull request so that
+// it's updated in place rather than a new comment being added for every push.
+const planCommentMarker = "<!-- orgbot:plan -->"
+
+// upsertPlanComment creates or updates the plan comment on the specified pull request of the org repo
+// so that it describes the specified validation. Only comments made by orgbot are updated.
+func upsertPlanComment(ctx context.Context, p orgbot.Platform, number int, v *orgValidation) error {
+	owner, name, _ := orgRepo(p.Config())
+	svc := p.GitHubService()
+
+	plan, err := planComment(ctx, p, v)
+	if err != nil {
+		return err
+	}
+	body := planCommentMarker + "\n" + plan
+
+	login, err := svc.Login(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get login of orgbot")
+	}
+
+	comments, err := svc.ListIssueComments(ctx, owner, name, number)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list comments of pull request #%d", number)
+	}
+
+	// Anyone can include the marker in their comments but only those of orgbot are its to update
+	for _, c := range comments {
+		if c.Author == login && strings.Contains(c.Body, planCommentMarker) {
+			log.Ctx(ctx).Info().Msgf("Updating plan comment %d on pull request #%d", c.ID, number)
+			return svc.UpdateIssueComment(ctx, owner, name, c.ID, body)
+		}
+	}
+
+	log.Ctx(ctx).Info().Msgf("Creating plan comment on pull request #%d", number)
+	return svc.CreateIssueComment(ctx, owner, name, number, body)
+}
+
+// planComment returns a markdown description of the plan of the specified validation.
+func planComment(ctx context.Context, p orgbot.Platform, v *orgValidation) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### orgbot plan for %s\n\n", shortSHA(v.sha))
+
+	if v.plan == nil {
+		fmt.Fprintf(&b, "%s. See the `%s` check for details.\n", v.run.Title, v.run.Name)
+		return b.String(), nil
+	}
+
+	if !v.plan.HasChanges() {
+		b.WriteString("Merging this pull request will not change the org.\n")
+		return b.String(), nil
+	}
+
+	sensitive, err := sensitiveChanges(ctx, p, v.orgName, v.plan.Teams)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sensitive) > 0 {
+		b.WriteString(":warning: **Sensitive changes**\n\n")
+		for _, s := range sensitive {
+			fmt.Fprintf(&b, "- %s\n", s)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(describeTeamReports(v.plan.Teams))
+
+	return b.String(), nil
+}
+
+// describeTeamReports returns a markdown description of the changes described by the specified reports.
+func describeTeamReports(reports []*orgbot.TeamReport) string {
+	var b strings.Builder
+
+	var created, renamed, deleted []string
+	for _, r := range reports {
+		switch {
+		case r.Created:
+			created = append(created, fmt.Sprintf("`%s`", r.Team))
+		case r.Deleted:
+			deleted = append(deleted, fmt.Sprintf("`%s`", r.Team))
+		case r.RenamedFrom != "":
+			renamed = append(renamed, fmt.Sprintf("`%s` to `%s`", r.RenamedFrom, r.Team))
+		}
+	}
+
+	if len(created) > 0 {
+		fmt.Fprintf(&b, "**Teams created:** %s\n\n", strings.Join(created, ", "))
+	}
+	if len(renamed) > 0 {
+		fmt.Fprintf(&b, "**Teams renamed:** %s\n\n", strings.Join(renamed, ", "))
+	}
+	if len(deleted) > 0 {
+		fmt.Fprintf(&b, "**Teams deleted:** %s\n\n", strings.Join(deleted, ", "))
+	}
+
+	var memberships []string
+	for _, r := range reports {
+		var changes []string
+		if len(r.Added) > 0 {
+			changes = append(changes, "added "+strings.Join(r.Added, ", "))
+		}
+		if len(r.Removed) > 0 {
+			changes = append(changes, "removed "+strings.Join(r.Removed, ", "))
+		}
+		if len(changes) > 0 {
+			memberships = append(memberships, fmt.Sprintf("- `%s`: %s", r.Team, strings.Join(changes, "; ")))
+		}
+	}
+
+	if len(memberships) > 0 {
+		fmt.Fprintf(&b, "**Membership changes:**\n\n%s\n", strings.Join(memberships, "\n"))
+	}
+
+	return b.String()
+}
+
+// sensitiveChanges returns descriptions of the planned changes that remove access to repos with admin
+// permission, i.e. the removal of users from, or the deletion of, teams that have admin access to repos.
+func sensitiveChanges(ctx context.Context, p orgbot.Platform, orgName string, reports []*orgbot.TeamReport) ([]string, error) {
+	svc := p.GitHubService()
+
+	teams, err := svc.ListTeams(ctx, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	teamsByName := map[string]*orgbot.GitHubTeam{}
+	for _, t := range teams {
+		teamsByName[t.Name] = t
+	}
+
+	var sensitive []string
+	for _, r := range reports {
+		if !r.Deleted && len(r.Removed) == 0 {
+			continue
+		}
+
+		// The plan hasn't been applied so renamed teams still have their previous name
+		liveName := r.Team
+		if r.RenamedFrom != "" {
+			liveName = r.RenamedFrom
+		}
+
+		t, ok := teamsByName[liveName]
+		if !ok {
+			continue
+		}
+
+		adminRepos, err := teamAdminRepos(ctx, svc, orgName, t)
+		if err != nil {
+			return nil, err
+		}
+		if len(adminRepos) == 0 {
+			continue
+		}
+
+		repos := fmt.Sprintf("%d repo(s): %s", len(adminRepos), strings.Join(adminRepos, ", "))
+		if r.Deleted {
+			sensitive = append(sensitive, fmt.Sprintf("Deleting `%s` which has admin access to %s", r.Team, repos))
+		} else {
+			sensitive = append(sensitive, fmt.Sprintf("Removing %s from `%s` which has admin access to %s",
+				strings.Join(r.Removed, ", "), r.Team, repos))
+		}
+	}
+
+	return sensitive, nil
+}
+
+// teamAdminRepos returns the sorted names of the unarchived repos that the specified team has admin
+// permission on.
+func teamAdminRepos(ctx context.Context, svc orgbot.GitHubService, orgName string, t *orgbot.GitHubTeam) ([]string, error) {
+	var repos []string
+	if err := svc.WalkReposByTeam(ctx, orgName, t.ID, func(r *orgbot.Repo) error {
+		if r.Archived {
+			return nil
+		}
+
+		for _, tp := range r.Teams {
+			if tp.TeamName == t.Name && tp.Permission == orgbot.RepoPermissionAdmin {
+				repos = append(repos, r.Name)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(repos)
+
+	return repos, nil
+}
+
+// shortSHA returns the abbreviated form of the specified commit SHA.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+func TestUpsertPlanCommentOnlyUpdatesOwnComments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	ctx := context.Background()
+
+	v := orgValidation{sha: "abcdef0123", run: &orgbot.CheckRun{Name: validateCheckName, Title: "Org configuration is invalid"}}
+
+	plat.MockGitHubService.
+		EXPECT().
+		Login(gomock.Any()).
+		Return("orgbot[bot]", nil).
+		Times(2)
+
+	// A user quoting the plan comment must not have their comment overwritten
+	quoted := &orgbot.IssueComment{ID: 1, Author: "someone", Body: "> " + planCommentMarker}
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListIssueComments(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.IssueComment{quoted}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		CreateIssueComment(gomock.Any(), "SEEK-Jobs", "org", 7, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName, repoName string, number int, body string) error {
+			if !strings.HasPrefix(body, planCommentMarker) {
+				t.Errorf("Expected plan comment to start with the marker, got %q", body)
+			}
+			return nil
+		})
+
+	if err := upsertPlanComment(ctx, plat, 7, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once orgbot has commented, its own comment is updated
+	plat.MockGitHubService.
+		EXPECT().
+		ListIssueComments(gomock.Any(), "SEEK-Jobs", "org", 7).
+		Return([]*orgbot.IssueComment{quoted, {ID: 2, Author: "orgbot[bot]", Body: planCommentMarker + "\nold plan"}}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateIssueComment(gomock.Any(), "SEEK-Jobs", "org", int64(2), gomock.Any()).
+		Return(nil)
+
+	if err := upsertPlanComment(ctx, plat, 7, &v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,232 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+// validateCheckName is the name of the check run published for pull requests to the org repo
+const validateCheckName = "orgbot/validate"
+
+// handlePullRequestEvent validates the org configuration of pull requests to the org repo whenever
+// they are opened or updated, publishing the outcome along with the changes that would be made if
+// the pull request were merged as a check run on its head commit and as a plan comment on the pull
+// request. The owner approvals of the pull request are also checked. Other pull requests are ignored.
+func handlePullRequestEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*hub.PullRequestEvent)
+	fullName := event.GetRepo().GetFullName()
+	if !isOrgRepo(p.Config(), fullName) || !isPullRequestUpdate(event.GetAction()) {
+		log.Ctx(ctx).Debug().Msgf("Ignoring pull request %s event for repo %s", event.GetAction(), fullName)
+		return nil
+	}
+
+	// The approvals check is independent of validation so is published even if validation fails
+	validateErr := validatePullRequest(ctx, p, event)
+	if err := publishApprovalsCheck(ctx, p, newPullRequest(event.GetPullRequest())); err != nil {
+		if validateErr != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("Failed to check approvals")
+			return validateErr
+		}
+		return err
+	}
+
+	return validateErr
+}
+
+// validatePullRequest validates the head commit of the pull request of the specified event and
+// publishes the outcome as a check run and plan comment.
+func validatePullRequest(ctx context.Context, p orgbot.Platform, event *hub.PullRequestEvent) error {
+	c := p.Config()
+	fullName := event.GetRepo().GetFullName()
+	owner, name, _ := orgRepo(c)
+	sha := event.GetPullRequest().GetHead().GetSHA()
+	log.Ctx(ctx).Info().Msgf("Validating %s of pull request #%d of repo %s", sha, event.GetNumber(), fullName)
+
+	v, err := validateOrgAt(ctx, p, sha)
+	if err != nil {
+		// Let contributors know that the outcome is unknown rather than leaving the check missing
+		failed := orgbot.CheckRun{
+			Name:       validateCheckName,
+			HeadSHA:    sha,
+			Conclusion: orgbot.CheckConclusionNeutral,
+			Title:      "Org configuration could not be validated",
+			Summary:    err.Error(),
+		}
+		if runErr := p.GitHubService().CreateCheckRun(ctx, owner, name, &failed); runErr != nil {
+			log.Ctx(ctx).Error().Err(runErr).Msg("Failed to report validation failure")
+		}
+		return errors.Wrapf(err, "failed to validate %s of repo %s", sha, fullName)
+	}
+
+	if err := p.GitHubService().CreateCheckRun(ctx, owner, name, v.run); err != nil {
+		return err
+	}
+
+	return upsertPlanComment(ctx, p, event.GetNumber(), v)
+}
+
+// isPullRequestUpdate returns whether the specified pull request action changes the head commit.
+func isPullRequestUpdate(action string) bool {
+	return action == "opened" || action == "synchronize" || action == "reopened"
+}
+
+// orgValidation is the outcome of validating the org configuration at a commit of the org repo.
+type orgValidation struct {
+	sha     string                 // SHA of the validated commit
+	orgName string                 // Name of the org (empty if the configuration could not be merged)
+	run     *orgbot.CheckRun       // Outcome as a check run
+	plan    *orgbot.ApplyOrgResult // Changes that applying the configuration would make (nil if invalid)
+}
+
+// validateOrgAt merges the org configuration at the specified commit of the org repo, runs the rules
+// against it and plans the changes that applying it would make against the live org. An error is only
+// returned if the configuration could not be validated.
+func validateOrgAt(ctx context.Context, p orgbot.Platform, sha string) (*orgValidation, error) {
+	c := p.Config()
+
+	dir, err := checkoutOrgRepo(ctx, p, sha)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	run := orgbot.CheckRun{Name: validateCheckName, HeadSHA: sha}
+	v := orgValidation{sha: sha, run: &run}
+
+	org, err := orgbot.MergeOrg(p.Codec(), dir)
+	if err != nil {
+		err = relativiseMergeError(err, dir)
+		if !isInvalidOrgError(err) {
+			return nil, err
+		}
+
+		run.Conclusion = orgbot.CheckConclusionFailure
+		run.Title = "Org configuration could not be merged"
+		run.Summary = err.Error()
+		if a := mergeErrorAnnotation(c, err); a != nil {
+			run.Annotations = append(run.Annotations, a)
+		}
+		return &v, nil
+	}
+	v.orgName = org.Name
+
+	// Applying to the read-only platform runs the rules and plans the changes without making them
+	res, err := orgbot.ApplyOrg(ctx, cmd.NewReadOnlyPlatform(p), org, nil)
+	if ruleErr, ok := errors.Cause(err).(*orgbot.CompositeRuleError); ok {
+		files, err := orgbot.TeamFiles(p.Codec(), dir)
+		if err != nil {
+			return nil, err
+		}
+
+		run.Conclusion = orgbot.CheckConclusionFailure
+		run.Title = fmt.Sprintf("Org configuration violates %d rule(s)", len(ruleErr.Errors))
+		run.Summary = describeRuleErrors(ruleErr)
+		run.Annotations = ruleErrorAnnotations(c, ruleErr, files)
+		return &v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run.Conclusion = orgbot.CheckConclusionSuccess
+	run.Title = "Org configuration is valid"
+	run.Summary = describeApplyOrgPlan(res)
+	v.plan = res
+	return &v, nil
+}
+
+// mergeErrorAnnotation returns an annotation of the file responsible for the specified MergeOrg error,
+// or nil if there is no such file.
+func mergeErrorAnnotation(c *orgbot.Config, err error) *orgbot.CheckAnnotation {
+	var rel string
+	switch e := errors.Cause(err).(type) {
+	case *orgbot.UnexpectedFileError:
+		rel = e.Path
+	case *orgbot.DecodeError:
+		rel = e.Path
+	case *orgbot.InvalidTeamDirNameError:
+		rel = path.Join(e.Dir, "team.yaml")
+	default:
+		return nil
+	}
+
+	return &orgbot.CheckAnnotation{
+		Path:    orgRepoPath(c, rel),
+		Line:    1,
+		Level:   orgbot.CheckAnnotationFailure,
+		Message: err.Error(),
+	}
+}
+
+// ruleErrorAnnotations returns annotations of the team files responsible for the specified rule
+// violations. Violations that can't be attributed to the file of a team (e.g. those of deleted
+// teams) are only described in the summary.
+func ruleErrorAnnotations(c *orgbot.Config, ruleErr *orgbot.CompositeRuleError, files map[string][]string) []*orgbot.CheckAnnotation {
+	var annotations []*orgbot.CheckAnnotation
+	for _, re := range ruleErr.Errors {
+		teamErr, ok := re.(orgbot.TeamRuleError)
+		if !ok {
+			continue
+		}
+
+		violations := teamErr.TeamViolations()
+		var teamNames []string
+		for t := range violations {
+			teamNames = append(teamNames, t)
+		}
+		sort.Strings(teamNames)
+
+		for _, t := range teamNames {
+			msg := re.Description()
+			if violations[t] != "" {
+				msg = fmt.Sprintf("%s: %s", msg, violations[t])
+			}
+
+			for _, f := range files[t] {
+				annotations = append(annotations, &orgbot.CheckAnnotation{
+					Path:    orgRepoPath(c, f),
+					Line:    1,
+					Level:   orgbot.CheckAnnotationFailure,
+					Title:   fmt.Sprintf("Team '%s'", t),
+					Message: fmt.Sprintf("%s (see %s)", msg, re.Link()),
+				})
+			}
+		}
+	}
+
+	return annotations
+}
+
+// describeRuleErrors returns a markdown description of the specified rule violations.
+func describeRuleErrors(ruleErr *orgbot.CompositeRuleError) string {
+	var b strings.Builder
+	for _, re := range ruleErr.Errors {
+		fmt.Fprintf(&b, "### %s\n\n%s\n\n[More information](%s)\n\n", re.Description(), re.ConstraintViolations(), re.Link())
+	}
+	return b.String()
+}
+
+// describeApplyOrgPlan returns a markdown description of the changes planned by the specified result.
+func describeApplyOrgPlan(res *orgbot.ApplyOrgResult) string {
+	if !res.HasChanges() {
+		return "Merging this pull request will not change the org."
+	}
+
+	return fmt.Sprintf("Merging this pull request will make the following changes to the org:\n\n"+
+		"| | Created/Added | Updated | Deleted |\n"+
+		"|---|---|---|---|\n"+
+		"| Teams | %d | %d | %d |\n"+
+		"| Memberships | %d | | %d |\n",
+		res.TeamsCreated, res.TeamsUpdated, res.TeamsDeleted, res.MembershipsAdded, res.MembershipsDeleted)
+}
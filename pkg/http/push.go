@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"fmt"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+// handlePushEvent applies the org configuration whenever the configured branch of the org repo is
+// pushed to, reporting the outcome as a status of the pushed commit. Pushes to other repos and
+// branches, and of commits that are no longer the head of the branch, are ignored.
+func handlePushEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*hub.PushEvent)
+	c := p.Config()
+	fullName := event.GetRepo().GetFullName()
+	if !isOrgRepo(c, fullName) || event.GetRef() != "refs/heads/"+c.OrgRepoBranch || event.GetDeleted() {
+		log.Ctx(ctx).Debug().Msgf("Ignoring push to %s of repo %s", event.GetRef(), fullName)
+		return nil
+	}
+
+	sha := event.GetAfter()
+	log.Ctx(ctx).Info().Msgf("Received push of %s to %s of repo %s", sha, event.GetRef(), fullName)
+
+	// Pushes that have been superseded, such as when retried or replayed, would roll the org back
+	owner, name, _ := orgRepo(c)
+	head, err := p.GitHubService().BranchSHA(ctx, owner, name, c.OrgRepoBranch)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get head of %s of repo %s", c.OrgRepoBranch, fullName)
+	}
+	if head != sha {
+		log.Ctx(ctx).Info().Msgf("Skipping push of %s as %s of repo %s has moved on to %s", sha, event.GetRef(), fullName, head)
+		return nil
+	}
+
+	if err := createCommitStatus(ctx, p, sha, orgbot.CommitStatePending, "Applying org configuration"); err != nil {
+		return err
+	}
+
+	res, err := applyOrgAt(ctx, p, sha)
+	if err != nil {
+		// Invalid configuration is the fault of the commit whereas anything else is ours
+		state := orgbot.CommitStateError
+		if isInvalidOrgError(err) {
+			state = orgbot.CommitStateFailure
+		}
+
+		if statusErr := createCommitStatus(ctx, p, sha, state, err.Error()); statusErr != nil {
+			log.Ctx(ctx).Error().Err(statusErr).Msg("Failed to report apply failure")
+		}
+		return errors.Wrapf(err, "failed to apply %s of repo %s", sha, fullName)
+	}
+
+	return createCommitStatus(ctx, p, sha, orgbot.CommitStateSuccess, describeApplyOrgResult(res))
+}
+
+// applyOrgAt merges the org configuration at the specified commit of the org repo and applies it.
+func applyOrgAt(ctx context.Context, p orgbot.Platform, sha string) (*orgbot.ApplyOrgResult, error) {
+	org, err := mergeOrgAt(ctx, p, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	// Topics derived from the old names of renamed teams are replaced as part of the apply
+	return orgbot.ApplyOrg(ctx, p, org, &orgbot.ApplyOrgOptions{RefreshTopics: true})
+}
+
+// describeApplyOrgResult returns a short description of the specified result.
+func describeApplyOrgResult(res *orgbot.ApplyOrgResult) string {
+	if !res.HasChanges() {
+		return "Org configuration applied with no changes"
+	}
+
+	return fmt.Sprintf("Teams: %d created, %d updated, %d deleted. Memberships: %d added, %d deleted",
+		res.TeamsCreated, res.TeamsUpdated, res.TeamsDeleted, res.MembershipsAdded, res.MembershipsDeleted)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+)
+
+func TestHandlePushEventSkipsSupersededCommits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	ctx := context.Background()
+
+	// The branch has moved on since the push, so the pushed commit isn't applied or given a status
+	plat.MockGitHubService.
+		EXPECT().
+		BranchSHA(gomock.Any(), "SEEK-Jobs", "org", "master").
+		Return("new-sha", nil)
+
+	payload := `{
+		"ref": "refs/heads/master",
+		"after": "old-sha",
+		"repository": {"name": "org", "full_name": "SEEK-Jobs/org", "owner": {"login": "SEEK-Jobs"}}
+	}`
+	if err := HandleEvent(ctx, plat, pushEvent, []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandlePushEventAppliesHeadCommit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().OrgRepo = "SEEK-Jobs/org"
+	plat.Config().OrgRepoBranch = "master"
+	ctx := context.Background()
+
+	plat.MockGitHubService.
+		EXPECT().
+		BranchSHA(gomock.Any(), "SEEK-Jobs", "org", "master").
+		Return("head-sha", nil)
+
+	// The head commit is applied, failing as its configuration is invalid
+	gomock.InOrder(
+		plat.MockGitHubService.
+			EXPECT().
+			CreateCommitStatus(gomock.Any(), "SEEK-Jobs", "org", "head-sha", gomock.Any()).
+			DoAndReturn(expectCommitState(t, orgbot.CommitStatePending)),
+		plat.MockGitHubService.
+			EXPECT().
+			CreateCommitStatus(gomock.Any(), "SEEK-Jobs", "org", "head-sha", gomock.Any()).
+			DoAndReturn(expectCommitState(t, orgbot.CommitStateFailure)),
+	)
+	plat.MockGitHubService.
+		EXPECT().
+		DownloadDir(gomock.Any(), "SEEK-Jobs", "org", "head-sha", "", gomock.Any()).
+		DoAndReturn(writeOrgRepo(map[string]string{
+			"org.yaml": "name: [\n",
+		}))
+
+	payload := `{
+		"ref": "refs/heads/master",
+		"after": "head-sha",
+		"repository": {"name": "org", "full_name": "SEEK-Jobs/org", "owner": {"login": "SEEK-Jobs"}}
+	}`
+	if err := HandleEvent(ctx, plat, pushEvent, []byte(payload)); err == nil {
+		t.Fatal("Expected applying invalid configuration to fail")
+	}
+}
+
+// expectCommitState returns a CreateCommitStatus implementation that checks the status has the specified state.
+func expectCommitState(t *testing.T, want orgbot.CommitState) func(context.Context, string, string, string, *orgbot.CommitStatus) error {
+	return func(ctx context.Context, orgName, repoName, sha string, status *orgbot.CommitStatus) error {
+		if status.State != want {
+			t.Errorf("Expected commit state %s, got %s", want, status.State)
+		}
+		return nil
+	}
+}
@@ -2,194 +2,299 @@ package http
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	hub "github.com/google/go-github/github"
 	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
 	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
 )
 
-type queueService struct {
-	config    *orgbot.Config
-	sqsClient sqsiface.SQSAPI
-}
+const (
+	// maxRetryDelay is the longest delay before a failed message is retried
+	maxRetryDelay = 15 * time.Minute
 
-type message struct {
-	InstallationID int64
-	EventType      string
-	DeliveryID     string
-	// String is better- base64 encoded if byte[]
-	Payload string
-}
+	// retriedMessagesMetric is the name of the counter of messages that failed to be handled and were retried
+	retriedMessagesMetric = "events.retried"
 
-type receiptHandle string
+	// deadLetteredMessagesMetric is the name of the counter of messages that were dead-lettered
+	deadLetteredMessagesMetric = "events.dead_lettered"
+)
 
-// newQueueService returns a configured queueService implementation.
-func newQueueService(c *orgbot.Config) (*queueService, error) {
-	sess, err := cmd.NewAWSSession()
-	if err != nil {
-		return nil, err
+// ListenForEvents polls the specified queue for Github event payloads until the specified context is
+// done or receiving fails. The configured number of workers handle messages in parallel, with the queue
+// keeping messages in the same group in order. Messages that fail to be handled are retried with backoff
+// until the configured maximum number of attempts, after which they're moved to the specified dead letter
+// store. Payloads too large to be queued are resolved from the specified blob store. The messages being
+// handled when the context is done are finished before returning.
+func ListenForEvents(ctx context.Context, p orgbot.Platform, q queue.Queue, dls queue.DeadLetterStore, blobs queue.BlobStore) error {
+	workers := p.Config().Workers
+	if workers < 1 {
+		workers = 1
 	}
 
-	return &queueService{
-		config:    c,
-		sqsClient: sqs.New(sess),
-	}, nil
+	log.Info().Msgf("Starting queue service with %d workers", workers)
 
-}
+	// A worker that fails to receive stops the others
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-// submit puts a given message on the queue
-func (s *queueService) submit(m *message) error {
-	buf, err := json.Marshal(m)
-	if err != nil {
-		return err
-	}
+	c := newCoalescer(p.Config().CoalesceWindow)
 
-	input := sqs.SendMessageInput{
-		MessageBody:            aws.String(string(buf)),
-		MessageDeduplicationId: aws.String(m.DeliveryID),
-		MessageGroupId:         aws.String(s.config.Name),
-		QueueUrl:               aws.String(s.config.QueueURL),
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := listen(ctx, p, q, dls, blobs, c); err != nil {
+				errs <- err
+				cancel()
+			}
+		}()
 	}
+	wg.Wait()
+	close(errs)
 
-	_, err = s.sqsClient.SendMessage(&input)
-	return err
+	// Finish the messages whose handling is deferred rather than leaving them to be redelivered
+	c.flush()
+
+	log.Info().Msgf("Stopped queue service")
+	return <-errs
 }
 
-// receive fetches messages from the queue
-func (s *queueService) receive() (*message, receiptHandle, error) {
-	receiveInput := &sqs.ReceiveMessageInput{
-		MaxNumberOfMessages: aws.Int64(1),
-		QueueUrl:            aws.String(s.config.QueueURL),
-	}
+// listen receives and handles messages from the specified queue one at a time until the specified context
+// is done or receiving fails. Messages that can be coalesced are handed to the specified coalescer.
+func listen(ctx context.Context, p orgbot.Platform, q queue.Queue, dls queue.DeadLetterStore, blobs queue.BlobStore, c *coalescer) error {
+	// Messages are handled with a context that isn't cancelled so that they're finished during shutdown
+	handleCtx := zerolog.Ctx(ctx).WithContext(context.Background())
 
-	messageOutput, err := s.sqsClient.ReceiveMessage(receiveInput)
-	if err != nil {
-		return nil, "", errors.Wrap(err, "failed to receive message from sqs")
+	for ctx.Err() == nil {
+		m, receiptHandle, err := q.Receive(ctx)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to receive message")
+			return err
+		}
+		received := time.Now()
+
+		if m == nil {
+			continue
+		}
+
+		if !m.IsSupported() {
+			// A newer orgbot submitted the message, such as during a deploy, so leave it for one to handle
+			// unless it's been left long enough that none are going to
+			err := fmt.Errorf("unsupported message version %d", m.Version)
+			if _, err := settleMessage(handleCtx, p.Config(), q, dls, m, receiptHandle, err); err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msgf("Failed to settle %s event %s", m.EventType, m.DeliveryID)
+			}
+			continue
+		}
+
+		// The payload is kept until the message has left the queue, and only then if it has been resolved
+		// so that any dead letter holds it
+		payloadRef := m.PayloadRef
+		if err := queue.ResolvePayload(handleCtx, blobs, m); err != nil {
+			if _, err := settleMessage(handleCtx, p.Config(), q, dls, m, receiptHandle, err); err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msgf("Failed to settle %s event %s", m.EventType, m.DeliveryID)
+			}
+			continue
+		}
+
+		handleFn := func() error {
+			err := handleMessage(handleCtx, p, m)
+			settled, settleErr := settleMessage(handleCtx, p.Config(), q, dls, m, receiptHandle, err)
+			if settleErr != nil {
+				zerolog.Ctx(ctx).Error().Err(settleErr).Msgf("Failed to settle %s event %s", m.EventType, m.DeliveryID)
+			}
+			if settled {
+				deletePayload(handleCtx, blobs, m, payloadRef)
+			}
+			return err
+		}
+
+		key := coalesceKey(p.Config(), m)
+		switch {
+		case key == "":
+			_ = handleFn()
+
+		case c.isRedundant(key, m):
+			metrics.GetOrRegisterCounter(coalescedMessagesMetric, cmd.MetricsRegistry()).Inc(1)
+			zerolog.Ctx(ctx).Debug().Msgf("Skipping %s event %s as it has been coalesced", m.EventType, m.DeliveryID)
+			if err := q.Delete(handleCtx, receiptHandle); err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msgf("Failed to delete %s event %s", m.EventType, m.DeliveryID)
+			} else {
+				deletePayload(handleCtx, blobs, m, payloadRef)
+			}
+
+		default:
+			// Handling is deferred so that the rest of a burst reaches the hook before it starts
+			c.schedule(key, m, received, handleFn)
+		}
 	}
 
-	if len(messageOutput.Messages) == 0 {
-		return nil, "", nil
+	return nil
+}
+
+// settleMessage deletes the specified message from the queue if it was handled without error. Otherwise
+// it's retried, or dead-lettered if it has been attempted the maximum number of times or the error can't
+// be fixed by retrying. Returns whether the message has left the queue.
+func settleMessage(ctx context.Context, c *orgbot.Config, q queue.Queue, dls queue.DeadLetterStore, m *queue.Message, handle queue.ReceiptHandle, handleErr error) (bool, error) {
+	if handleErr == nil {
+		return deleteMessage(ctx, q, handle)
 	}
 
-	if len(messageOutput.Messages) > 1 {
-		return nil, "", fmt.Errorf("received too many messages from sqs (expected 1, received %d)", len(messageOutput.Messages))
+	delay := retryDelay(c, m.Attempts)
+	if m.Attempts < c.MaxAttempts && isRetryable(handleErr) {
+		metrics.GetOrRegisterCounter(retriedMessagesMetric, cmd.MetricsRegistry()).Inc(1)
+		zerolog.Ctx(ctx).Warn().Err(handleErr).Msgf("Failed to handle %s event %s (attempt %d of %d), retrying in %v",
+			m.EventType, m.DeliveryID, m.Attempts, c.MaxAttempts, delay)
+		return false, q.Retry(ctx, handle, delay)
 	}
 
-	var m message
-	receivedMessage := messageOutput.Messages[0]
-	if err := json.Unmarshal([]byte(*receivedMessage.Body), &m); err != nil {
-		return nil, "", errors.Wrap(err, "failed to unmarshal sqs message")
+	d := queue.DeadLetter{
+		Message:  *m,
+		Attempts: m.Attempts,
+		Error:    handleErr.Error(),
+		Time:     time.Now().UTC(),
+	}
+	if err := dls.Put(ctx, &d); err != nil {
+		// Keep the message on the queue rather than lose it
+		if retryErr := q.Retry(ctx, handle, delay); retryErr != nil {
+			zerolog.Ctx(ctx).Error().Err(retryErr).Msgf("Failed to retry %s event %s", m.EventType, m.DeliveryID)
+		}
+		return false, errors.Wrap(err, "failed to dead-letter message")
 	}
 
-	return &m, receiptHandle(*receivedMessage.ReceiptHandle), nil
+	metrics.GetOrRegisterCounter(deadLetteredMessagesMetric, cmd.MetricsRegistry()).Inc(1)
+	zerolog.Ctx(ctx).Error().Err(handleErr).Msgf("Failed to handle %s event %s after %d attempts, dead-lettered it",
+		m.EventType, m.DeliveryID, m.Attempts)
+
+	return deleteMessage(ctx, q, handle)
 }
 
-// delete removes a message from the queue, signalling that processing it is successfully completed
-func (s *queueService) delete(handle receiptHandle) error {
-	if handle == "" {
-		return nil
-	}
-	deleteInput := &sqs.DeleteMessageInput{
-		QueueUrl:      aws.String(s.config.QueueURL),
-		ReceiptHandle: aws.String(string(handle)),
+// deleteMessage deletes the message with the specified receipt handle from the specified queue, returning
+// whether it was deleted.
+func deleteMessage(ctx context.Context, q queue.Queue, handle queue.ReceiptHandle) (bool, error) {
+	if err := q.Delete(ctx, handle); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	_, err := s.sqsClient.DeleteMessage(deleteInput)
-	if err != nil {
-		return errors.Wrap(err, "failed to delete message from sqs")
+// deletePayload deletes the offloaded payload with the specified reference of the specified message, which
+// has left the queue, from the specified blob store. Failing to delete it only leaves it behind.
+func deletePayload(ctx context.Context, blobs queue.BlobStore, m *queue.Message, payloadRef string) {
+	if payloadRef == "" || blobs == nil {
+		return
 	}
 
-	return nil
+	if err := blobs.Delete(ctx, payloadRef); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msgf("Failed to delete payload %s of %s event %s", payloadRef, m.EventType, m.DeliveryID)
+	}
 }
 
-// ListenForEvents polls an SQS queue for Github event payloads
-func ListenForEvents(ctx context.Context, p orgbot.Platform, errChan chan error) {
-	log.Info().Msgf("Starting queue service")
+// retryDelay returns the delay before a message that has failed the specified number of attempts is
+// retried. The configured backoff is doubled for each attempt after the first, up to maxRetryDelay.
+func retryDelay(c *orgbot.Config, attempts int) time.Duration {
+	delay := c.RetryBackoff
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
 
-	queueService, err := newQueueService(p.Config())
-	if err != nil {
-		zerolog.Ctx(ctx).Error().Err(err)
-		errChan <- err
-		return
+	if delay > maxRetryDelay {
+		return maxRetryDelay
 	}
+	return delay
+}
 
-	for {
-		m, receiptHandle, err := queueService.receive()
-		if err != nil {
-			zerolog.Ctx(ctx).Error().Err(err)
-			errChan <- err
-			return
-		}
+// handleMessage decodes the event payload of the specified message and handles it according to
+// its event type.
+func handleMessage(ctx context.Context, p orgbot.Platform, m *queue.Message) error {
+	return events.route(ctx, p, m)
+}
 
-		if m == nil {
-			continue
-		}
+// HandleEvent handles the specified event payload as if it had been received from the queue, such as when
+// simulating the delivery of an event.
+func HandleEvent(ctx context.Context, p orgbot.Platform, eventType string, payload []byte) error {
+	return handleMessage(ctx, p, &queue.Message{EventType: eventType, Payload: string(payload)})
+}
 
-		var event hub.TeamEvent
-		if err := json.Unmarshal([]byte(m.Payload), &event); err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Msgf("Failed to unmarshal message")
-			continue
-		}
+// handleTeamEvent refreshes the topics of the repos affected by the specified team event. Teams that
+// are created, edited or deleted outside of orgbot are reverted if their org is enforced.
+func handleTeamEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*hub.TeamEvent)
+	if event.Action == nil {
+		return nil
+	}
 
-		if event.Repo != nil && *event.Repo.Fork {
-			log.Ctx(ctx).Warn().Msgf("Skipping repo %s", *event.Repo.FullName)
-			err := queueService.delete(receiptHandle)
-			if err != nil {
-				zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to delete message")
-			}
-			continue
-		}
+	if event.Repo.GetFork() {
+		log.Ctx(ctx).Warn().Msgf("Skipping repo %s", event.Repo.GetFullName())
+		return nil
+	}
 
-		if event.Action != nil {
-			switch *event.Action {
-			case "added_to_repository", "removed_from_repository":
-				log.Ctx(ctx).Info().Msgf("Received team update event for team %s on repo %s", *event.Repo.Name, *event.Team.Name)
-				_, err = orgbot.UpdateRepoAdminTopics(ctx, p, *event.Repo.Owner.Login, *event.Repo.Name)
-
-			case "edited":
-				// Permissions changing is covered here
-				log.Ctx(ctx).Info().Msgf("Received team update event for team %s", *event.Team.Name)
-				if event.Repo != nil {
-					// If the repo isn't nil, the permissions for that team on that repo have changed
-					_, err = orgbot.UpdateRepoAdminTopics(ctx, p, *event.Repo.Owner.Login, *event.Repo.Name)
-				} else {
-					_, err = orgbot.UpdateTeamAdminTopics(ctx, p, *event.Org.Login, orgbot.GitHubTeamID(*event.Team.ID))
-				}
-			case "created", "deleted":
-			default:
-				err = fmt.Errorf("don't recognise command %s", *event.Action)
-			}
+	teamName := event.GetTeam().GetName()
+	orgName := event.GetOrg().GetLogin()
+
+	var err error
+	switch *event.Action {
+	case "added_to_repository", "removed_from_repository":
+		log.Ctx(ctx).Info().Msgf("Received team update event for team %s on repo %s", teamName, event.Repo.GetName())
+		_, err = orgbot.UpdateRepoAdminTopics(ctx, p, event.Repo.GetOwner().GetLogin(), event.Repo.GetName())
+
+	case "edited":
+		switch {
+		case event.Repo != nil:
+			// If the repo isn't nil, the permissions for that team on that repo have changed
+			log.Ctx(ctx).Info().Msgf("Received team permission event for team %s on repo %s", teamName, event.Repo.GetName())
+			_, err = orgbot.UpdateRepoAdminTopics(ctx, p, event.Repo.GetOwner().GetLogin(), event.Repo.GetName())
+		case event.Changes != nil && event.Changes.Name != nil:
+			// The team was renamed so topics derived from the old name need replacing
+			log.Ctx(ctx).Info().Msgf("Received team rename event for team %s (previously %s)", teamName, aws.StringValue(event.Changes.Name.From))
+			_, err = orgbot.UpdateTeamAdminTopics(ctx, p, orgName, orgbot.GitHubTeamID(event.GetTeam().GetID()))
+		default:
+			// Description and privacy changes don't affect topics
+			log.Ctx(ctx).Debug().Msgf("Ignoring team edit event for team %s", teamName)
 		}
 
-		if err != nil {
-			zerolog.Ctx(ctx).Error().Err(err)
-		}
-		err = queueService.delete(receiptHandle)
-		if err != nil {
-			zerolog.Ctx(ctx).Error().Err(err).Msgf("failed to delete message")
-		}
+	case "deleted":
+		log.Ctx(ctx).Info().Msgf("Received team deletion event for team %s", teamName)
+		_, err = orgbot.UpdateDeletedTeamTopics(ctx, p, orgName, teamName)
+
+	case "created":
+	default:
+		err = fmt.Errorf("don't recognise command %s", *event.Action)
 	}
-}
 
-// sendMessage pushes a message to the queue
-func sendMessage(p orgbot.Platform, m message) error {
-	queueService, err := newQueueService(p.Config())
 	if err != nil {
 		return err
 	}
 
-	if err := queueService.submit(&m); err != nil {
-		return errors.Wrap(err, "failed to submit command")
+	// Changes to repo permissions aren't described by the org repo
+	if event.Repo != nil {
+		return nil
 	}
 
-	return nil
+	// Changes made by orgbot itself are the desired state so don't need reverting, unless the event may have
+	// been coalesced with changes made by others
+	if !isEnforcementTeamEvent(event) {
+		self, err := isSelf(ctx, p, event.GetSender())
+		if err != nil {
+			return err
+		}
+		if self {
+			return nil
+		}
+	}
+
+	change := fmt.Sprintf("team %s %s", teamName, event.GetAction())
+	return enforceOrg(ctx, p, orgName, event.GetSender().GetLogin(), change)
 }
@@ -0,0 +1,254 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+func TestSettleMessageRetriesThenDeadLetters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().MaxAttempts = 2
+	ctx := context.Background()
+
+	q := queue.NewMemoryQueue()
+	dls := queue.NewMemoryDeadLetterStore()
+
+	m := queue.Message{InstallationID: 123, EventType: teamEvent, DeliveryID: "delivery-1", Payload: "{}"}
+	if err := q.Submit(ctx, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	handleErr := errors.New("failed to update topics")
+
+	// The first failure is retried and the second dead-letters the message
+	for attempt := 1; attempt <= 2; attempt++ {
+		got, handle, err := q.Receive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil {
+			t.Fatalf("Expected message to be received on attempt %d", attempt)
+		}
+
+		if _, err := settleMessage(ctx, plat.Config(), q, dls, got, handle, handleErr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, _, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("Expected dead-lettered message to be deleted from the queue")
+	}
+
+	deadLetters, err := dls.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range deadLetters {
+		if d.Time.IsZero() {
+			t.Errorf("Expected dead letter %s to record when it was dead-lettered", d.Message.DeliveryID)
+		}
+		d.Time = time.Time{}
+
+		// The times the message was queued are only known when it's received
+		d.Message.SentAt = time.Time{}
+		d.Message.ReceivedAt = time.Time{}
+	}
+
+	m.Attempts = 2
+	want := []*queue.DeadLetter{{Message: m, Attempts: 2, Error: "failed to update topics"}}
+	if diff := cmp.Diff(want, deadLetters); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := &orgbot.Config{RetryBackoff: 30 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, maxRetryDelay},
+		{100, maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(c, tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestListenForEventsFinishesEventOnShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().MaxAttempts = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Topics are disambiguated against the teams of the org
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return(nil, nil).
+		AnyTimes()
+
+	q := queue.NewMemoryQueue()
+	dls := queue.NewMemoryDeadLetterStore()
+
+	m := queue.Message{
+		InstallationID: 123,
+		EventType:      teamEvent,
+		DeliveryID:     "delivery-1",
+		Payload: `{
+			"action": "added_to_repository",
+			"team": {"id": 1, "name": "Foo"},
+			"repository": {"name": "repo1", "full_name": "SEEK-Jobs/repo1", "fork": false, "owner": {"login": "SEEK-Jobs"}},
+			"organization": {"login": "SEEK-Jobs"}
+		}`,
+	}
+	if err := q.Submit(ctx, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shut down while the event is being handled
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(gomock.Any(), "SEEK-Jobs", "repo1").
+		DoAndReturn(func(context.Context, string, string) (*orgbot.Repo, error) {
+			cancel()
+			return &orgbot.Repo{
+				Name:  "repo1",
+				Teams: []*orgbot.TeamPermission{{TeamName: "Foo", Permission: orgbot.RepoPermissionAdmin}},
+			}, nil
+		})
+
+	// Expect the event to be finished regardless
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateRepoTopics(gomock.Any(), "SEEK-Jobs", "repo1", []string{"admin-foo"}).
+		DoAndReturn(func(ctx context.Context, orgName, repoName string, topics []string) error {
+			return ctx.Err()
+		})
+
+	if err := ListenForEvents(ctx, plat, q, dls, queue.NewMemoryBlobStore()); err != nil {
+		t.Fatal(err)
+	}
+
+	deadLetters, err := dls.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters) != 0 {
+		t.Errorf("Expected event to be handled, but it was dead-lettered: %s", deadLetters[0].Error)
+	}
+}
+
+func TestListenForEventsHandlesMessageVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().MaxAttempts = 2
+	plat.Config().RetryBackoff = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Topics are disambiguated against the teams of the org
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return(nil, nil).
+		AnyTimes()
+
+	q := queue.NewMemoryQueue()
+	dls := queue.NewMemoryDeadLetterStore()
+	blobs := queue.NewMemoryBlobStore()
+
+	payload := `{
+		"action": "added_to_repository",
+		"team": {"id": 1, "name": "Foo"},
+		"repository": {"name": "%s", "full_name": "SEEK-Jobs/%[1]s", "fork": false, "owner": {"login": "SEEK-Jobs"}},
+		"organization": {"login": "SEEK-Jobs"}
+	}`
+	if err := blobs.Put(ctx, "delivery-2.json", []byte(fmt.Sprintf(payload, "repo2"))); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []queue.Message{
+		// Submitted before messages were versioned
+		{InstallationID: 123, EventType: teamEvent, DeliveryID: "delivery-1", GroupKey: "1", Payload: fmt.Sprintf(payload, "repo1")},
+		// Payload offloaded to the blob store
+		{Version: queue.MessageVersion, InstallationID: 123, EventType: teamEvent, DeliveryID: "delivery-2", GroupKey: "2", PayloadRef: "delivery-2.json"},
+		// Submitted by a newer orgbot
+		{Version: queue.MessageVersion + 1, InstallationID: 123, EventType: teamEvent, DeliveryID: "delivery-3", GroupKey: "3"},
+	}
+	for i := range messages {
+		if err := q.Submit(ctx, &messages[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Expect the old and offloaded messages to be handled
+	for _, repoName := range []string{"repo1", "repo2"} {
+		plat.MockGitHubService.
+			EXPECT().
+			RepoByName(gomock.Any(), "SEEK-Jobs", repoName).
+			Return(&orgbot.Repo{Name: repoName}, nil)
+	}
+
+	go func() {
+		// Long enough for the local queue to poll for the retried message
+		time.Sleep(1500 * time.Millisecond)
+		cancel()
+	}()
+
+	if err := ListenForEvents(ctx, plat, q, dls, blobs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expect the newer message to be left for a newer orgbot until it has been attempted the maximum number
+	// of times, then dead-lettered
+	deadLetters, err := dls.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].Message.DeliveryID != "delivery-3" || deadLetters[0].Attempts != 2 {
+		t.Errorf("Expected delivery-3 to be dead-lettered after 2 attempts, got %+v", deadLetters)
+	}
+
+	m, _, err := q.Receive(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Errorf("Expected all events to be handled or dead-lettered, but %s is still queued", m.DeliveryID)
+	}
+
+	// Expect the offloaded payload to be deleted once its message has been handled
+	if _, err := blobs.Get(context.Background(), "delivery-2.json"); err == nil {
+		t.Error("Expected the offloaded payload of delivery-2 to be deleted")
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/yaml"
+)
+
+// repositoryLifecycleEvent extends hub.RepositoryEvent with the changes made by "renamed" actions, which
+// github.com/google/go-github/github/event_types.go predates.
+type repositoryLifecycleEvent struct {
+	hub.RepositoryEvent
+	Changes struct {
+		Repository struct {
+			Name struct {
+				From string `json:"from"`
+			} `json:"name"`
+		} `json:"repository"`
+	} `json:"changes"`
+}
+
+// handleRepositoryEvent grants the default teams their permissions on repos that are created in or
+// transferred to the org, proposes changes to the repos manifest of the org repo when repos are
+// renamed and, if configured, strips write access from the teams of repos when they're archived.
+func handleRepositoryEvent(ctx context.Context, p orgbot.Platform, e interface{}) error {
+	event := e.(*repositoryLifecycleEvent)
+	orgName := event.GetRepo().GetOwner().GetLogin()
+	repoName := event.GetRepo().GetName()
+
+	switch event.GetAction() {
+	case "created", "transferred":
+		log.Ctx(ctx).Info().Msgf("Received repo %s event for repo %s", event.GetAction(), repoName)
+		return applyRepoDefaults(ctx, p, orgName, repoName)
+
+	case "renamed":
+		oldName := event.Changes.Repository.Name.From
+		log.Ctx(ctx).Info().Msgf("Received repo rename event for repo %s (previously %s)", repoName, oldName)
+		return proposeRepoRename(ctx, p, oldName, repoName)
+
+	case "archived":
+		if !p.Config().ArchivedReadOnly {
+			log.Ctx(ctx).Debug().Msgf("Ignoring archival of repo %s as archived repos retain write access", repoName)
+			return nil
+		}
+
+		log.Ctx(ctx).Info().Msgf("Received repo archive event for repo %s", repoName)
+		return stripArchivedRepoWriteAccess(ctx, p, orgName, repoName)
+
+	default:
+		log.Ctx(ctx).Debug().Msgf("Ignoring repo %s event for repo %s", event.GetAction(), repoName)
+		return nil
+	}
+}
+
+// applyRepoDefaults grants the configured default teams their permissions on the specified repo and
+// refreshes its admin topics.
+func applyRepoDefaults(ctx context.Context, p orgbot.Platform, orgName, repoName string) error {
+	if defaults := p.Config().DefaultRepoTeams; len(defaults) > 0 {
+		res, err := orgbot.UpdateRepoTeams(ctx, p, orgName, orgbot.NewRepoDefaultsChangeSet(repoName, defaults))
+		if err != nil {
+			return errors.Wrapf(err, "failed to grant default team permissions on repo %s", repoName)
+		}
+		log.Ctx(ctx).Info().Msgf("Granted %d default team permission(s) on repo %s", res.TeamPermissionsAdded+res.TeamPermissionsUpdated, repoName)
+	}
+
+	_, err := orgbot.UpdateRepoAdminTopics(ctx, p, orgName, repoName)
+	return err
+}
+
+// stripArchivedRepoWriteAccess downgrades the teams with write access to the specified archived repo
+// to read access.
+func stripArchivedRepoWriteAccess(ctx context.Context, p orgbot.Platform, orgName, repoName string) error {
+	r, err := p.GitHubService().RepoByName(ctx, orgName, repoName)
+	if err != nil {
+		return err
+	}
+
+	res, err := orgbot.UpdateRepoTeams(ctx, p, orgName, orgbot.NewArchivedRepoChangeSet(r))
+	if err != nil {
+		return errors.Wrapf(err, "failed to strip write access from archived repo %s", repoName)
+	}
+
+	log.Ctx(ctx).Info().Msgf("Downgraded %d team(s) to read permission on archived repo %s", res.TeamPermissionsUpdated, repoName)
+	return nil
+}
+
+// proposeRepoRename opens a pull request against the org repo that renames the specified repo in the
+// repos manifest, if the manifest references it.
+func proposeRepoRename(ctx context.Context, p orgbot.Platform, oldName, newName string) error {
+	c := p.Config()
+	owner, name, ok := orgRepo(c)
+	if !ok {
+		log.Ctx(ctx).Info().Msgf("Ignoring rename of repo %s as no org repo has been configured", oldName)
+		return nil
+	}
+
+	dir, err := checkoutOrgRepo(ctx, p, c.OrgRepoBranch)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// The repo is renamed in place so that the rest of the manifest, including comments, is left unchanged
+	buf, err := ioutil.ReadFile(filepath.Join(dir, orgbot.ReposFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	buf, renamed, err := yaml.RenameItems(buf, "repos", "name", oldName, newName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename repo %s in %s", oldName, orgbot.ReposFile)
+	}
+	if !renamed {
+		log.Ctx(ctx).Debug().Msgf("Repos manifest doesn't reference renamed repo %s", oldName)
+		return nil
+	}
+
+	number, err := p.GitHubService().ProposeFileChanges(ctx, owner, name, &orgbot.FileChangeProposal{
+		Base:   c.OrgRepoBranch,
+		Branch: fmt.Sprintf("orgbot/rename-%s-to-%s", strings.ToLower(oldName), strings.ToLower(newName)),
+		Title:  fmt.Sprintf("Rename repo %s to %s", oldName, newName),
+		Body:   fmt.Sprintf("Repo `%s` was renamed to `%s` so the repos manifest needs to follow suit.", oldName, newName),
+		Files:  map[string][]byte{orgRepoPath(c, orgbot.ReposFile): buf},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to propose rename of repo %s", oldName)
+	}
+
+	log.Ctx(ctx).Info().Msgf("Opened pull request #%d to rename repo %s to %s", number, oldName, newName)
+	return nil
+}
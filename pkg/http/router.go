@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sort"
+
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/cmd"
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+// panicsMetric is the name of the counter of messages whose handlers panicked
+const panicsMetric = "events.panics"
+
+// events routes the messages of the event types that orgbot handles to their handlers.
+var events = newEventRouter().
+	register(issueCommentEvent, typed(func() interface{} { return new(hub.IssueCommentEvent) }, handleIssueCommentEvent)).
+	register(membershipEvent, typed(func() interface{} { return new(hub.MembershipEvent) }, handleMembershipEvent)).
+	register(organizationEvent, typed(func() interface{} { return new(hub.OrganizationEvent) }, handleOrganizationEvent)).
+	register(pullRequestEvent, typed(func() interface{} { return new(hub.PullRequestEvent) }, handlePullRequestEvent)).
+	register(pullRequestReviewEvent, typed(func() interface{} { return new(hub.PullRequestReviewEvent) }, handlePullRequestReviewEvent)).
+	register(pushEvent, typed(func() interface{} { return new(hub.PushEvent) }, handlePushEvent)).
+	register(repositoryEvent, typed(func() interface{} { return new(repositoryLifecycleEvent) }, handleRepositoryEvent)).
+	register(teamEvent, typed(func() interface{} { return new(hub.TeamEvent) }, handleTeamEvent))
+
+// unretryableError is returned when handling a message fails in a way that retrying can't fix, such as
+// the payload being malformed or the handler panicking.
+type unretryableError struct {
+	error
+}
+
+// Cause implements the causer interface of github.com/pkg/errors.
+func (e *unretryableError) Cause() error {
+	return e.error
+}
+
+// isRetryable returns whether handling a message that failed with the specified error may succeed if retried.
+// Invalid org configuration stays invalid however many times it's retried.
+func isRetryable(err error) bool {
+	if isInvalidOrgError(err) {
+		return false
+	}
+
+	for err != nil {
+		if _, ok := err.(*unretryableError); ok {
+			return false
+		}
+
+		c, ok := err.(interface{ Cause() error })
+		if !ok {
+			return true
+		}
+		err = c.Cause()
+	}
+	return true
+}
+
+// payloadHandler handles the specified event payload of a message.
+type payloadHandler func(ctx context.Context, p orgbot.Platform, payload []byte) error
+
+// typed returns a payloadHandler that decodes payloads into the event returned by the specified function,
+// failing unretryably if the payload is malformed, and calls the specified handler with it. Handlers
+// assert the type of the event, which is the type returned by the function.
+func typed(newEvent func() interface{}, handler func(ctx context.Context, p orgbot.Platform, event interface{}) error) payloadHandler {
+	return func(ctx context.Context, p orgbot.Platform, payload []byte) error {
+		event := newEvent()
+		if err := json.Unmarshal(payload, event); err != nil {
+			return &unretryableError{errors.Wrap(err, "failed to unmarshal message")}
+		}
+		return handler(ctx, p, event)
+	}
+}
+
+// eventRouter dispatches messages to the handlers registered for their event types.
+type eventRouter struct {
+	handlers map[string]payloadHandler
+}
+
+// newEventRouter returns an eventRouter without any handlers.
+func newEventRouter() *eventRouter {
+	return &eventRouter{handlers: map[string]payloadHandler{}}
+}
+
+// register registers the specified handler for messages of the specified event type and returns the
+// router.
+func (r *eventRouter) register(eventType string, handler payloadHandler) *eventRouter {
+	r.handlers[eventType] = handler
+	return r
+}
+
+// eventTypes returns the event types that handlers are registered for.
+func (r *eventRouter) eventTypes() []string {
+	var eventTypes []string
+	for t := range r.handlers {
+		eventTypes = append(eventTypes, t)
+	}
+	sort.Strings(eventTypes)
+	return eventTypes
+}
+
+// route calls the handler registered for the event type of the specified message with its payload. A
+// panicking handler is recovered from so that it only fails the message being handled.
+func (r *eventRouter) route(ctx context.Context, p orgbot.Platform, m *queue.Message) (err error) {
+	handler, ok := r.handlers[m.EventType]
+	if !ok {
+		return &unretryableError{fmt.Errorf("don't recognise event type %s", m.EventType)}
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			metrics.GetOrRegisterCounter(panicsMetric, cmd.MetricsRegistry()).Inc(1)
+			err = &unretryableError{fmt.Errorf("%s event handler panicked: %v\n%s", m.EventType, v, debug.Stack())}
+		}
+	}()
+
+	return handler(ctx, p, []byte(m.Payload))
+}
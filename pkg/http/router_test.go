@@ -0,0 +1,182 @@
+package http
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	hub "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/orgbot"
+	"github.com/SEEK-Jobs/orgbot/pkg/queue"
+)
+
+func TestEventRouterRoutesByEventType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	var got string
+	r := newEventRouter().
+		register(teamEvent, typed(func() interface{} { return new(hub.TeamEvent) }, func(ctx context.Context, p orgbot.Platform, e interface{}) error {
+			got = "team " + e.(*hub.TeamEvent).GetTeam().GetName()
+			return nil
+		})).
+		register(pushEvent, typed(func() interface{} { return new(hub.PushEvent) }, func(ctx context.Context, p orgbot.Platform, e interface{}) error {
+			got = "push " + e.(*hub.PushEvent).GetRef()
+			return nil
+		}))
+
+	if err := r.route(ctx, plat, &queue.Message{EventType: pushEvent, Payload: `{"ref": "refs/heads/master"}`}); err != nil {
+		t.Fatal(err)
+	}
+	if got != "push refs/heads/master" {
+		t.Errorf("Expected push handler to be called, got %q", got)
+	}
+
+	if types := strings.Join(r.eventTypes(), ","); types != "push,team" {
+		t.Errorf("Expected push and team event types, got %s", types)
+	}
+}
+
+func TestEventRouterUnretryableErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	r := newEventRouter().
+		register(teamEvent, typed(func() interface{} { return new(hub.TeamEvent) }, func(ctx context.Context, p orgbot.Platform, e interface{}) error {
+			var repo *hub.Repository
+			_ = *repo.Name // Panics
+			return nil
+		}))
+
+	tests := []struct {
+		name    string
+		message *queue.Message
+		wantErr string
+	}{
+		{
+			name:    "panic",
+			message: &queue.Message{EventType: teamEvent, Payload: `{}`},
+			wantErr: "team event handler panicked",
+		},
+		{
+			name:    "malformed payload",
+			message: &queue.Message{EventType: teamEvent, Payload: `{`},
+			wantErr: "failed to unmarshal message",
+		},
+		{
+			name:    "unknown event type",
+			message: &queue.Message{EventType: "deployment", Payload: `{}`},
+			wantErr: "don't recognise event type deployment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.route(ctx, plat, tt.message)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if isRetryable(err) {
+				t.Errorf("Expected error to be unretryable: %v", err)
+			}
+		})
+	}
+}
+
+func TestSettleMessageDeadLettersPanicsImmediately(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := orgbot.NewTestPlatform(ctrl)
+	plat.Config().MaxAttempts = 5
+	ctx := context.Background()
+
+	q := queue.NewMemoryQueue()
+	dls := queue.NewMemoryDeadLetterStore()
+
+	if err := q.Submit(ctx, &queue.Message{EventType: teamEvent, DeliveryID: "delivery-1", Payload: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, handle, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newEventRouter().
+		register(teamEvent, typed(func() interface{} { return new(hub.TeamEvent) }, func(ctx context.Context, p orgbot.Platform, e interface{}) error {
+			panic("boom")
+		}))
+
+	if _, err := settleMessage(ctx, plat.Config(), q, dls, m, handle, r.route(ctx, plat, m)); err != nil {
+		t.Fatal(err)
+	}
+
+	deadLetters, err := dls.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].Attempts != 1 || !strings.Contains(deadLetters[0].Error, "boom") {
+		t.Errorf("Expected the message to be dead-lettered on its first attempt with the panic, got %+v", deadLetters)
+	}
+}
+
+func TestSettleMessageDeadLettersInvalidOrgImmediately(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "YAML error",
+			err:  errors.Wrap(&orgbot.DecodeError{Path: "team-a/team.yaml", Err: errors.New("bad indentation")}, "failed to apply"),
+		},
+		{
+			name: "rule violation",
+			err:  errors.Wrap(&orgbot.CompositeRuleError{}, "failed to apply"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			plat := orgbot.NewTestPlatform(ctrl)
+			plat.Config().MaxAttempts = 5
+			ctx := context.Background()
+
+			q := queue.NewMemoryQueue()
+			dls := queue.NewMemoryDeadLetterStore()
+
+			if err := q.Submit(ctx, &queue.Message{EventType: pushEvent, DeliveryID: "delivery-1", Payload: "{}"}); err != nil {
+				t.Fatal(err)
+			}
+
+			m, handle, err := q.Receive(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := settleMessage(ctx, plat.Config(), q, dls, m, handle, tt.err); err != nil {
+				t.Fatal(err)
+			}
+
+			deadLetters, err := dls.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deadLetters) != 1 || deadLetters[0].Attempts != 1 {
+				t.Errorf("Expected the message to be dead-lettered on its first attempt, got %+v", deadLetters)
+			}
+		})
+	}
+}
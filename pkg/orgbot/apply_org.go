@@ -15,22 +15,40 @@ type ApplyOrgResult struct {
 	TeamsDeleted       int `json:"teamsDeleted" yaml:"teamsDeleted"`
 	MembershipsAdded   int `json:"membershipsAdded" yaml:"membershipsAdded"`
 	MembershipsDeleted int `json:"membershipsDeleted" yaml:"membershipsDeleted"`
+	ReposTopicsUpdated int `json:"reposTopicsUpdated" yaml:"reposTopicsUpdated"`
+
+	// Teams describes the changes made to each team that was changed.
+	Teams []*TeamReport `json:"teams,omitempty" yaml:"teams,omitempty"`
+}
+
+// ApplyOrgOptions describes the optional behaviour of the ApplyOrg function.
+type ApplyOrgOptions struct {
+	// RefreshTopics updates the topics of the repos of renamed teams once the org has been
+	// applied so that topics derived from their old names don't linger.
+	RefreshTopics bool
 }
 
 // HasChanges returns whether the apply operation resulted in any changes.
 func (r *ApplyOrgResult) HasChanges() bool {
-	return *r != ApplyOrgResult{}
+	return r.TeamsCreated > 0 || r.TeamsUpdated > 0 || r.TeamsDeleted > 0 ||
+		r.MembershipsAdded > 0 || r.MembershipsDeleted > 0 || r.ReposTopicsUpdated > 0
 }
 
-// keptGitHubTeam extends GitHubTeam to also specify whether the team was freshly created.
+// keptGitHubTeam extends GitHubTeam to also specify whether the team was freshly created or renamed.
 type keptGitHubTeam struct {
 	GitHubTeam
-	created bool
+	created      bool
+	previousName string // Name of the team before it was renamed (empty if it wasn't)
 }
 
 // ApplyOrg applies the specified organisational structure against the GitHub organisation
-// making the necessary changes to teams and memberships as required.
-func ApplyOrg(ctx context.Context, plat Platform, org *Org) (*ApplyOrgResult, error) {
+// making the necessary changes to teams and memberships as required. A nil opts applies the
+// default options.
+func ApplyOrg(ctx context.Context, plat Platform, org *Org, opts *ApplyOrgOptions) (*ApplyOrgResult, error) {
+	if opts == nil {
+		opts = &ApplyOrgOptions{}
+	}
+
 	// First, verify that no rules have been broken
 	if err := plat.RuleEngine().Run(ctx, org); err != nil {
 		return nil, err
@@ -46,7 +64,8 @@ func ApplyOrg(ctx context.Context, plat Platform, org *Org) (*ApplyOrgResult, er
 	}
 
 	// Configure the GitHub teams based on the org specification
-	keptTeams, err := configureTeams(ctx, statsGitHubService, org, haveTeams)
+	reports := newTeamReports()
+	keptTeams, err := configureTeams(ctx, statsGitHubService, org, haveTeams, reports)
 	if err != nil {
 		return nil, err
 	}
@@ -57,10 +76,27 @@ func ApplyOrg(ctx context.Context, plat Platform, org *Org) (*ApplyOrgResult, er
 	}
 
 	// Configure the memberships for the teams based on the org specification
-	if err = configureTeamMemberships(ctx, statsGitHubService, org, keptTeams); err != nil {
+	if err = configureTeamMemberships(ctx, statsGitHubService, org, keptTeams, reports); err != nil {
 		return nil, err
 	}
 
+	// Refresh the topics of the repos of renamed teams
+	reposTopicsUpdated := 0
+	if opts.RefreshTopics {
+		for _, kept := range keptTeams {
+			if kept.previousName == "" {
+				continue
+			}
+
+			zerolog.Ctx(ctx).Info().Msgf("Refreshing topics of repositories of renamed GitHub team '%s'", kept.Name)
+			res, err := UpdateTeamAdminTopics(ctx, plat, org.Name, kept.ID)
+			if err != nil {
+				return nil, err
+			}
+			reposTopicsUpdated += res.ReposUpdated
+		}
+	}
+
 	stats := statsGitHubService.Stats()
 	zerolog.Ctx(ctx).Info().Msgf("Created %d teams, updated %d teams, deleted %d teams, added %d memberships, and deleted %d memberships",
 		stats.TeamsCreated, stats.TeamsUpdated, stats.TeamsDeleted, stats.TeamMembershipsAdded, stats.TeamMembershipsDeleted)
@@ -71,6 +107,8 @@ func ApplyOrg(ctx context.Context, plat Platform, org *Org) (*ApplyOrgResult, er
 		TeamsDeleted:       stats.TeamsDeleted,
 		MembershipsAdded:   stats.TeamMembershipsAdded,
 		MembershipsDeleted: stats.TeamMembershipsDeleted,
+		ReposTopicsUpdated: reposTopicsUpdated,
+		Teams:              reports.changed(),
 	}, nil
 }
 
@@ -123,9 +161,10 @@ func promoteAdmins(ctx context.Context, gitHubService GitHubService, org *Org) e
 	return nil
 }
 
-// configureTeams configures the teams within the GitHub organisation to match the desired state. This function
-// returns the slice of all teams in the organisation after all create/update/deletes have occurred.
-func configureTeams(ctx context.Context, gitHubService GitHubService, org *Org, haveTeams []*GitHubTeam) ([]*keptGitHubTeam, error) {
+// configureTeams configures the teams within the GitHub organisation to match the desired state, reporting
+// the changes made to each team. This function returns the slice of all teams in the organisation after all
+// create/update/deletes have occurred.
+func configureTeams(ctx context.Context, gitHubService GitHubService, org *Org, haveTeams []*GitHubTeam, reports *teamReports) ([]*keptGitHubTeam, error) {
 	// Collect all teams we're going to keep
 	var keptTeams []*keptGitHubTeam
 
@@ -135,12 +174,16 @@ func configureTeams(ctx context.Context, gitHubService GitHubService, org *Org,
 	process = func(parent *GitHubTeam, wantTeams []*Team) error {
 		for _, want := range wantTeams {
 			var t *GitHubTeam
-			var created bool
+			var created, updated bool
+			var previousName string
 			var err error
 
 			// Update the team if it exists, create it if it doesn't
 			if have := findGitHubTeamFromDesired(haveTeams, want); have != nil {
-				t, err = updateTeam(ctx, gitHubService, parent, have, want)
+				if have.Name != want.Name {
+					previousName = have.Name
+				}
+				t, updated, err = updateTeam(ctx, gitHubService, parent, have, want)
 			} else {
 				created = true
 				t, err = createTeam(ctx, gitHubService, org.Name, parent, want)
@@ -150,7 +193,12 @@ func configureTeams(ctx context.Context, gitHubService GitHubService, org *Org,
 				return err
 			}
 
-			keptTeams = append(keptTeams, &keptGitHubTeam{GitHubTeam: *t, created: created})
+			report := reports.team(want.Name)
+			report.Created = created
+			report.Updated = updated
+			report.RenamedFrom = previousName
+
+			keptTeams = append(keptTeams, &keptGitHubTeam{GitHubTeam: *t, created: created, previousName: previousName})
 
 			if err = process(t, want.Children); err != nil {
 				return err
@@ -179,14 +227,16 @@ func configureTeams(ctx context.Context, gitHubService GitHubService, org *Org,
 			if err := gitHubService.DeleteTeam(ctx, t.ID); err != nil {
 				return nil, err
 			}
+			reports.team(t.Name).Deleted = true
 		}
 	}
 
 	return keptTeams, nil
 }
 
-// configureTeamMemberships configures team memberships within the organisation to match the desired state.
-func configureTeamMemberships(ctx context.Context, gitHubService GitHubService, org *Org, haveTeams []*keptGitHubTeam) error {
+// configureTeamMemberships configures team memberships within the organisation to match the desired state,
+// reporting the users added to and removed from each team.
+func configureTeamMemberships(ctx context.Context, gitHubService GitHubService, org *Org, haveTeams []*keptGitHubTeam, reports *teamReports) error {
 	// Transform the wanted teams into a map for easy access
 	wantTeamsByName := teamsByName(org.Teams)
 
@@ -211,15 +261,17 @@ func configureTeamMemberships(ctx context.Context, gitHubService GitHubService,
 			}
 		}
 
+		report := reports.team(have.Name)
+
 		// Add/remove team maintainers if they have changed
 		haveMaintainerEmails := gitHubUserEmails(haveMaintainers)
-		if err := updateTeamMemberships(ctx, gitHubService, org.Name, &have.GitHubTeam, haveMaintainerEmails, want.Maintainers, RoleMaintainer); err != nil {
+		if err := updateTeamMemberships(ctx, gitHubService, org.Name, &have.GitHubTeam, haveMaintainerEmails, want.Maintainers, RoleMaintainer, report); err != nil {
 			return err
 		}
 
 		// Add/remove team members if they have changed
 		haveMemberEmails := gitHubUserEmails(haveMembers)
-		if err := updateTeamMemberships(ctx, gitHubService, org.Name, &have.GitHubTeam, haveMemberEmails, want.Members, RoleMember); err != nil {
+		if err := updateTeamMemberships(ctx, gitHubService, org.Name, &have.GitHubTeam, haveMemberEmails, want.Members, RoleMember, report); err != nil {
 			return err
 		}
 	}
@@ -228,7 +280,8 @@ func configureTeamMemberships(ctx context.Context, gitHubService GitHubService,
 }
 
 // updateTeam updates the existing GitHub team to match the expected state and have the specified parent.
-func updateTeam(ctx context.Context, gitHubService GitHubService, parent *GitHubTeam, have *GitHubTeam, want *Team) (*GitHubTeam, error) {
+// The second return parameter reports whether the team was modified.
+func updateTeam(ctx context.Context, gitHubService GitHubService, parent *GitHubTeam, have *GitHubTeam, want *Team) (*GitHubTeam, bool, error) {
 	// Was the team modified?
 	modified := false
 
@@ -268,12 +321,12 @@ func updateTeam(ctx context.Context, gitHubService GitHubService, parent *GitHub
 		t.ParentID = wantParentID
 		tt, err := gitHubService.UpdateTeam(ctx, &t)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		updated = tt
 	}
 
-	return updated, nil
+	return updated, modified, nil
 }
 
 // createTeam creates the specified desired team with the specified parent.
@@ -304,8 +357,9 @@ func createTeam(ctx context.Context, gitHubService GitHubService, orgName string
 	return created, nil
 }
 
-// updateTeamMemberships updates the specified GitHub team to have the specified users with the specified role.
-func updateTeamMemberships(ctx context.Context, gitHubService GitHubService, orgName string, t *GitHubTeam, haveEmails []string, wantEmails []string, role GitHubTeamRole) error {
+// updateTeamMemberships updates the specified GitHub team to have the specified users with the specified role,
+// recording the users added and removed in the specified report.
+func updateTeamMemberships(ctx context.Context, gitHubService GitHubService, orgName string, t *GitHubTeam, haveEmails []string, wantEmails []string, role GitHubTeamRole, report *TeamReport) error {
 	haveEmailSet := newStringSet(haveEmails)
 	wantEmailSet := newStringSet(wantEmails)
 
@@ -317,6 +371,7 @@ func updateTeamMemberships(ctx context.Context, gitHubService GitHubService, org
 		if err := gitHubService.AddTeamMembership(ctx, orgName, t.ID, u, role); err != nil {
 			return err
 		}
+		report.Added = append(report.Added, u)
 	}
 
 	// Delete removed members from the team
@@ -327,6 +382,7 @@ func updateTeamMemberships(ctx context.Context, gitHubService GitHubService, org
 		if err := gitHubService.DeleteTeamMembership(ctx, orgName, t.ID, u, role); err != nil {
 			return err
 		}
+		report.Removed = append(report.Removed, u)
 	}
 
 	return nil
@@ -62,7 +62,7 @@ func TestApplyOrgRuleViolations(t *testing.T) {
 		Run(ctx, testOrg).
 		Return(wantErr)
 
-	_, err := ApplyOrg(context.Background(), plat, testOrg)
+	_, err := ApplyOrg(context.Background(), plat, testOrg, nil)
 
 	// Verify that the same error is returned by ApplyOrg
 	if diff := cmp.Diff(wantErr, err); diff != "" {
@@ -93,7 +93,7 @@ func TestApplyOrgCreateFromScratch(t *testing.T) {
 	expectCreateTeams(ctx, plat.MockGitHubService, testOrg.Name, parentGitHubTeam, child1GitHubTeam, child2GitHubTeam)
 
 	// Run the SUT
-	res, err := ApplyOrg(context.Background(), plat, testOrg)
+	res, err := ApplyOrg(context.Background(), plat, testOrg, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -102,6 +102,11 @@ func TestApplyOrgCreateFromScratch(t *testing.T) {
 	wantRes := &ApplyOrgResult{
 		TeamsCreated:     3,
 		MembershipsAdded: 8,
+		Teams: []*TeamReport{
+			{Team: "parent", Created: true, Added: []string{"alice@seek.com.au", "bob@seek.com.au", "david@seek.com.au"}},
+			{Team: "child1", Created: true, Added: []string{"bob@seek.com.au", "gavin@seekasia.com", "lester@seekasia.com"}},
+			{Team: "child2", Created: true, Added: []string{"alice@seek.com.au", "david@seek.com.au"}},
+		},
 	}
 
 	if diff := cmp.Diff(wantRes, res); diff != "" {
@@ -132,7 +137,7 @@ func TestApplyOrgNoOp(t *testing.T) {
 	expectListTeamMembers(ctx, plat.MockGitHubService, testOrg.Name, parentGitHubTeam, child1GitHubTeam, child2GitHubTeam)
 
 	// Run the SUT
-	res, err := ApplyOrg(context.Background(), plat, testOrg)
+	res, err := ApplyOrg(context.Background(), plat, testOrg, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -171,7 +176,7 @@ func TestApplyOrgAddTeams(t *testing.T) {
 	expectCreateTeams(ctx, plat.MockGitHubService, testOrg.Name, child2GitHubTeam)
 
 	// Run the SUT
-	res, err := ApplyOrg(context.Background(), plat, testOrg)
+	res, err := ApplyOrg(context.Background(), plat, testOrg, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -180,6 +185,9 @@ func TestApplyOrgAddTeams(t *testing.T) {
 	wantRes := &ApplyOrgResult{
 		TeamsCreated:     1,
 		MembershipsAdded: 2,
+		Teams: []*TeamReport{
+			{Team: "child2", Created: true, Added: []string{"alice@seek.com.au", "david@seek.com.au"}},
+		},
 	}
 
 	if diff := cmp.Diff(wantRes, res); diff != "" {
@@ -223,7 +231,7 @@ func TestApplyOrgDeleteTeams(t *testing.T) {
 		Return(nil)
 
 	// Run the SUT
-	res, err := ApplyOrg(context.Background(), plat, testOrg)
+	res, err := ApplyOrg(context.Background(), plat, testOrg, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -231,6 +239,7 @@ func TestApplyOrgDeleteTeams(t *testing.T) {
 	// Verify results
 	wantRes := &ApplyOrgResult{
 		TeamsDeleted: 1,
+		Teams:        []*TeamReport{{Team: "child3", Deleted: true}},
 	}
 
 	if diff := cmp.Diff(wantRes, res); diff != "" {
@@ -333,7 +342,7 @@ func TestApplyOrgUpdateTeams(t *testing.T) {
 		Return(nil)
 
 	// Run the SUT
-	res, err := ApplyOrg(context.Background(), plat, updatedOrg)
+	res, err := ApplyOrg(context.Background(), plat, updatedOrg, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -343,6 +352,81 @@ func TestApplyOrgUpdateTeams(t *testing.T) {
 		TeamsUpdated:       3,
 		MembershipsAdded:   3,
 		MembershipsDeleted: 1,
+		Teams: []*TeamReport{
+			{Team: "parent-no-longer", Updated: true, RenamedFrom: "parent", Added: []string{"danny@seek.com.au"}},
+			{Team: "new-parent", Updated: true, RenamedFrom: "child1", Added: []string{"alice@seek.com.au"}},
+			{Team: "only-child", Updated: true, RenamedFrom: "child2", Added: []string{"bob@seek.com.au"}, Removed: []string{"david@seek.com.au"}},
+		},
+	}
+
+	if diff := cmp.Diff(wantRes, res); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestApplyOrgRefreshTopics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatformPassingRules(ctrl)
+	ctx := context.Background()
+
+	org := &Org{
+		Name: "SEEK-Jobs",
+		Teams: []*Team{
+			{Name: "platform", Previously: []string{"infra"}, Description: "Platform"},
+		},
+	}
+
+	// The team currently has its previous name
+	haveTeam := &gitHubTeamWithMembers{team: &GitHubTeam{ID: 200, Name: "infra", Description: "Platform"}}
+	renamedTeam := asGitHubTeam(org.Teams[0], 200, 0)
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(ctx, org.Name).
+		Return([]*GitHubTeam{haveTeam.team}, nil)
+	expectListAdmins(ctx, plat.MockGitHubService, org.Name)
+	expectListTeamMembers(ctx, plat.MockGitHubService, org.Name, haveTeam)
+
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateTeam(ctx, renamedTeam).
+		Return(renamedTeam, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(ctx, org.Name).
+		Return([]*GitHubTeam{renamedTeam}, nil)
+
+	// Expect the repos of the renamed team to have their topics refreshed
+	plat.MockGitHubService.
+		EXPECT().
+		WalkReposByTeam(ctx, org.Name, GitHubTeamID(200), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName string, teamID GitHubTeamID, walkFn WalkReposFunc) error {
+			return walkFn(&Repo{
+				Name:   "repo1",
+				Topics: []string{"admin-infra", "go"},
+				Teams: []*TeamPermission{
+					{TeamName: "platform", Permission: RepoPermissionAdmin},
+				},
+			})
+		})
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateRepoTopics(ctx, org.Name, "repo1", []string{"admin-platform", "go"}).
+		Return(nil)
+
+	// Run the SUT
+	res, err := ApplyOrg(ctx, plat, org, &ApplyOrgOptions{RefreshTopics: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify results
+	wantRes := &ApplyOrgResult{
+		TeamsUpdated:       1,
+		ReposTopicsUpdated: 1,
+		Teams:              []*TeamReport{{Team: "platform", Updated: true, RenamedFrom: "infra"}},
 	}
 
 	if diff := cmp.Diff(wantRes, res); diff != "" {
@@ -0,0 +1,172 @@
+package orgbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// ApplyReposResult describes the complete set of operations taken by the ApplyRepos function.
+type ApplyReposResult struct {
+	TeamPermissionsAdded   int `json:"teamPermissionsAdded" yaml:"teamPermissionsAdded"` // Includes updated permissions
+	TeamPermissionsRemoved int `json:"teamPermissionsRemoved" yaml:"teamPermissionsRemoved"`
+	ReposTopicsUpdated     int `json:"reposTopicsUpdated" yaml:"reposTopicsUpdated"`
+}
+
+// HasChanges returns whether the apply operation resulted in any changes.
+func (r *ApplyReposResult) HasChanges() bool {
+	return *r != ApplyReposResult{}
+}
+
+// ApplyRepos applies the specified repo manifest against the GitHub organisation making the
+// necessary changes to the team permissions and topics of each listed repo. Teams that are not
+// listed for a repo are removed from it and topics are derived from the listed teams according to
+// the configured topic schemes.
+func ApplyRepos(ctx context.Context, plat Platform, manifest *RepoManifest) (*ApplyReposResult, error) {
+	// First, verify that no rules have been broken
+	if err := plat.RuleEngine().RunRepos(ctx, manifest); err != nil {
+		return nil, err
+	}
+
+	// Make the GitHubService gather stats so that we print/return results
+	statsGitHubService := NewStatsGitHubService(plat.GitHubService())
+
+	// What teams currently exist?
+	teams, err := statsGitHubService.ListTeams(ctx, manifest.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	haveTeamsByName := map[string]*GitHubTeam{}
+	for _, t := range teams {
+		haveTeamsByName[t.Name] = t
+	}
+
+	rectifier := newTopicRectifierForTeams(topicSchemes(plat), teams)
+
+	for _, spec := range manifest.Repos {
+		have, err := statsGitHubService.RepoByName(ctx, manifest.Name, spec.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Archived repos are read-only
+		if have.Archived {
+			zerolog.Ctx(ctx).Warn().Msgf("Skipping archived repository '%s'", spec.Name)
+			continue
+		}
+
+		wantTeams, err := repoSpecTeamPermissions(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := configureRepoTeams(ctx, statsGitHubService, manifest.Name, have, wantTeams, haveTeamsByName); err != nil {
+			return nil, err
+		}
+
+		if err := configureRepoTopics(ctx, statsGitHubService, rectifier, manifest.Name, have, spec.Topics, wantTeams); err != nil {
+			return nil, err
+		}
+	}
+
+	stats := statsGitHubService.Stats()
+	zerolog.Ctx(ctx).Info().Msgf("Added or updated %d team permissions, removed %d team permissions, and updated topics of %d repos",
+		stats.TeamRepoPermissionsAdded, stats.TeamRepoPermissionsDeleted, stats.RepoTopicsUpdated)
+
+	return &ApplyReposResult{
+		TeamPermissionsAdded:   stats.TeamRepoPermissionsAdded,
+		TeamPermissionsRemoved: stats.TeamRepoPermissionsDeleted,
+		ReposTopicsUpdated:     stats.RepoTopicsUpdated,
+	}, nil
+}
+
+// repoSpecTeamPermissions returns the TeamPermissions described by the specified RepoSpec with
+// each permission converted to its canonical form.
+func repoSpecTeamPermissions(spec *RepoSpec) ([]*TeamPermission, error) {
+	var teamPermissions []*TeamPermission
+	for _, t := range spec.Teams {
+		p, err := ParseRepoPermission(string(t.Permission))
+		if err != nil {
+			return nil, err
+		}
+		teamPermissions = append(teamPermissions, &TeamPermission{TeamName: t.Name, Permission: p})
+	}
+
+	return teamPermissions, nil
+}
+
+// configureRepoTeams configures the team permissions of the specified repo to match the desired state.
+func configureRepoTeams(ctx context.Context, gitHubService GitHubService, orgName string, have *Repo, wantTeams []*TeamPermission, haveTeamsByName map[string]*GitHubTeam) error {
+	havePermissions := map[string]RepoPermission{}
+	for _, tp := range have.Teams {
+		havePermissions[tp.TeamName] = tp.Permission
+	}
+
+	wantPermissions := map[string]RepoPermission{}
+	for _, tp := range wantTeams {
+		wantPermissions[tp.TeamName] = tp.Permission
+	}
+
+	// Add teams that are missing or that have the wrong permission
+	for _, tp := range wantTeams {
+		havePermission, ok := havePermissions[tp.TeamName]
+		if ok && havePermission == tp.Permission {
+			continue
+		}
+
+		t, ok := haveTeamsByName[tp.TeamName]
+		if !ok {
+			return fmt.Errorf("team '%s' does not exist", tp.TeamName)
+		}
+
+		if havePermission != "" {
+			zerolog.Ctx(ctx).Info().Msgf("Updating team '%s' on repository '%s' from permission '%s' to '%s'", tp.TeamName, have.Name, havePermission, tp.Permission)
+		} else {
+			zerolog.Ctx(ctx).Info().Msgf("Adding team '%s' to repository '%s' with permission '%s'", tp.TeamName, have.Name, tp.Permission)
+		}
+
+		if err := gitHubService.AddTeamRepoPermission(ctx, orgName, have.Name, t.ID, tp.Permission); err != nil {
+			return err
+		}
+	}
+
+	// Remove teams that are not wanted
+	for _, tp := range have.Teams {
+		if _, ok := wantPermissions[tp.TeamName]; ok {
+			continue
+		}
+
+		t, ok := haveTeamsByName[tp.TeamName]
+		if !ok {
+			// Secret teams aren't listed so they can't be managed
+			zerolog.Ctx(ctx).Warn().Msgf("Ignoring unknown team '%s' on repository '%s'", tp.TeamName, have.Name)
+			continue
+		}
+
+		zerolog.Ctx(ctx).Info().Msgf("Removing team '%s' from repository '%s' which had permission '%s'", tp.TeamName, have.Name, tp.Permission)
+		if err := gitHubService.DeleteTeamRepoPermission(ctx, orgName, have.Name, t.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// configureRepoTopics configures the topics of the specified repo to be the specified topics plus
+// the topics derived from the specified desired teams.
+func configureRepoTopics(ctx context.Context, gitHubService GitHubService, rectifier *topicRectifier, orgName string, have *Repo, topics []string, wantTeams []*TeamPermission) error {
+	want := Repo{Name: have.Name, Topics: topics, Teams: wantTeams}
+	rectifier.rectify(&want)
+	sort.Strings(want.Topics)
+
+	if newStringSet(have.Topics).Equal(newStringSet(want.Topics)) {
+		return nil
+	}
+
+	zerolog.Ctx(ctx).Info().Msgf("Updating repo %s/%s to have topics %s", orgName, have.Name, strings.Join(want.Topics, ", "))
+	return gitHubService.UpdateRepoTopics(ctx, orgName, have.Name, want.Topics)
+}
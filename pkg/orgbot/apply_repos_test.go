@@ -0,0 +1,124 @@
+package orgbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyRepos(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	manifest := &RepoManifest{
+		Name: "SEEK-Jobs",
+		Repos: []*RepoSpec{
+			{
+				Name:   "repo1",
+				Topics: []string{"go"},
+				Teams: []*RepoTeam{
+					{Name: "Foo", Permission: RepoPermissionAdmin},
+					{Name: "Bar", Permission: "write"},
+					{Name: "Qux", Permission: RepoPermissionRead},
+				},
+			},
+			{
+				Name: "repo2",
+				Teams: []*RepoTeam{
+					{Name: "Foo", Permission: RepoPermissionAdmin},
+				},
+			},
+			{
+				Name: "repo3",
+				Teams: []*RepoTeam{
+					{Name: "Foo", Permission: RepoPermissionAdmin},
+				},
+			},
+		},
+	}
+
+	plat.MockRuleEngine.
+		EXPECT().
+		RunRepos(ctx, manifest).
+		Return(nil)
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(ctx, "SEEK-Jobs").
+		Return([]*GitHubTeam{
+			{ID: 100, ParentID: 0, Name: "Foo"},
+			{ID: 101, ParentID: 100, Name: "Bar"},
+			{ID: 102, ParentID: 100, Name: "Baz"},
+			{ID: 103, ParentID: 100, Name: "Qux"},
+		}, nil)
+
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(ctx, "SEEK-Jobs", "repo1").
+		Return(&Repo{
+			Name:   "repo1",
+			Topics: []string{"admin-foo", "old"},
+			Teams: []*TeamPermission{
+				{TeamName: "Foo", Permission: RepoPermissionAdmin},
+				{TeamName: "Bar", Permission: RepoPermissionRead},
+				{TeamName: "Baz", Permission: RepoPermissionRead},
+			},
+		}, nil)
+
+	// repo2 is already in the desired state
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(ctx, "SEEK-Jobs", "repo2").
+		Return(&Repo{
+			Name:   "repo2",
+			Topics: []string{"admin-foo"},
+			Teams: []*TeamPermission{
+				{TeamName: "Foo", Permission: RepoPermissionAdmin},
+			},
+		}, nil)
+
+	// repo3 is archived so must not be touched
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(ctx, "SEEK-Jobs", "repo3").
+		Return(&Repo{Name: "repo3", Archived: true}, nil)
+
+	// Expect Bar to be updated to write, Qux to be added and Baz to be removed
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo1", GitHubTeamID(101), RepoPermissionWrite).
+		Return(nil)
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo1", GitHubTeamID(103), RepoPermissionRead).
+		Return(nil)
+	plat.MockGitHubService.
+		EXPECT().
+		DeleteTeamRepoPermission(ctx, "SEEK-Jobs", "repo1", GitHubTeamID(102)).
+		Return(nil)
+
+	// Expect unlisted topics to be removed while the admin topic is retained
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateRepoTopics(ctx, "SEEK-Jobs", "repo1", []string{"admin-foo", "go"}).
+		Return(nil)
+
+	res, err := ApplyRepos(ctx, plat, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ApplyReposResult{
+		TeamPermissionsAdded:   2,
+		TeamPermissionsRemoved: 1,
+		ReposTopicsUpdated:     1,
+	}
+	if diff := cmp.Diff(want, res); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
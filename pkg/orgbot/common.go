@@ -1,6 +1,8 @@
 package orgbot
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"regexp"
 	"sort"
 	"strings"
@@ -18,10 +20,11 @@ func SortOrg(org *Org) {
 	}
 }
 
-// SortTeam sorts the members, maintainers and child teams of the specified team.
+// SortTeam sorts the members, maintainers, owners and child teams of the specified team.
 func SortTeam(team *Team) {
 	sort.Strings(team.Maintainers)
 	sort.Strings(team.Members)
+	sort.Strings(team.Owners)
 
 	// Sort children by name for consistency
 	if team.Children != nil {
@@ -70,3 +73,13 @@ func normaliseName(name string) string {
 
 	return s
 }
+
+// shortHashLength is the number of hexadecimal characters in the suffix used to disambiguate topics.
+const shortHashLength = 6
+
+// shortHash returns a short, deterministic hash of the specified name that is used to
+// disambiguate generated topics that would otherwise collide.
+func shortHash(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:shortHashLength]
+}
@@ -4,10 +4,29 @@ import (
 	"context"
 )
 
-// DumpRepos returns the current configuration of repositories within the specified GitHub organisation.
-func DumpRepos(ctx context.Context, plat Platform, orgName string) ([]*Repo, error) {
+// DumpRepos returns the current configuration of the repositories within the specified GitHub
+// organisation that are selected by the specified selector (or all repositories if it is nil). The
+// direct collaborators of the repositories are only included if requested.
+func DumpRepos(ctx context.Context, plat Platform, orgName string, selector *RepoSelector, withCollaborators bool) ([]*Repo, error) {
+	selected, err := newRepoFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+
 	var repos []*Repo
 	if err := plat.GitHubService().WalkRepos(ctx, orgName, func(r *Repo) error {
+		if !selected(r) {
+			return nil
+		}
+
+		if withCollaborators {
+			collaborators, err := plat.GitHubService().ListRepoCollaborators(ctx, orgName, r.Name)
+			if err != nil {
+				return err
+			}
+			r.Collaborators = collaborators
+		}
+
 		repos = append(repos, r)
 		return nil
 	}); err != nil {
@@ -29,7 +29,7 @@ func TestDumpReposTopics(t *testing.T) {
 			return nil
 		})
 
-	repos, err := DumpRepos(ctx, plat, "SEEK-Jobs")
+	repos, err := DumpRepos(ctx, plat, "SEEK-Jobs", nil, false)
 	if err != nil {
 		t.Fatal(err)
 	}
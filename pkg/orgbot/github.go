@@ -20,6 +20,44 @@ const (
 	phonyTeamID GitHubTeamID = -1
 )
 
+// CommitState is the type used to describe the state of a commit status.
+type CommitState string
+
+// PullRequestReviewState is the type used to describe the state of a pull request review.
+type PullRequestReviewState string
+
+const (
+	ReviewApproved         PullRequestReviewState = "APPROVED"          // The reviewer approved the changes
+	ReviewChangesRequested PullRequestReviewState = "CHANGES_REQUESTED" // The reviewer requested changes
+	ReviewCommented        PullRequestReviewState = "COMMENTED"         // The reviewer only commented
+	ReviewDismissed        PullRequestReviewState = "DISMISSED"         // The review was dismissed
+)
+
+const (
+	CommitStatePending CommitState = "pending" // The commit is still being processed
+	CommitStateSuccess CommitState = "success" // Processing of the commit succeeded
+	CommitStateFailure CommitState = "failure" // The commit was processed but was found to be invalid
+	CommitStateError   CommitState = "error"   // The commit could not be processed
+)
+
+// CheckConclusion is the type used to describe the conclusion of a completed check run.
+type CheckConclusion string
+
+const (
+	CheckConclusionSuccess CheckConclusion = "success" // The check passed
+	CheckConclusionFailure CheckConclusion = "failure" // The check failed
+	CheckConclusionNeutral CheckConclusion = "neutral" // The check could not be completed
+)
+
+// CheckAnnotationLevel is the type used to describe the severity of a check run annotation.
+type CheckAnnotationLevel string
+
+const (
+	CheckAnnotationNotice  CheckAnnotationLevel = "notice"  // Informational annotation
+	CheckAnnotationWarning CheckAnnotationLevel = "warning" // Annotation that doesn't fail the check
+	CheckAnnotationFailure CheckAnnotationLevel = "failure" // Annotation that fails the check
+)
+
 // WalkReposFunc is the type of the function called for each repo in the GitHub org
 // by the WalkRepos function. If the function returns an error walking stops.
 type WalkReposFunc func(r *Repo) error
@@ -53,16 +91,22 @@ type GitHubService interface {
 	// DeleteTeamRepoPermission removes the specified team's permissions from the specified repository.
 	DeleteTeamRepoPermission(ctx context.Context, orgName string, repoName string, teamID GitHubTeamID) error
 
-	// WalkRepos walks over all repos in the specified org, passing each to the walk function.
+	// WalkRepos walks over all repos in the specified org, including archived repos, passing
+	// each to the walk function.
 	WalkRepos(ctx context.Context, orgName string, walkFn WalkReposFunc) error
 
 	// WalkReposByTeam walks over all repos that are directly accessible by the specified team,
-	// passing each to the walk function.
+	// including archived repos, passing each to the walk function.
 	WalkReposByTeam(ctx context.Context, orgName string, teamID GitHubTeamID, walkFn WalkReposFunc) error
 
 	// RepoByName returns the repo with name repoName
 	RepoByName(ctx context.Context, orgName, repoName string) (*Repo, error)
 
+	// ListRepoCollaborators returns the direct collaborators of the specified repo. The repos passed to
+	// walk functions and returned by RepoByName don't include their collaborators as fetching them costs
+	// an API call per repo.
+	ListRepoCollaborators(ctx context.Context, orgName, repoName string) ([]*CollaboratorPermission, error)
+
 	// UpdateRepoTopics updates the topics for the given repo.
 	UpdateRepoTopics(ctx context.Context, orgName, repoName string, topics []string) error
 
@@ -76,6 +120,62 @@ type GitHubService interface {
 	// UserByLogin returns the user in the specified org with the specified login username
 	// or GitHubUserNotFoundError if the user is not a member of the org.
 	UserByLogin(ctx context.Context, orgName, login string) (*GitHubUser, error)
+
+	// BranchSHA returns the SHA of the head commit of the specified branch.
+	BranchSHA(ctx context.Context, orgName, repoName, branch string) (string, error)
+
+	// DownloadDir writes the files beneath the specified path of the specified repo at the specified
+	// ref (a branch, tag or commit SHA) to the local directory dir, preserving their layout.
+	DownloadDir(ctx context.Context, orgName, repoName, ref, path, dir string) error
+
+	// CreateCommitStatus creates the specified status for the commit with the specified SHA.
+	CreateCommitStatus(ctx context.Context, orgName, repoName, sha string, status *CommitStatus) error
+
+	// CreateCheckRun creates the specified completed check run, including all of its annotations.
+	CreateCheckRun(ctx context.Context, orgName, repoName string, run *CheckRun) error
+
+	// ListOpenIssues returns the open issues of the specified repo, excluding pull requests.
+	ListOpenIssues(ctx context.Context, orgName, repoName string) ([]*Issue, error)
+
+	// CreateIssue opens an issue with the specified title and body and returns its number.
+	CreateIssue(ctx context.Context, orgName, repoName, title, body string) (int, error)
+
+	// UpdateIssue replaces the body of the specified issue.
+	UpdateIssue(ctx context.Context, orgName, repoName string, number int, body string) error
+
+	// ProposeFileChanges commits the specified file changes to a new branch and opens a pull request
+	// for them, returning its number. If the branch already exists it's reused, and if it already has
+	// an open pull request the changes are considered proposed and the number of that pull request is
+	// returned instead.
+	ProposeFileChanges(ctx context.Context, orgName, repoName string, proposal *FileChangeProposal) (int, error)
+
+	// Login returns the login that the changes made through this service are attributed to, i.e. the
+	// bot user of the GitHub App or the user of the token.
+	Login(ctx context.Context) (string, error)
+
+	// ListIssueComments returns the comments on the specified issue or pull request in the order
+	// they were created.
+	ListIssueComments(ctx context.Context, orgName, repoName string, number int) ([]*IssueComment, error)
+
+	// CreateIssueComment adds a comment with the specified body to the specified issue or pull request.
+	CreateIssueComment(ctx context.Context, orgName, repoName string, number int, body string) error
+
+	// UpdateIssueComment replaces the body of the comment with the specified ID.
+	UpdateIssueComment(ctx context.Context, orgName, repoName string, commentID int64, body string) error
+
+	// PullRequestByNumber returns the pull request with the specified number.
+	PullRequestByNumber(ctx context.Context, orgName, repoName string, number int) (*PullRequest, error)
+
+	// ListPullRequestReviews returns the reviews of the specified pull request in the order they
+	// were submitted.
+	ListPullRequestReviews(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestReview, error)
+
+	// MergePullRequest merges the specified pull request, provided that its head is still the commit with
+	// the specified SHA, and returns the SHA of the merge commit.
+	MergePullRequest(ctx context.Context, orgName, repoName string, number int, sha string) (string, error)
+
+	// ListPullRequestFiles returns the files changed by the specified pull request.
+	ListPullRequestFiles(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestFile, error)
 }
 
 // GitHubTeam represents a team within GitHub.
@@ -92,6 +192,79 @@ type GitHubUser struct {
 	Email string // Company email address
 }
 
+// CommitStatus represents the status of a commit as reported to GitHub.
+type CommitStatus struct {
+	Context     string      // Label that differentiates this status from those of other systems
+	State       CommitState // State of the status
+	Description string      // Short description of the status
+	TargetURL   string      // URL providing more detail about the status (optional)
+}
+
+// CheckRun represents a completed check run on a commit.
+type CheckRun struct {
+	Name        string             // Name of the check
+	HeadSHA     string             // SHA of the commit that was checked
+	Conclusion  CheckConclusion    // Conclusion of the check
+	Title       string             // Title of the check output
+	Summary     string             // Summary of the check output (markdown)
+	Text        string             // Details of the check output (markdown, optional)
+	Annotations []*CheckAnnotation // Annotations of specific files (optional)
+}
+
+// CheckAnnotation represents an annotation of a line within a file as part of a check run.
+type CheckAnnotation struct {
+	Path    string               // Path of the annotated file relative to the root of the repo
+	Line    int                  // Line of the file that is annotated
+	Level   CheckAnnotationLevel // Severity of the annotation
+	Title   string               // Title of the annotation (optional)
+	Message string               // Message of the annotation
+}
+
+// FileChangeProposal describes changes to files of a repo that are proposed as a pull request.
+type FileChangeProposal struct {
+	Base   string            // Branch the pull request is to be merged into
+	Branch string            // Branch created for the changes
+	Title  string            // Title of the pull request and message of the commits
+	Body   string            // Body of the pull request (markdown)
+	Files  map[string][]byte // New contents of the files keyed by path relative to the root of the repo
+}
+
+// Issue represents an issue.
+type Issue struct {
+	Number int    // Number of the issue
+	Title  string // Title of the issue
+	Body   string // Body of the issue (markdown)
+}
+
+// IssueComment represents a comment on an issue or pull request.
+type IssueComment struct {
+	ID     int64  // ID of the comment
+	Author string // Login of the user that created the comment
+	Body   string // Body of the comment (markdown)
+}
+
+// PullRequest represents a pull request.
+type PullRequest struct {
+	Number  int    // Number of the pull request
+	Author  string // Login of the user that opened the pull request
+	Open    bool   // Whether the pull request is open
+	HeadSHA string // SHA of the head commit of the pull request
+	BaseRef string // Name of the branch the pull request is to be merged into
+}
+
+// PullRequestFile represents a file changed by a pull request.
+type PullRequestFile struct {
+	Path         string // Path of the file relative to the root of the repo
+	PreviousPath string // Path of the file before it was renamed (empty unless renamed)
+}
+
+// PullRequestReview represents a review of a pull request.
+type PullRequestReview struct {
+	Author   string                 // Login of the reviewer
+	State    PullRequestReviewState // State of the review
+	CommitID string                 // SHA of the commit that was reviewed
+}
+
 // GitHubServiceWithStats extends the GitHubService interface to provide stats gathering functionality.
 type GitHubServiceWithStats interface {
 	GitHubService
@@ -105,11 +278,14 @@ type GitHubServiceWithStats interface {
 
 // GitHubStats encapsulates GitHub API operation statistics.
 type GitHubStats struct {
-	TeamsCreated           int // Number of teams created
-	TeamsUpdated           int // Number of teams updated
-	TeamsDeleted           int // Number of teams deleted
-	TeamMembershipsAdded   int // Number of team memberships added
-	TeamMembershipsDeleted int // Number of team memberships deleted
+	TeamsCreated               int // Number of teams created
+	TeamsUpdated               int // Number of teams updated
+	TeamsDeleted               int // Number of teams deleted
+	TeamMembershipsAdded       int // Number of team memberships added
+	TeamMembershipsDeleted     int // Number of team memberships deleted
+	TeamRepoPermissionsAdded   int // Number of team repo permissions added or updated
+	TeamRepoPermissionsDeleted int // Number of team repo permissions deleted
+	RepoTopicsUpdated          int // Number of repos that had their topics updated
 }
 
 // GitHubUserNotFoundError is the type of error returned when no SSO information can be found for a user.
@@ -208,6 +384,11 @@ func (s *readOnlyService) RepoByName(ctx context.Context, orgName, repoName stri
 	return s.delegate.RepoByName(ctx, orgName, repoName)
 }
 
+// ListRepoCollaborators implements orgbot.GitHubService.
+func (s *readOnlyService) ListRepoCollaborators(ctx context.Context, orgName, repoName string) ([]*CollaboratorPermission, error) {
+	return s.delegate.ListRepoCollaborators(ctx, orgName, repoName)
+}
+
 // UpdateRepoTopics implements orgbot.GitHubService
 func (s *readOnlyService) UpdateRepoTopics(ctx context.Context, orgName, repoName string, topics []string) error {
 	return nil
@@ -228,6 +409,86 @@ func (s *readOnlyService) UserByLogin(ctx context.Context, orgName, login string
 	return s.delegate.UserByLogin(ctx, orgName, login)
 }
 
+// BranchSHA implements orgbot.GitHubService.
+func (s *readOnlyService) BranchSHA(ctx context.Context, orgName, repoName, branch string) (string, error) {
+	return s.delegate.BranchSHA(ctx, orgName, repoName, branch)
+}
+
+// DownloadDir implements orgbot.GitHubService.
+func (s *readOnlyService) DownloadDir(ctx context.Context, orgName, repoName, ref, path, dir string) error {
+	return s.delegate.DownloadDir(ctx, orgName, repoName, ref, path, dir)
+}
+
+// CreateCommitStatus implements orgbot.GitHubService.
+func (s *readOnlyService) CreateCommitStatus(ctx context.Context, orgName, repoName, sha string, status *CommitStatus) error {
+	return nil
+}
+
+// CreateCheckRun implements orgbot.GitHubService.
+func (s *readOnlyService) CreateCheckRun(ctx context.Context, orgName, repoName string, run *CheckRun) error {
+	return nil
+}
+
+// ListOpenIssues implements orgbot.GitHubService.
+func (s *readOnlyService) ListOpenIssues(ctx context.Context, orgName, repoName string) ([]*Issue, error) {
+	return s.delegate.ListOpenIssues(ctx, orgName, repoName)
+}
+
+// CreateIssue implements orgbot.GitHubService.
+func (s *readOnlyService) CreateIssue(ctx context.Context, orgName, repoName, title, body string) (int, error) {
+	return 0, nil
+}
+
+// UpdateIssue implements orgbot.GitHubService.
+func (s *readOnlyService) UpdateIssue(ctx context.Context, orgName, repoName string, number int, body string) error {
+	return nil
+}
+
+// ProposeFileChanges implements orgbot.GitHubService.
+func (s *readOnlyService) ProposeFileChanges(ctx context.Context, orgName, repoName string, proposal *FileChangeProposal) (int, error) {
+	return 0, nil
+}
+
+// Login implements orgbot.GitHubService.
+func (s *readOnlyService) Login(ctx context.Context) (string, error) {
+	return s.delegate.Login(ctx)
+}
+
+// ListIssueComments implements orgbot.GitHubService.
+func (s *readOnlyService) ListIssueComments(ctx context.Context, orgName, repoName string, number int) ([]*IssueComment, error) {
+	return s.delegate.ListIssueComments(ctx, orgName, repoName, number)
+}
+
+// CreateIssueComment implements orgbot.GitHubService.
+func (s *readOnlyService) CreateIssueComment(ctx context.Context, orgName, repoName string, number int, body string) error {
+	return nil
+}
+
+// UpdateIssueComment implements orgbot.GitHubService.
+func (s *readOnlyService) UpdateIssueComment(ctx context.Context, orgName, repoName string, commentID int64, body string) error {
+	return nil
+}
+
+// PullRequestByNumber implements orgbot.GitHubService.
+func (s *readOnlyService) PullRequestByNumber(ctx context.Context, orgName, repoName string, number int) (*PullRequest, error) {
+	return s.delegate.PullRequestByNumber(ctx, orgName, repoName, number)
+}
+
+// ListPullRequestFiles implements orgbot.GitHubService.
+func (s *readOnlyService) ListPullRequestFiles(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestFile, error) {
+	return s.delegate.ListPullRequestFiles(ctx, orgName, repoName, number)
+}
+
+// ListPullRequestReviews implements orgbot.GitHubService.
+func (s *readOnlyService) ListPullRequestReviews(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestReview, error) {
+	return s.delegate.ListPullRequestReviews(ctx, orgName, repoName, number)
+}
+
+// MergePullRequest implements orgbot.GitHubService.
+func (s *readOnlyService) MergePullRequest(ctx context.Context, orgName, repoName string, number int, sha string) (string, error) {
+	return "", nil
+}
+
 // statsService provides a GitHubService adapter that gathers statistics about GitHub
 // API operations after calling its delegate.
 type statsService struct {
@@ -321,12 +582,28 @@ func (s *statsService) DeleteTeamMembership(ctx context.Context, orgName string,
 
 // AddTeamRepoPermission implements orgbot.GitHubService.
 func (s *statsService) AddTeamRepoPermission(ctx context.Context, orgName string, repoName string, teamID GitHubTeamID, permission RepoPermission) error {
-	return s.delegate.AddTeamRepoPermission(ctx, orgName, repoName, teamID, permission)
+	if err := s.delegate.AddTeamRepoPermission(ctx, orgName, repoName, teamID, permission); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.stats.TeamRepoPermissionsAdded++
+	return nil
 }
 
 // DeleteTeamRepoPermission implements orgbot.GitHubService.
 func (s *statsService) DeleteTeamRepoPermission(ctx context.Context, orgName string, repoName string, teamID GitHubTeamID) error {
-	return s.delegate.DeleteTeamRepoPermission(ctx, orgName, repoName, teamID)
+	if err := s.delegate.DeleteTeamRepoPermission(ctx, orgName, repoName, teamID); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.stats.TeamRepoPermissionsDeleted++
+	return nil
 }
 
 // WalkRepos implements orgbot.GitHubService.
@@ -344,9 +621,22 @@ func (s *statsService) RepoByName(ctx context.Context, orgName, repoName string)
 	return s.delegate.RepoByName(ctx, orgName, repoName)
 }
 
+// ListRepoCollaborators implements orgbot.GitHubService.
+func (s *statsService) ListRepoCollaborators(ctx context.Context, orgName, repoName string) ([]*CollaboratorPermission, error) {
+	return s.delegate.ListRepoCollaborators(ctx, orgName, repoName)
+}
+
 // UpdateRepoTopics implements orgbot.GithubService
 func (s *statsService) UpdateRepoTopics(ctx context.Context, orgName, repoName string, topics []string) error {
-	return s.delegate.UpdateRepoTopics(ctx, orgName, repoName, topics)
+	if err := s.delegate.UpdateRepoTopics(ctx, orgName, repoName, topics); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.stats.RepoTopicsUpdated++
+	return nil
 }
 
 // ListAdmins implements orgbot.GitHubService.
@@ -364,6 +654,86 @@ func (s *statsService) UserByLogin(ctx context.Context, orgName, login string) (
 	return s.delegate.UserByLogin(ctx, orgName, login)
 }
 
+// BranchSHA implements orgbot.GitHubService.
+func (s *statsService) BranchSHA(ctx context.Context, orgName, repoName, branch string) (string, error) {
+	return s.delegate.BranchSHA(ctx, orgName, repoName, branch)
+}
+
+// DownloadDir implements orgbot.GitHubService.
+func (s *statsService) DownloadDir(ctx context.Context, orgName, repoName, ref, path, dir string) error {
+	return s.delegate.DownloadDir(ctx, orgName, repoName, ref, path, dir)
+}
+
+// CreateCommitStatus implements orgbot.GitHubService.
+func (s *statsService) CreateCommitStatus(ctx context.Context, orgName, repoName, sha string, status *CommitStatus) error {
+	return s.delegate.CreateCommitStatus(ctx, orgName, repoName, sha, status)
+}
+
+// CreateCheckRun implements orgbot.GitHubService.
+func (s *statsService) CreateCheckRun(ctx context.Context, orgName, repoName string, run *CheckRun) error {
+	return s.delegate.CreateCheckRun(ctx, orgName, repoName, run)
+}
+
+// ListOpenIssues implements orgbot.GitHubService.
+func (s *statsService) ListOpenIssues(ctx context.Context, orgName, repoName string) ([]*Issue, error) {
+	return s.delegate.ListOpenIssues(ctx, orgName, repoName)
+}
+
+// CreateIssue implements orgbot.GitHubService.
+func (s *statsService) CreateIssue(ctx context.Context, orgName, repoName, title, body string) (int, error) {
+	return s.delegate.CreateIssue(ctx, orgName, repoName, title, body)
+}
+
+// UpdateIssue implements orgbot.GitHubService.
+func (s *statsService) UpdateIssue(ctx context.Context, orgName, repoName string, number int, body string) error {
+	return s.delegate.UpdateIssue(ctx, orgName, repoName, number, body)
+}
+
+// ProposeFileChanges implements orgbot.GitHubService.
+func (s *statsService) ProposeFileChanges(ctx context.Context, orgName, repoName string, proposal *FileChangeProposal) (int, error) {
+	return s.delegate.ProposeFileChanges(ctx, orgName, repoName, proposal)
+}
+
+// Login implements orgbot.GitHubService.
+func (s *statsService) Login(ctx context.Context) (string, error) {
+	return s.delegate.Login(ctx)
+}
+
+// ListIssueComments implements orgbot.GitHubService.
+func (s *statsService) ListIssueComments(ctx context.Context, orgName, repoName string, number int) ([]*IssueComment, error) {
+	return s.delegate.ListIssueComments(ctx, orgName, repoName, number)
+}
+
+// CreateIssueComment implements orgbot.GitHubService.
+func (s *statsService) CreateIssueComment(ctx context.Context, orgName, repoName string, number int, body string) error {
+	return s.delegate.CreateIssueComment(ctx, orgName, repoName, number, body)
+}
+
+// UpdateIssueComment implements orgbot.GitHubService.
+func (s *statsService) UpdateIssueComment(ctx context.Context, orgName, repoName string, commentID int64, body string) error {
+	return s.delegate.UpdateIssueComment(ctx, orgName, repoName, commentID, body)
+}
+
+// PullRequestByNumber implements orgbot.GitHubService.
+func (s *statsService) PullRequestByNumber(ctx context.Context, orgName, repoName string, number int) (*PullRequest, error) {
+	return s.delegate.PullRequestByNumber(ctx, orgName, repoName, number)
+}
+
+// ListPullRequestFiles implements orgbot.GitHubService.
+func (s *statsService) ListPullRequestFiles(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestFile, error) {
+	return s.delegate.ListPullRequestFiles(ctx, orgName, repoName, number)
+}
+
+// ListPullRequestReviews implements orgbot.GitHubService.
+func (s *statsService) ListPullRequestReviews(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestReview, error) {
+	return s.delegate.ListPullRequestReviews(ctx, orgName, repoName, number)
+}
+
+// MergePullRequest implements orgbot.GitHubService.
+func (s *statsService) MergePullRequest(ctx context.Context, orgName, repoName string, number int, sha string) (string, error) {
+	return s.delegate.MergePullRequest(ctx, orgName, repoName, number, sha)
+}
+
 // ZeroStats implements orgbot.GitHubServiceWithStats.
 func (s *statsService) ZeroStats() {
 	s.stats = GitHubStats{}
@@ -0,0 +1,88 @@
+package orgbot
+
+import (
+	"context"
+	"strings"
+)
+
+// DepartedUsers returns a map of the names of the teams within the specified org to the email addresses
+// of their members and owners that are no longer members of the GitHub org. The specified departed email
+// addresses are always treated as departed as the user information may not yet reflect their departure.
+// Teams without departed users are omitted.
+func DepartedUsers(ctx context.Context, gitHubService GitHubService, org *Org, departed ...string) (map[string][]string, error) {
+	known := map[string]bool{}
+	for _, email := range departed {
+		known[strings.ToLower(email)] = false
+	}
+
+	// isDeparted returns whether the user with the specified email address has departed, caching the outcome
+	isDeparted := func(email string) (bool, error) {
+		k := strings.ToLower(email)
+		if member, ok := known[k]; ok {
+			return !member, nil
+		}
+
+		_, err := gitHubService.UserByEmail(ctx, org.Name, email)
+		if err != nil {
+			if _, ok := err.(*GitHubUserNotFoundError); !ok {
+				return false, err
+			}
+		}
+		known[k] = err == nil
+		return err != nil, nil
+	}
+
+	violations := map[string][]string{}
+
+	// descend recursively descends into the team hierarchy looking for departed users within each team
+	var descend func([]*Team) error
+	descend = func(teams []*Team) error {
+		for _, t := range teams {
+			emails := append(append([]string{}, t.Maintainers...), t.Members...)
+			for _, email := range mergeEmails(emails, t.Owners) {
+				departed, err := isDeparted(email)
+				if err != nil {
+					return err
+				}
+				if departed {
+					violations[t.Name] = append(violations[t.Name], email)
+				}
+			}
+			if err := descend(t.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := descend(org.Teams); err != nil {
+		return nil, err
+	}
+
+	return violations, nil
+}
+
+// IsMembershipDrift returns whether a change to the membership of the user with the specified email
+// address in the team with the specified name departs from the desired state of the specified org, i.e.
+// whether the user was added to a team they aren't meant to be in or removed from a team they are meant
+// to be in. Teams that aren't part of the org are unmanaged so any member added to them is drift.
+func IsMembershipDrift(org *Org, teamName, email string, added bool) bool {
+	wanted := false
+
+	var descend func([]*Team)
+	descend = func(teams []*Team) {
+		for _, t := range teams {
+			if t.Name == teamName {
+				for _, e := range append(append([]string{}, t.Maintainers...), t.Members...) {
+					if strings.EqualFold(e, email) {
+						wanted = true
+					}
+				}
+			}
+			descend(t.Children)
+		}
+	}
+	descend(org.Teams)
+
+	return wanted != added
+}
@@ -0,0 +1,97 @@
+package orgbot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDepartedUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gitHubService := NewMockGitHubService(ctrl)
+	ctx := context.Background()
+
+	gitHubService.
+		EXPECT().
+		UserByEmail(ctx, "SEEK-Jobs", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName, email string) (*GitHubUser, error) {
+			if strings.HasSuffix(email, "@unknown.com") {
+				return nil, &GitHubUserNotFoundError{OrgName: "SEEK-Jobs", Email: email}
+			}
+			return &GitHubUser{Login: "foobar", Email: email}, nil
+		}).
+		AnyTimes()
+
+	org := &Org{
+		Name: "SEEK-Jobs",
+		Teams: []*Team{
+			{
+				Name:    "Foo",
+				Members: []string{"member-a@foo.com", "member-b@unknown.com"},
+				Owners:  []string{"owner-a@unknown.com"},
+				Children: []*Team{
+					{
+						Name:    "Bar",
+						Members: []string{"member-a@bar.com", "Leaver@bar.com"}, // Not yet reflected by the user information
+					},
+				},
+			},
+			{
+				Name:    "Baz",
+				Members: []string{"member-a@baz.com"},
+			},
+		},
+	}
+
+	got, err := DepartedUsers(ctx, gitHubService, org, "leaver@bar.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"Foo": {"member-b@unknown.com", "owner-a@unknown.com"},
+		"Bar": {"Leaver@bar.com"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestIsMembershipDrift(t *testing.T) {
+	org := &Org{
+		Name: "SEEK-Jobs",
+		Teams: []*Team{
+			{
+				Name:    "Foo",
+				Members: []string{"member-a@foo.com"},
+				Children: []*Team{
+					{Name: "Bar", Members: []string{"member-a@bar.com"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		team  string
+		email string
+		added bool
+		want  bool
+	}{
+		{team: "Foo", email: "member-a@foo.com", added: true, want: false},
+		{team: "Foo", email: "Member-A@foo.com", added: false, want: true},
+		{team: "Bar", email: "member-a@foo.com", added: true, want: true},
+		{team: "Bar", email: "member-b@bar.com", added: false, want: false},
+		{team: "Unmanaged", email: "member-a@foo.com", added: true, want: true},
+	}
+
+	for _, test := range tests {
+		if got := IsMembershipDrift(org, test.team, test.email, test.added); got != test.want {
+			t.Errorf("IsMembershipDrift(%s, %s, %t) = %t, want %t", test.team, test.email, test.added, got, test.want)
+		}
+	}
+}
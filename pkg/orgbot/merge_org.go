@@ -14,10 +14,14 @@ const (
 	teamFile = "team.yaml" // Control file that describes a team within an org
 )
 
+// ReposFile is the name of the control file alongside the org file that describes the repos within an org.
+const ReposFile = "repos.yaml"
+
 var (
-	unrecognisedFileReason = fmt.Sprintf("only %s and %s files are supported", orgFile, teamFile)
+	unrecognisedFileReason = fmt.Sprintf("only %s, %s and %s files are supported", orgFile, teamFile, ReposFile)
 	multipleOrgFilesReason = fmt.Sprintf("multiple %s files detected", orgFile)
 	noSiblingFilesReason   = fmt.Sprintf("%s and %s files can't be siblings", orgFile, teamFile)
+	nestedReposFileReason  = fmt.Sprintf("%s files are only allowed alongside the %s file", ReposFile, orgFile)
 )
 
 // MissingControlFileError indicates the absence of a control file in a directory where one is expected.
@@ -55,6 +59,17 @@ func (e *InvalidTeamDirNameError) Error() string {
 		e.Dir, e.ValidDir, e.TeamName)
 }
 
+// DecodeError indicates that a file within the organisational structure could not be decoded.
+type DecodeError struct {
+	Path string // Path of the file that could not be decoded
+	Err  error  // Error returned by the codec
+}
+
+// Error implements error.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("could not unmarshal %s: %v", e.Path, e.Err)
+}
+
 // MergeOrg descends into the specified directory, reading the org.yaml and team.yaml
 // files, and constructs and returns an Org.
 func MergeOrg(codec Codec, dir string) (*Org, error) {
@@ -130,7 +145,15 @@ func MergeOrg(codec Codec, dir string) (*Org, error) {
 				continue
 			}
 
-			// All files other than org.yaml and team.yaml are unrecognised
+			// repos.yaml files are only allowed in the top level org directory and are read by MergeRepos
+			if f.Name() == ReposFile {
+				if dir != orgDir {
+					return &UnexpectedFileError{Path: path, Reason: nestedReposFileReason}
+				}
+				continue
+			}
+
+			// All files other than org.yaml, team.yaml and repos.yaml are unrecognised
 			return &UnexpectedFileError{Path: path, Reason: unrecognisedFileReason}
 		}
 
@@ -144,6 +167,81 @@ func MergeOrg(codec Codec, dir string) (*Org, error) {
 	return org, nil
 }
 
+// MergeRepos reads the repos.yaml file from the specified org directory and returns it as
+// a RepoManifest. If the manifest does not specify the org name, the name is taken from the
+// org.yaml file in the same directory.
+func MergeRepos(codec Codec, dir string) (*RepoManifest, error) {
+	org, err := readOrg(codec, dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &MissingControlFileError{Dir: dir, File: orgFile}
+		}
+		return nil, err
+	}
+
+	manifest, err := ReadRepoManifest(codec, filepath.Join(dir, ReposFile))
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, &MissingControlFileError{Dir: dir, File: ReposFile}
+		}
+		return nil, err
+	}
+
+	if manifest.Name == "" {
+		manifest.Name = org.Name
+	}
+
+	return manifest, nil
+}
+
+// TeamFiles descends into the specified org directory and returns a map of team names to the paths
+// of the team.yaml files that describe them. Paths are relative to the org directory and use forward
+// slashes. More than one path is returned for a name when multiple teams share it.
+func TeamFiles(codec Codec, dir string) (map[string][]string, error) {
+	files := map[string][]string{}
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || info.Name() != teamFile {
+			return nil
+		}
+
+		t, err := readTeam(codec, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		files[t.Name] = append(files[t.Name], filepath.ToSlash(rel))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// ReadRepoManifest reads the specified repos file and returns it as a RepoManifest.
+func ReadRepoManifest(codec Codec, path string) (*RepoManifest, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", path)
+	}
+
+	manifest := RepoManifest{}
+	if err = codec.Decode(buf, &manifest); err != nil {
+		return nil, &DecodeError{Path: path, Err: err}
+	}
+
+	return &manifest, nil
+}
+
 // UnmergeOrg decomposes the specified Org into a hierarchy of teams and writes them to the specified
 // directory under a top-level directory named after the organisation. Directory names are normalised
 // representations of the team/org name.
@@ -202,7 +300,7 @@ func readOrg(codec Codec, dir string) (*Org, error) {
 
 	org := Org{}
 	if err = codec.Decode(buf, &org); err != nil {
-		return nil, errors.Wrapf(err, "could not unmarshal %s", path)
+		return nil, &DecodeError{Path: path, Err: err}
 	}
 
 	return &org, nil
@@ -235,7 +333,7 @@ func readTeam(codec Codec, dir string) (*Team, error) {
 
 	team := Team{}
 	if err = codec.Decode(buf, &team); err != nil {
-		return nil, errors.Wrapf(err, "could not unmarshal %s", path)
+		return nil, &DecodeError{Path: path, Err: err}
 	}
 
 	return &team, nil
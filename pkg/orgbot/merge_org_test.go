@@ -54,6 +54,48 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeRepos(t *testing.T) {
+	want := &RepoManifest{
+		Name: "Org-A",
+		Repos: []*RepoSpec{
+			{
+				Name:   "repo-a",
+				Topics: []string{"go"},
+				Teams: []*RepoTeam{
+					{Name: "Team A", Permission: RepoPermissionAdmin},
+					{Name: "Team C", Permission: "read", Reason: "Team C consumes the repo-a API"},
+				},
+			},
+		},
+	}
+
+	got, err := MergeRepos(yaml.NewCodec(), "test_data/valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestTeamFiles(t *testing.T) {
+	want := map[string][]string{
+		"Team A": {"team-a/team.yaml"},
+		"Team B": {"team-b/team.yaml"},
+		"Team C": {"team-b/team-c/team.yaml"},
+	}
+
+	got, err := TeamFiles(yaml.NewCodec(), "test_data/valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
 func TestMergeValidationErrors(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -80,7 +122,7 @@ func TestMergeValidationErrors(t *testing.T) {
 		{
 			name:    "HasMaintainers",
 			dir:     "test_data/has-maintainers",
-			wantErr: nil, // This is just a generic YAML type error
+			wantErr: nil, // This is a YAML type error, the details of which are up to the codec
 		},
 		{
 			name: "MissingOrgFile",
@@ -122,8 +164,8 @@ func TestMergeValidationErrors(t *testing.T) {
 			if diff := cmp.Diff(test.wantErr, err); diff != "" {
 				t.Errorf("Test case '%s': (-want +got)\n%s", test.name, diff)
 			}
-		} else if err == nil {
-			t.Errorf("Test case '%s': expected error but got nil\n", test.name)
+		} else if _, ok := err.(*DecodeError); !ok {
+			t.Errorf("Test case '%s': expected decode error but got %v\n", test.name, err)
 		}
 	}
 }
@@ -206,6 +206,21 @@ func (mr *MockGitHubServiceMockRecorder) RepoByName(ctx, orgName, repoName inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepoByName", reflect.TypeOf((*MockGitHubService)(nil).RepoByName), ctx, orgName, repoName)
 }
 
+// ListRepoCollaborators mocks base method
+func (m *MockGitHubService) ListRepoCollaborators(ctx context.Context, orgName, repoName string) ([]*CollaboratorPermission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRepoCollaborators", ctx, orgName, repoName)
+	ret0, _ := ret[0].([]*CollaboratorPermission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRepoCollaborators indicates an expected call of ListRepoCollaborators
+func (mr *MockGitHubServiceMockRecorder) ListRepoCollaborators(ctx, orgName, repoName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoCollaborators", reflect.TypeOf((*MockGitHubService)(nil).ListRepoCollaborators), ctx, orgName, repoName)
+}
+
 // UpdateRepoTopics mocks base method
 func (m *MockGitHubService) UpdateRepoTopics(ctx context.Context, orgName, repoName string, topics []string) error {
 	m.ctrl.T.Helper()
@@ -265,6 +280,240 @@ func (mr *MockGitHubServiceMockRecorder) UserByLogin(ctx, orgName, login interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByLogin", reflect.TypeOf((*MockGitHubService)(nil).UserByLogin), ctx, orgName, login)
 }
 
+// BranchSHA mocks base method
+func (m *MockGitHubService) BranchSHA(ctx context.Context, orgName, repoName, branch string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BranchSHA", ctx, orgName, repoName, branch)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BranchSHA indicates an expected call of BranchSHA
+func (mr *MockGitHubServiceMockRecorder) BranchSHA(ctx, orgName, repoName, branch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BranchSHA", reflect.TypeOf((*MockGitHubService)(nil).BranchSHA), ctx, orgName, repoName, branch)
+}
+
+// DownloadDir mocks base method
+func (m *MockGitHubService) DownloadDir(ctx context.Context, orgName, repoName, ref, path, dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadDir", ctx, orgName, repoName, ref, path, dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadDir indicates an expected call of DownloadDir
+func (mr *MockGitHubServiceMockRecorder) DownloadDir(ctx, orgName, repoName, ref, path, dir interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadDir", reflect.TypeOf((*MockGitHubService)(nil).DownloadDir), ctx, orgName, repoName, ref, path, dir)
+}
+
+// CreateCommitStatus mocks base method
+func (m *MockGitHubService) CreateCommitStatus(ctx context.Context, orgName, repoName, sha string, status *CommitStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommitStatus", ctx, orgName, repoName, sha, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCommitStatus indicates an expected call of CreateCommitStatus
+func (mr *MockGitHubServiceMockRecorder) CreateCommitStatus(ctx, orgName, repoName, sha, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommitStatus", reflect.TypeOf((*MockGitHubService)(nil).CreateCommitStatus), ctx, orgName, repoName, sha, status)
+}
+
+// CreateCheckRun mocks base method
+func (m *MockGitHubService) CreateCheckRun(ctx context.Context, orgName, repoName string, run *CheckRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCheckRun", ctx, orgName, repoName, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCheckRun indicates an expected call of CreateCheckRun
+func (mr *MockGitHubServiceMockRecorder) CreateCheckRun(ctx, orgName, repoName, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCheckRun", reflect.TypeOf((*MockGitHubService)(nil).CreateCheckRun), ctx, orgName, repoName, run)
+}
+
+// Login mocks base method
+func (m *MockGitHubService) Login(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login
+func (mr *MockGitHubServiceMockRecorder) Login(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockGitHubService)(nil).Login), ctx)
+}
+
+// ListIssueComments mocks base method
+func (m *MockGitHubService) ListIssueComments(ctx context.Context, orgName, repoName string, number int) ([]*IssueComment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIssueComments", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].([]*IssueComment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIssueComments indicates an expected call of ListIssueComments
+func (mr *MockGitHubServiceMockRecorder) ListIssueComments(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIssueComments", reflect.TypeOf((*MockGitHubService)(nil).ListIssueComments), ctx, orgName, repoName, number)
+}
+
+// CreateIssueComment mocks base method
+func (m *MockGitHubService) CreateIssueComment(ctx context.Context, orgName, repoName string, number int, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIssueComment", ctx, orgName, repoName, number, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIssueComment indicates an expected call of CreateIssueComment
+func (mr *MockGitHubServiceMockRecorder) CreateIssueComment(ctx, orgName, repoName, number, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIssueComment", reflect.TypeOf((*MockGitHubService)(nil).CreateIssueComment), ctx, orgName, repoName, number, body)
+}
+
+// UpdateIssueComment mocks base method
+func (m *MockGitHubService) UpdateIssueComment(ctx context.Context, orgName, repoName string, commentID int64, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIssueComment", ctx, orgName, repoName, commentID, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIssueComment indicates an expected call of UpdateIssueComment
+func (mr *MockGitHubServiceMockRecorder) UpdateIssueComment(ctx, orgName, repoName, commentID, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIssueComment", reflect.TypeOf((*MockGitHubService)(nil).UpdateIssueComment), ctx, orgName, repoName, commentID, body)
+}
+
+// PullRequestByNumber mocks base method
+func (m *MockGitHubService) PullRequestByNumber(ctx context.Context, orgName, repoName string, number int) (*PullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PullRequestByNumber", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].(*PullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PullRequestByNumber indicates an expected call of PullRequestByNumber
+func (mr *MockGitHubServiceMockRecorder) PullRequestByNumber(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullRequestByNumber", reflect.TypeOf((*MockGitHubService)(nil).PullRequestByNumber), ctx, orgName, repoName, number)
+}
+
+// ListPullRequestReviews mocks base method
+func (m *MockGitHubService) ListPullRequestReviews(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestReview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPullRequestReviews", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].([]*PullRequestReview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPullRequestReviews indicates an expected call of ListPullRequestReviews
+func (mr *MockGitHubServiceMockRecorder) ListPullRequestReviews(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPullRequestReviews", reflect.TypeOf((*MockGitHubService)(nil).ListPullRequestReviews), ctx, orgName, repoName, number)
+}
+
+// MergePullRequest mocks base method
+func (m *MockGitHubService) MergePullRequest(ctx context.Context, orgName, repoName string, number int, sha string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergePullRequest", ctx, orgName, repoName, number, sha)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergePullRequest indicates an expected call of MergePullRequest
+func (mr *MockGitHubServiceMockRecorder) MergePullRequest(ctx, orgName, repoName, number, sha interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergePullRequest", reflect.TypeOf((*MockGitHubService)(nil).MergePullRequest), ctx, orgName, repoName, number, sha)
+}
+
+// ListPullRequestFiles mocks base method
+func (m *MockGitHubService) ListPullRequestFiles(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPullRequestFiles", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].([]*PullRequestFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPullRequestFiles indicates an expected call of ListPullRequestFiles
+func (mr *MockGitHubServiceMockRecorder) ListPullRequestFiles(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPullRequestFiles", reflect.TypeOf((*MockGitHubService)(nil).ListPullRequestFiles), ctx, orgName, repoName, number)
+}
+
+// ListOpenIssues mocks base method
+func (m *MockGitHubService) ListOpenIssues(ctx context.Context, orgName, repoName string) ([]*Issue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenIssues", ctx, orgName, repoName)
+	ret0, _ := ret[0].([]*Issue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenIssues indicates an expected call of ListOpenIssues
+func (mr *MockGitHubServiceMockRecorder) ListOpenIssues(ctx, orgName, repoName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenIssues", reflect.TypeOf((*MockGitHubService)(nil).ListOpenIssues), ctx, orgName, repoName)
+}
+
+// CreateIssue mocks base method
+func (m *MockGitHubService) CreateIssue(ctx context.Context, orgName, repoName, title, body string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIssue", ctx, orgName, repoName, title, body)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIssue indicates an expected call of CreateIssue
+func (mr *MockGitHubServiceMockRecorder) CreateIssue(ctx, orgName, repoName, title, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIssue", reflect.TypeOf((*MockGitHubService)(nil).CreateIssue), ctx, orgName, repoName, title, body)
+}
+
+// UpdateIssue mocks base method
+func (m *MockGitHubService) UpdateIssue(ctx context.Context, orgName, repoName string, number int, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIssue", ctx, orgName, repoName, number, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIssue indicates an expected call of UpdateIssue
+func (mr *MockGitHubServiceMockRecorder) UpdateIssue(ctx, orgName, repoName, number, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIssue", reflect.TypeOf((*MockGitHubService)(nil).UpdateIssue), ctx, orgName, repoName, number, body)
+}
+
+// ProposeFileChanges mocks base method
+func (m *MockGitHubService) ProposeFileChanges(ctx context.Context, orgName, repoName string, proposal *FileChangeProposal) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProposeFileChanges", ctx, orgName, repoName, proposal)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProposeFileChanges indicates an expected call of ProposeFileChanges
+func (mr *MockGitHubServiceMockRecorder) ProposeFileChanges(ctx, orgName, repoName, proposal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProposeFileChanges", reflect.TypeOf((*MockGitHubService)(nil).ProposeFileChanges), ctx, orgName, repoName, proposal)
+}
+
 // MockGitHubServiceWithStats is a mock of GitHubServiceWithStats interface
 type MockGitHubServiceWithStats struct {
 	ctrl     *gomock.Controller
@@ -461,6 +710,21 @@ func (mr *MockGitHubServiceWithStatsMockRecorder) RepoByName(ctx, orgName, repoN
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepoByName", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).RepoByName), ctx, orgName, repoName)
 }
 
+// ListRepoCollaborators mocks base method
+func (m *MockGitHubServiceWithStats) ListRepoCollaborators(ctx context.Context, orgName, repoName string) ([]*CollaboratorPermission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRepoCollaborators", ctx, orgName, repoName)
+	ret0, _ := ret[0].([]*CollaboratorPermission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRepoCollaborators indicates an expected call of ListRepoCollaborators
+func (mr *MockGitHubServiceWithStatsMockRecorder) ListRepoCollaborators(ctx, orgName, repoName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepoCollaborators", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).ListRepoCollaborators), ctx, orgName, repoName)
+}
+
 // UpdateRepoTopics mocks base method
 func (m *MockGitHubServiceWithStats) UpdateRepoTopics(ctx context.Context, orgName, repoName string, topics []string) error {
 	m.ctrl.T.Helper()
@@ -520,6 +784,240 @@ func (mr *MockGitHubServiceWithStatsMockRecorder) UserByLogin(ctx, orgName, logi
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserByLogin", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).UserByLogin), ctx, orgName, login)
 }
 
+// BranchSHA mocks base method
+func (m *MockGitHubServiceWithStats) BranchSHA(ctx context.Context, orgName, repoName, branch string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BranchSHA", ctx, orgName, repoName, branch)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BranchSHA indicates an expected call of BranchSHA
+func (mr *MockGitHubServiceWithStatsMockRecorder) BranchSHA(ctx, orgName, repoName, branch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BranchSHA", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).BranchSHA), ctx, orgName, repoName, branch)
+}
+
+// DownloadDir mocks base method
+func (m *MockGitHubServiceWithStats) DownloadDir(ctx context.Context, orgName, repoName, ref, path, dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadDir", ctx, orgName, repoName, ref, path, dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadDir indicates an expected call of DownloadDir
+func (mr *MockGitHubServiceWithStatsMockRecorder) DownloadDir(ctx, orgName, repoName, ref, path, dir interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadDir", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).DownloadDir), ctx, orgName, repoName, ref, path, dir)
+}
+
+// CreateCommitStatus mocks base method
+func (m *MockGitHubServiceWithStats) CreateCommitStatus(ctx context.Context, orgName, repoName, sha string, status *CommitStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommitStatus", ctx, orgName, repoName, sha, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCommitStatus indicates an expected call of CreateCommitStatus
+func (mr *MockGitHubServiceWithStatsMockRecorder) CreateCommitStatus(ctx, orgName, repoName, sha, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommitStatus", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).CreateCommitStatus), ctx, orgName, repoName, sha, status)
+}
+
+// CreateCheckRun mocks base method
+func (m *MockGitHubServiceWithStats) CreateCheckRun(ctx context.Context, orgName, repoName string, run *CheckRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCheckRun", ctx, orgName, repoName, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCheckRun indicates an expected call of CreateCheckRun
+func (mr *MockGitHubServiceWithStatsMockRecorder) CreateCheckRun(ctx, orgName, repoName, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCheckRun", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).CreateCheckRun), ctx, orgName, repoName, run)
+}
+
+// Login mocks base method
+func (m *MockGitHubServiceWithStats) Login(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login
+func (mr *MockGitHubServiceWithStatsMockRecorder) Login(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).Login), ctx)
+}
+
+// ListIssueComments mocks base method
+func (m *MockGitHubServiceWithStats) ListIssueComments(ctx context.Context, orgName, repoName string, number int) ([]*IssueComment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIssueComments", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].([]*IssueComment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIssueComments indicates an expected call of ListIssueComments
+func (mr *MockGitHubServiceWithStatsMockRecorder) ListIssueComments(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIssueComments", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).ListIssueComments), ctx, orgName, repoName, number)
+}
+
+// CreateIssueComment mocks base method
+func (m *MockGitHubServiceWithStats) CreateIssueComment(ctx context.Context, orgName, repoName string, number int, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIssueComment", ctx, orgName, repoName, number, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIssueComment indicates an expected call of CreateIssueComment
+func (mr *MockGitHubServiceWithStatsMockRecorder) CreateIssueComment(ctx, orgName, repoName, number, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIssueComment", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).CreateIssueComment), ctx, orgName, repoName, number, body)
+}
+
+// UpdateIssueComment mocks base method
+func (m *MockGitHubServiceWithStats) UpdateIssueComment(ctx context.Context, orgName, repoName string, commentID int64, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIssueComment", ctx, orgName, repoName, commentID, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIssueComment indicates an expected call of UpdateIssueComment
+func (mr *MockGitHubServiceWithStatsMockRecorder) UpdateIssueComment(ctx, orgName, repoName, commentID, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIssueComment", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).UpdateIssueComment), ctx, orgName, repoName, commentID, body)
+}
+
+// PullRequestByNumber mocks base method
+func (m *MockGitHubServiceWithStats) PullRequestByNumber(ctx context.Context, orgName, repoName string, number int) (*PullRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PullRequestByNumber", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].(*PullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PullRequestByNumber indicates an expected call of PullRequestByNumber
+func (mr *MockGitHubServiceWithStatsMockRecorder) PullRequestByNumber(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullRequestByNumber", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).PullRequestByNumber), ctx, orgName, repoName, number)
+}
+
+// ListPullRequestReviews mocks base method
+func (m *MockGitHubServiceWithStats) ListPullRequestReviews(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestReview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPullRequestReviews", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].([]*PullRequestReview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPullRequestReviews indicates an expected call of ListPullRequestReviews
+func (mr *MockGitHubServiceWithStatsMockRecorder) ListPullRequestReviews(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPullRequestReviews", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).ListPullRequestReviews), ctx, orgName, repoName, number)
+}
+
+// MergePullRequest mocks base method
+func (m *MockGitHubServiceWithStats) MergePullRequest(ctx context.Context, orgName, repoName string, number int, sha string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergePullRequest", ctx, orgName, repoName, number, sha)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergePullRequest indicates an expected call of MergePullRequest
+func (mr *MockGitHubServiceWithStatsMockRecorder) MergePullRequest(ctx, orgName, repoName, number, sha interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergePullRequest", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).MergePullRequest), ctx, orgName, repoName, number, sha)
+}
+
+// ListPullRequestFiles mocks base method
+func (m *MockGitHubServiceWithStats) ListPullRequestFiles(ctx context.Context, orgName, repoName string, number int) ([]*PullRequestFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPullRequestFiles", ctx, orgName, repoName, number)
+	ret0, _ := ret[0].([]*PullRequestFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPullRequestFiles indicates an expected call of ListPullRequestFiles
+func (mr *MockGitHubServiceWithStatsMockRecorder) ListPullRequestFiles(ctx, orgName, repoName, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPullRequestFiles", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).ListPullRequestFiles), ctx, orgName, repoName, number)
+}
+
+// ListOpenIssues mocks base method
+func (m *MockGitHubServiceWithStats) ListOpenIssues(ctx context.Context, orgName, repoName string) ([]*Issue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOpenIssues", ctx, orgName, repoName)
+	ret0, _ := ret[0].([]*Issue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOpenIssues indicates an expected call of ListOpenIssues
+func (mr *MockGitHubServiceWithStatsMockRecorder) ListOpenIssues(ctx, orgName, repoName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOpenIssues", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).ListOpenIssues), ctx, orgName, repoName)
+}
+
+// CreateIssue mocks base method
+func (m *MockGitHubServiceWithStats) CreateIssue(ctx context.Context, orgName, repoName, title, body string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIssue", ctx, orgName, repoName, title, body)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIssue indicates an expected call of CreateIssue
+func (mr *MockGitHubServiceWithStatsMockRecorder) CreateIssue(ctx, orgName, repoName, title, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIssue", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).CreateIssue), ctx, orgName, repoName, title, body)
+}
+
+// UpdateIssue mocks base method
+func (m *MockGitHubServiceWithStats) UpdateIssue(ctx context.Context, orgName, repoName string, number int, body string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIssue", ctx, orgName, repoName, number, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIssue indicates an expected call of UpdateIssue
+func (mr *MockGitHubServiceWithStatsMockRecorder) UpdateIssue(ctx, orgName, repoName, number, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIssue", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).UpdateIssue), ctx, orgName, repoName, number, body)
+}
+
+// ProposeFileChanges mocks base method
+func (m *MockGitHubServiceWithStats) ProposeFileChanges(ctx context.Context, orgName, repoName string, proposal *FileChangeProposal) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProposeFileChanges", ctx, orgName, repoName, proposal)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProposeFileChanges indicates an expected call of ProposeFileChanges
+func (mr *MockGitHubServiceWithStatsMockRecorder) ProposeFileChanges(ctx, orgName, repoName, proposal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProposeFileChanges", reflect.TypeOf((*MockGitHubServiceWithStats)(nil).ProposeFileChanges), ctx, orgName, repoName, proposal)
+}
+
 // ZeroStats mocks base method
 func (m *MockGitHubServiceWithStats) ZeroStats() {
 	m.ctrl.T.Helper()
@@ -599,6 +1097,32 @@ func (mr *MockRuleEngineMockRecorder) Add(r interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockRuleEngine)(nil).Add), r)
 }
 
+// RunRepos mocks base method
+func (m *MockRuleEngine) RunRepos(ctx context.Context, manifest *RepoManifest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunRepos", ctx, manifest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunRepos indicates an expected call of RunRepos
+func (mr *MockRuleEngineMockRecorder) RunRepos(ctx, manifest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunRepos", reflect.TypeOf((*MockRuleEngine)(nil).RunRepos), ctx, manifest)
+}
+
+// AddRepoRule mocks base method
+func (m *MockRuleEngine) AddRepoRule(r RepoRule) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "AddRepoRule", r)
+}
+
+// AddRepoRule indicates an expected call of AddRepoRule
+func (mr *MockRuleEngineMockRecorder) AddRepoRule(r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRepoRule", reflect.TypeOf((*MockRuleEngine)(nil).AddRepoRule), r)
+}
+
 // MockRule is a mock of Rule interface
 type MockRule struct {
 	ctrl     *gomock.Controller
@@ -636,6 +1160,43 @@ func (mr *MockRuleMockRecorder) Run(ctx, org interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRule)(nil).Run), ctx, org)
 }
 
+// MockRepoRule is a mock of RepoRule interface
+type MockRepoRule struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepoRuleMockRecorder
+}
+
+// MockRepoRuleMockRecorder is the mock recorder for MockRepoRule
+type MockRepoRuleMockRecorder struct {
+	mock *MockRepoRule
+}
+
+// NewMockRepoRule creates a new mock instance
+func NewMockRepoRule(ctrl *gomock.Controller) *MockRepoRule {
+	mock := &MockRepoRule{ctrl: ctrl}
+	mock.recorder = &MockRepoRuleMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRepoRule) EXPECT() *MockRepoRuleMockRecorder {
+	return m.recorder
+}
+
+// Run mocks base method
+func (m *MockRepoRule) Run(ctx context.Context, manifest *RepoManifest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx, manifest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run
+func (mr *MockRepoRuleMockRecorder) Run(ctx, manifest interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRepoRule)(nil).Run), ctx, manifest)
+}
+
 // MockRuleError is a mock of RuleError interface
 type MockRuleError struct {
 	ctrl     *gomock.Controller
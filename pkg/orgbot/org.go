@@ -1,5 +1,11 @@
 package orgbot
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // Org represents the desired state for an org.
 type Org struct {
 	Name  string  `json:"name,omitempty" yaml:"name,omitempty"`
@@ -19,23 +25,91 @@ type Team struct {
 	Maintainers     []string `json:"-" yaml:"-"`
 	Members         []string `json:"members,omitempty" yaml:"members,omitempty"`
 	RestrictMembers []string `json:"restrictMembers,omitempty" yaml:"restrictMembers,omitempty"`
-	Children        []*Team  `json:"teams,omitempty" yaml:"teams,omitempty"`
+
+	// Owners are the users that must approve changes to the team, or to any of its descendants, in
+	// the org repo. They needn't be members of the team.
+	Owners []string `json:"owners,omitempty" yaml:"owners,omitempty"`
+
+	Children []*Team `json:"teams,omitempty" yaml:"teams,omitempty"`
+}
+
+// RepoManifest represents the desired state for the repos within an org. Only the repos that
+// are listed are managed; all other repos in the org are left untouched.
+type RepoManifest struct {
+	Name  string      `json:"name,omitempty" yaml:"name,omitempty"`
+	Repos []*RepoSpec `json:"repos,omitempty" yaml:"repos,omitempty"`
+}
+
+// RepoSpec represents the desired state for a repo.
+type RepoSpec struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Topics excludes admin topics as they are derived from the admin teams of the repo.
+	Topics []string    `json:"topics,omitempty" yaml:"topics,omitempty"`
+	Teams  []*RepoTeam `json:"teams,omitempty" yaml:"teams,omitempty"`
+}
+
+// RepoTeam represents the desired permission of a team on a repo along with the reason
+// the team has been given access.
+type RepoTeam struct {
+	Name       string         `json:"name,omitempty" yaml:"name,omitempty"`
+	Permission RepoPermission `json:"permission,omitempty" yaml:"permission,omitempty"`
+	Reason     string         `json:"reason,omitempty" yaml:"reason,omitempty"`
 }
 
 // RepoPermission is the type used for team permissions on repos
 type RepoPermission string
 
 const (
-	RepoPermissionRead  RepoPermission = "pull"  // Team read permission on a repo
-	RepoPermissionWrite RepoPermission = "push"  // Team write permission on a repo
-	RepoPermissionAdmin RepoPermission = "admin" // Team admin permission on a repo
+	RepoPermissionRead     RepoPermission = "pull"     // Team read permission on a repo
+	RepoPermissionTriage   RepoPermission = "triage"   // Team triage permission on a repo
+	RepoPermissionWrite    RepoPermission = "push"     // Team write permission on a repo
+	RepoPermissionMaintain RepoPermission = "maintain" // Team maintain permission on a repo
+	RepoPermissionAdmin    RepoPermission = "admin"    // Team admin permission on a repo
 )
 
-// Repo represents a GitHub repository.
+// repoPermissionAliases maps the names by which permissions may be specified to RepoPermissions.
+var repoPermissionAliases = map[string]RepoPermission{
+	"pull":     RepoPermissionRead,
+	"read":     RepoPermissionRead,
+	"triage":   RepoPermissionTriage,
+	"push":     RepoPermissionWrite,
+	"write":    RepoPermissionWrite,
+	"maintain": RepoPermissionMaintain,
+	"admin":    RepoPermissionAdmin,
+}
+
+// ParseRepoPermission returns the RepoPermission with the specified name. Both the GitHub API
+// names (e.g. "pull") and the GitHub UI names (e.g. "read") of permissions are accepted.
+func ParseRepoPermission(name string) (RepoPermission, error) {
+	if p, ok := repoPermissionAliases[strings.ToLower(name)]; ok {
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown repository permission '%s'", name)
+}
+
+// RepoVisibility is the type used for the visibility of repos
+type RepoVisibility string
+
+const (
+	RepoVisibilityPublic   RepoVisibility = "public"   // Repo is visible to everyone
+	RepoVisibilityPrivate  RepoVisibility = "private"  // Repo is visible only to those granted access
+	RepoVisibilityInternal RepoVisibility = "internal" // Repo is visible to all members of the enterprise
+)
+
+// Repo represents a GitHub repository. Its direct collaborators are only populated when requested as
+// fetching them costs an API call per repo.
 type Repo struct {
-	Name   string            `json:"name,omitempty" yaml:"name,omitempty"`
-	Topics []string          `json:"topics,omitempty" yaml:"topics,omitempty"`
-	Teams  []*TeamPermission `json:"teams,omitempty" yaml:"teams,omitempty"`
+	Name            string                    `json:"name,omitempty" yaml:"name,omitempty"`
+	Visibility      RepoVisibility            `json:"visibility,omitempty" yaml:"visibility,omitempty"`
+	Archived        bool                      `json:"archived,omitempty" yaml:"archived,omitempty"`
+	DefaultBranch   string                    `json:"defaultBranch,omitempty" yaml:"defaultBranch,omitempty"`
+	PrimaryLanguage string                    `json:"primaryLanguage,omitempty" yaml:"primaryLanguage,omitempty"`
+	CreatedAt       *time.Time                `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+	PushedAt        *time.Time                `json:"pushedAt,omitempty" yaml:"pushedAt,omitempty"`
+	Topics          []string                  `json:"topics,omitempty" yaml:"topics,omitempty"`
+	Teams           []*TeamPermission         `json:"teams,omitempty" yaml:"teams,omitempty"`
+	Collaborators   []*CollaboratorPermission `json:"collaborators,omitempty" yaml:"collaborators,omitempty"`
 }
 
 // TeamPermission represents a team's access permissions to a repository.
@@ -43,3 +117,9 @@ type TeamPermission struct {
 	TeamName   string
 	Permission RepoPermission
 }
+
+// CollaboratorPermission represents a direct collaborator's access permissions to a repository.
+type CollaboratorPermission struct {
+	Login      string
+	Permission RepoPermission
+}
@@ -21,11 +21,30 @@ type Codec interface {
 
 // Config provides the application configuration.
 type Config struct {
-	Name              string        // Name of this application
-	Version           string        // Version of this application
-	MetricsInterval   time.Duration // Interval at which metrics are reported to CloudWatch
-	GitHubAuditBucket string        // Name of the bucket where GitHub audit data is stored
-	QueueURL          string        // URL of the SQS queue used for asynchronous processing
+	Name              string         // Name of this application
+	Version           string         // Version of this application
+	MetricsInterval   time.Duration  // Interval at which metrics are reported to CloudWatch
+	GitHubAuditBucket string         // Name of the bucket where GitHub audit data is stored
+	QueueBackend      string         // Implementation of the queue used for asynchronous processing (sqs, memory or file)
+	QueueURL          string         // URL of the SQS queue used for asynchronous processing
+	QueueDir          string         // Directory holding the messages of the file queue
+	Workers           int            // Number of messages processed in parallel
+	CoalesceWindow    time.Duration  // Time bursts of events for the same target are collected before it's recomputed once
+	MaxAttempts       int            // Number of times a message is processed before it's dead-lettered
+	RetryBackoff      time.Duration  // Delay before a failed message is first retried, doubled for each further attempt
+	DeadLetterBucket  string         // Name of the bucket where dead-lettered messages are stored by the sqs queue
+	PayloadBucket     string         // Name of the bucket where payloads too large for the sqs queue are stored
+	PayloadThreshold  int            // Size in bytes above which messages have their payloads stored in the payload bucket
+	ArchiveBucket     string         // Name of the bucket where the events delivered to the hook are archived for the sqs queue
+	MaxUnownedRepos   int            // Maximum number of repos without an admin team (negative disables the check)
+	TopicSchemes      []*TopicScheme // Schemes used to derive repo topics from teams (empty for the defaults)
+	OrgRepo           string         // Full name of the repo containing the org configuration (empty to disable)
+	OrgRepoBranch     string         // Branch of the org repo that is applied when pushed to
+	OrgRepoDir        string         // Directory within the org repo that contains the org.yaml file
+	ApproverTeam      string         // Team whose members may apply org repo pull requests (empty to disable)
+	DefaultRepoTeams  []*RepoTeam    // Teams granted permissions on repos created in or transferred to the org
+	ArchivedReadOnly  bool           // Whether write access is stripped from teams when repos are archived
+	EnforcedOrgs      []string       // Orgs whose teams are reverted to the org repo when changed outside of orgbot
 
 	GitHubAppConfig
 }
@@ -0,0 +1,59 @@
+package orgbot
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ParseRepoTeams parses the specified JSON array of repo teams, such as the default teams granted
+// permissions on new repos, canonicalising their permissions.
+func ParseRepoTeams(buf []byte) ([]*RepoTeam, error) {
+	var teams []*RepoTeam
+	if err := json.Unmarshal(buf, &teams); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal repo teams")
+	}
+
+	for _, t := range teams {
+		if t.Name == "" {
+			return nil, errors.New("repo teams must have a name")
+		}
+
+		p, err := ParseRepoPermission(string(t.Permission))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid permission for repo team '%s'", t.Name)
+		}
+		t.Permission = p
+	}
+
+	return teams, nil
+}
+
+// NewRepoDefaultsChangeSet returns a change set that grants the specified default teams their
+// permissions on the repo with the specified name.
+func NewRepoDefaultsChangeSet(repoName string, defaults []*RepoTeam) *RepoTeamsChangeSet {
+	changeSet := RepoTeamsChangeSet{OnlyRepos: []string{repoName}}
+	for _, t := range defaults {
+		changeSet.AddTeams = append(changeSet.AddTeams, &TeamPermission{TeamName: t.Name, Permission: t.Permission})
+	}
+	return &changeSet
+}
+
+// NewArchivedRepoChangeSet returns a change set that downgrades the teams with permissions on the
+// specified archived repo that allow writing to it, other than admin teams, to read permission.
+// Archived repos are read-only so write access only serves to allow them to be unarchived.
+func NewArchivedRepoChangeSet(r *Repo) *RepoTeamsChangeSet {
+	archived := true
+	changeSet := RepoTeamsChangeSet{
+		OnlyRepos: []string{r.Name},
+		Selector:  &RepoSelector{Archived: &archived},
+	}
+
+	for _, tp := range r.Teams {
+		if tp.Permission == RepoPermissionWrite || tp.Permission == RepoPermissionMaintain {
+			changeSet.AddTeams = append(changeSet.AddTeams, &TeamPermission{TeamName: tp.TeamName, Permission: RepoPermissionRead})
+		}
+	}
+
+	return &changeSet
+}
@@ -0,0 +1,54 @@
+package orgbot
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRepoTeams(t *testing.T) {
+	got, err := ParseRepoTeams([]byte(`[{"name": "Foo", "permission": "admin"}, {"name": "Bar", "permission": "read"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*RepoTeam{
+		{Name: "Foo", Permission: RepoPermissionAdmin},
+		{Name: "Bar", Permission: RepoPermissionRead},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	for _, bad := range []string{`{}`, `[{"permission": "admin"}]`, `[{"name": "Foo", "permission": "owner"}]`} {
+		if _, err := ParseRepoTeams([]byte(bad)); err == nil {
+			t.Errorf("expected error parsing %s", bad)
+		}
+	}
+}
+
+func TestNewArchivedRepoChangeSet(t *testing.T) {
+	got := NewArchivedRepoChangeSet(&Repo{
+		Name:     "repo1",
+		Archived: true,
+		Teams: []*TeamPermission{
+			{TeamName: "Foo", Permission: RepoPermissionAdmin},
+			{TeamName: "Bar", Permission: RepoPermissionMaintain},
+			{TeamName: "Baz", Permission: RepoPermissionWrite},
+			{TeamName: "Qux", Permission: RepoPermissionRead},
+		},
+	})
+
+	archived := true
+	want := &RepoTeamsChangeSet{
+		AddTeams: []*TeamPermission{
+			{TeamName: "Bar", Permission: RepoPermissionRead},
+			{TeamName: "Baz", Permission: RepoPermissionRead},
+		},
+		OnlyRepos: []string{"repo1"},
+		Selector:  &RepoSelector{Archived: &archived},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,174 @@
+package orgbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RepoOwnershipReport describes how well the repos within an org are covered by admin teams. The
+// admin teams of a repo are its owners and are surfaced to other tooling through admin topics.
+type RepoOwnershipReport struct {
+	ReposChecked int `json:"reposChecked" yaml:"reposChecked"`
+
+	// Unowned lists the names of repos that have no admin team.
+	Unowned []string `json:"unowned,omitempty" yaml:"unowned,omitempty"`
+
+	// MultipleOwners maps the names of repos that have more than one admin team to those teams.
+	MultipleOwners map[string][]string `json:"multipleOwners,omitempty" yaml:"multipleOwners,omitempty"`
+
+	// CollaboratorAdmins maps the names of repos that have individual collaborators with admin
+	// permission to the logins of those collaborators.
+	CollaboratorAdmins map[string][]string `json:"collaboratorAdmins,omitempty" yaml:"collaboratorAdmins,omitempty"`
+}
+
+// RepoOwnership returns a RepoOwnershipReport for the repos in the specified organisation that are
+// selected by the specified selector (or all repositories if it is nil). Archived repos are excluded
+// unless the selector explicitly asks for them as they can no longer be changed.
+func RepoOwnership(ctx context.Context, plat Platform, orgName string, selector *RepoSelector) (*RepoOwnershipReport, error) {
+	selected, err := newRepoFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	skipArchived := selector == nil || selector.Archived == nil
+
+	report := RepoOwnershipReport{
+		MultipleOwners:     map[string][]string{},
+		CollaboratorAdmins: map[string][]string{},
+	}
+
+	if err := plat.GitHubService().WalkRepos(ctx, orgName, func(r *Repo) error {
+		if (skipArchived && r.Archived) || !selected(r) {
+			return nil
+		}
+
+		report.ReposChecked++
+
+		adminTeams := repoAdminTeams(r)
+		switch {
+		case len(adminTeams) == 0:
+			report.Unowned = append(report.Unowned, r.Name)
+		case len(adminTeams) > 1:
+			report.MultipleOwners[r.Name] = adminTeams
+		}
+
+		collaborators, err := plat.GitHubService().ListRepoCollaborators(ctx, orgName, r.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range collaborators {
+			if c.Permission == RepoPermissionAdmin {
+				report.CollaboratorAdmins[r.Name] = append(report.CollaboratorAdmins[r.Name], c.Login)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(report.Unowned)
+
+	return &report, nil
+}
+
+// repoAdminTeams returns the sorted names of the teams that have admin permission on the specified repo.
+func repoAdminTeams(r *Repo) []string {
+	var adminTeams []string
+	for _, tp := range r.Teams {
+		if tp.Permission == RepoPermissionAdmin {
+			adminTeams = append(adminTeams, tp.TeamName)
+		}
+	}
+
+	sort.Strings(adminTeams)
+
+	return adminTeams
+}
+
+// UnownedReposError is the error returned when the number of repos without an admin team exceeds the
+// configured maximum.
+type UnownedReposError struct {
+	// MaxUnowned is the maximum number of unowned repos that is tolerated.
+	MaxUnowned int
+
+	// Violations is an array of the names of repos that have no admin team.
+	Violations []string
+}
+
+// Description implements RuleError.
+func (e *UnownedReposError) Description() string {
+	return fmt.Sprintf("No more than %d repos may be without an admin team", e.MaxUnowned)
+}
+
+// ConstraintViolations implements RuleError.
+func (e *UnownedReposError) ConstraintViolations() string {
+	return quoteJoin(e.Violations)
+}
+
+// Link implements RuleError.
+func (e *UnownedReposError) Link() string {
+	return docURL + "#unowned-repos"
+}
+
+// Error implements RuleError.
+func (e *UnownedReposError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
+}
+
+// unownedReposRule provides an implementation of RepoRule that verifies that the number of
+// unarchived repos in the org without an admin team does not exceed a maximum.
+type unownedReposRule struct {
+	gitHubService GitHubService
+	maxUnowned    int
+}
+
+// NewUnownedReposRule returns a RepoRule that fails when more than maxUnowned unarchived repos in
+// the org have no admin team. It is not part of the default rules returned by NewRuleEngine.
+func NewUnownedReposRule(gitHubService GitHubService, maxUnowned int) RepoRule {
+	return &unownedReposRule{gitHubService: gitHubService, maxUnowned: maxUnowned}
+}
+
+// Run implements RepoRule. Repos listed in the manifest are judged by the teams that the manifest
+// gives them rather than the teams they currently have.
+func (r *unownedReposRule) Run(ctx context.Context, manifest *RepoManifest) error {
+	specs := map[string]*RepoSpec{}
+	for _, spec := range manifest.Repos {
+		specs[strings.ToLower(spec.Name)] = spec
+	}
+
+	var unowned []string
+	if err := r.gitHubService.WalkRepos(ctx, manifest.Name, func(repo *Repo) error {
+		if repo.Archived {
+			return nil
+		}
+
+		owned := len(repoAdminTeams(repo)) > 0
+		if spec, ok := specs[strings.ToLower(repo.Name)]; ok {
+			// Invalid permissions are reported by repoTeamPermissionsRule
+			owned = false
+			for _, t := range spec.Teams {
+				if p, err := ParseRepoPermission(string(t.Permission)); err == nil && p == RepoPermissionAdmin {
+					owned = true
+				}
+			}
+		}
+
+		if !owned {
+			unowned = append(unowned, repo.Name)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(unowned) > r.maxUnowned {
+		sort.Strings(unowned)
+		return &UnownedReposError{MaxUnowned: r.maxUnowned, Violations: unowned}
+	}
+
+	return nil
+}
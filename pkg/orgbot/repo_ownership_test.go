@@ -0,0 +1,140 @@
+package orgbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+// ownershipTestRepos returns repos with a mix of ownership problems.
+func ownershipTestRepos() []*Repo {
+	return []*Repo{
+		{
+			Name: "owned",
+			Teams: []*TeamPermission{
+				{TeamName: "Foo", Permission: RepoPermissionAdmin},
+				{TeamName: "Bar", Permission: RepoPermissionWrite},
+			},
+		},
+		{
+			Name: "shared",
+			Teams: []*TeamPermission{
+				{TeamName: "Foo", Permission: RepoPermissionAdmin},
+				{TeamName: "Bar", Permission: RepoPermissionAdmin},
+			},
+		},
+		{
+			Name: "orphan-b",
+			Teams: []*TeamPermission{
+				{TeamName: "Bar", Permission: RepoPermissionMaintain},
+			},
+		},
+		{
+			Name: "orphan-a",
+		},
+		{
+			Name:     "archived",
+			Archived: true,
+		},
+	}
+}
+
+// expectWalkRepos configures the specified MockGitHubService to walk over the specified repos.
+func expectWalkRepos(gitHubService *MockGitHubService, repos []*Repo) {
+	gitHubService.
+		EXPECT().
+		WalkRepos(gomock.Any(), "SEEK-Jobs", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName string, walkFn WalkReposFunc) error {
+			for _, r := range repos {
+				if err := walkFn(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func TestRepoOwnership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	expectWalkRepos(plat.MockGitHubService, ownershipTestRepos())
+
+	// Collaborators are only listed for the repos that are checked
+	for _, repoName := range []string{"owned", "orphan-b", "orphan-a"} {
+		plat.MockGitHubService.
+			EXPECT().
+			ListRepoCollaborators(gomock.Any(), "SEEK-Jobs", repoName).
+			Return(nil, nil)
+	}
+	plat.MockGitHubService.
+		EXPECT().
+		ListRepoCollaborators(gomock.Any(), "SEEK-Jobs", "shared").
+		Return([]*CollaboratorPermission{
+			{Login: "jbloggs", Permission: RepoPermissionAdmin},
+			{Login: "jsmith", Permission: RepoPermissionWrite},
+		}, nil)
+
+	report, err := RepoOwnership(ctx, plat, "SEEK-Jobs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &RepoOwnershipReport{
+		ReposChecked: 4,
+		Unowned:      []string{"orphan-a", "orphan-b"},
+		MultipleOwners: map[string][]string{
+			"shared": {"Bar", "Foo"},
+		},
+		CollaboratorAdmins: map[string][]string{
+			"shared": {"jbloggs"},
+		},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestUnownedReposRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// The manifest gives orphan-b an admin team so only orphan-a remains unowned
+	manifest := &RepoManifest{
+		Name: "SEEK-Jobs",
+		Repos: []*RepoSpec{
+			{
+				Name: "orphan-b",
+				Teams: []*RepoTeam{
+					{Name: "Bar", Permission: RepoPermissionAdmin},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		maxUnowned int
+		wantErr    error
+	}{
+		{maxUnowned: 1, wantErr: nil},
+		{maxUnowned: 0, wantErr: &UnownedReposError{MaxUnowned: 0, Violations: []string{"orphan-a"}}},
+	}
+
+	for _, test := range tests {
+		gitHubService := NewMockGitHubService(ctrl)
+		expectWalkRepos(gitHubService, ownershipTestRepos())
+
+		rule := NewUnownedReposRule(gitHubService, test.maxUnowned)
+		err := rule.Run(ctx, manifest)
+		if diff := cmp.Diff(test.wantErr, err); diff != "" {
+			t.Errorf("Max unowned %d: (-want +got)\n%s", test.maxUnowned, diff)
+		}
+	}
+}
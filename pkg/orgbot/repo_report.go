@@ -0,0 +1,64 @@
+package orgbot
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RepoOutcome is the type used to describe what a bulk repo operation did to a single repo.
+type RepoOutcome string
+
+const (
+	RepoOutcomeChanged RepoOutcome = "changed" // The repo was changed
+	RepoOutcomeSkipped RepoOutcome = "skipped" // The repo was deliberately left untouched
+	RepoOutcomeFailed  RepoOutcome = "failed"  // The repo could not be changed
+)
+
+// RepoReport describes the outcome of a bulk repo operation for a single repo.
+type RepoReport struct {
+	Repo    string      `json:"repo" yaml:"repo"`
+	Outcome RepoOutcome `json:"outcome" yaml:"outcome"`
+	Changes []string    `json:"changes,omitempty" yaml:"changes,omitempty"` // Changes made when the outcome is changed
+	Reason  string      `json:"reason,omitempty" yaml:"reason,omitempty"`   // Reason the repo was skipped
+	Error   string      `json:"error,omitempty" yaml:"error,omitempty"`     // Error encountered when the outcome is failed
+}
+
+// newChangedRepoReport returns a RepoReport for the specified repo that has the changed outcome if
+// any changes were made, otherwise nil.
+func newChangedRepoReport(repoName string, changes []string) *RepoReport {
+	if len(changes) == 0 {
+		return nil
+	}
+	return &RepoReport{Repo: repoName, Outcome: RepoOutcomeChanged, Changes: changes}
+}
+
+// newSkippedRepoReport returns a RepoReport for the specified repo that has the skipped outcome.
+func newSkippedRepoReport(repoName string, reason string) *RepoReport {
+	return &RepoReport{Repo: repoName, Outcome: RepoOutcomeSkipped, Reason: reason}
+}
+
+// newFailedRepoReport returns a RepoReport for the specified repo that has the failed outcome.
+// Changes that were made before the failure occurred are retained.
+func newFailedRepoReport(repoName string, changes []string, err error) *RepoReport {
+	return &RepoReport{Repo: repoName, Outcome: RepoOutcomeFailed, Changes: changes, Error: err.Error()}
+}
+
+// topicChanges returns a description of each topic that was added or removed when changing
+// the topics of a repo from have to want.
+func topicChanges(have []string, want []string) []string {
+	haveTopics := newStringSet(have)
+	wantTopics := newStringSet(want)
+
+	var changes []string
+	for _, t := range stringSetToSlice(wantTopics.Difference(haveTopics)) {
+		changes = append(changes, fmt.Sprintf("added topic '%s'", t))
+	}
+	for _, t := range stringSetToSlice(haveTopics.Difference(wantTopics)) {
+		changes = append(changes, fmt.Sprintf("removed topic '%s'", t))
+	}
+
+	// Sort the changes so that they can be predictably tested
+	sort.Strings(changes)
+
+	return changes
+}
@@ -0,0 +1,288 @@
+package orgbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	set "github.com/deckarep/golang-set"
+)
+
+// RepoNamesUniqueError is the error returned when one or more repos are listed more than once.
+type RepoNamesUniqueError struct {
+	// Violations is an array of repo names that violate the constraint.
+	Violations []string
+}
+
+// Description implements RuleError.
+func (e *RepoNamesUniqueError) Description() string {
+	return "Repos must not be listed more than once"
+}
+
+// ConstraintViolations implements RuleError.
+func (e *RepoNamesUniqueError) ConstraintViolations() string {
+	return quoteJoin(e.Violations)
+}
+
+// Link implements RuleError.
+func (e *RepoNamesUniqueError) Link() string {
+	return docURL + "#duplicate-repos"
+}
+
+// Error implements RuleError.
+func (e *RepoNamesUniqueError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
+}
+
+// repoNamesUniqueRule provides an implementation of RepoRule that verifies that
+// each repo is only listed once within a manifest.
+type repoNamesUniqueRule struct{}
+
+// Run implements RepoRule.
+func (r *repoNamesUniqueRule) Run(ctx context.Context, manifest *RepoManifest) error {
+	var violations []string
+	unique := set.NewSet()
+
+	for _, repo := range manifest.Repos {
+		// Repo names are case-insensitive within GitHub
+		if !unique.Add(strings.ToLower(repo.Name)) {
+			violations = append(violations, repo.Name)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &RepoNamesUniqueError{Violations: violations}
+	}
+
+	return nil
+}
+
+// RepoTeamsUniqueError is the error returned when teams within a repo are not unique.
+type RepoTeamsUniqueError struct {
+	// Violations is a map of repo names to team names within those repos that violate the constraint.
+	Violations map[string][]string
+}
+
+// Description implements RuleError.
+func (e *RepoTeamsUniqueError) Description() string {
+	return "Teams must not be repeated within a repo"
+}
+
+// ConstraintViolations implements RuleError.
+func (e *RepoTeamsUniqueError) ConstraintViolations() string {
+	var msgs []string
+	for r, teams := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("'%s': %s", r, quoteJoin(teams)))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Link implements RuleError.
+func (e *RepoTeamsUniqueError) Link() string {
+	return docURL + "#duplicate-repo-teams"
+}
+
+// Error implements RuleError.
+func (e *RepoTeamsUniqueError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
+}
+
+// repoTeamsUniqueRule provides an implementation of RepoRule that verifies that
+// teams within a repo are not repeated.
+type repoTeamsUniqueRule struct{}
+
+// Run implements RepoRule.
+func (r *repoTeamsUniqueRule) Run(ctx context.Context, manifest *RepoManifest) error {
+	violations := map[string][]string{}
+
+	for _, repo := range manifest.Repos {
+		unique := set.NewSet()
+		for _, t := range repo.Teams {
+			if !unique.Add(t.Name) {
+				violations[repo.Name] = append(violations[repo.Name], t.Name)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &RepoTeamsUniqueError{Violations: violations}
+	}
+
+	return nil
+}
+
+// RepoTeamPermissionsError is the error returned when teams are given unknown permissions on repos.
+type RepoTeamPermissionsError struct {
+	// Violations is a map of repo names to team names within those repos that violate the constraint.
+	Violations map[string][]string
+}
+
+// Description implements RuleError.
+func (e *RepoTeamPermissionsError) Description() string {
+	return "Teams must be given one of the 'read', 'triage', 'write', 'maintain' or 'admin' permissions"
+}
+
+// ConstraintViolations implements RuleError.
+func (e *RepoTeamPermissionsError) ConstraintViolations() string {
+	var msgs []string
+	for r, teams := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("'%s': %s", r, quoteJoin(teams)))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Link implements RuleError.
+func (e *RepoTeamPermissionsError) Link() string {
+	return docURL + "#repo-team-permissions"
+}
+
+// Error implements RuleError.
+func (e *RepoTeamPermissionsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
+}
+
+// repoTeamPermissionsRule provides an implementation of RepoRule that verifies that
+// teams are given known permissions on repos.
+type repoTeamPermissionsRule struct{}
+
+// Run implements RepoRule.
+func (r *repoTeamPermissionsRule) Run(ctx context.Context, manifest *RepoManifest) error {
+	violations := map[string][]string{}
+
+	for _, repo := range manifest.Repos {
+		for _, t := range repo.Teams {
+			if _, err := ParseRepoPermission(string(t.Permission)); err != nil {
+				violations[repo.Name] = append(violations[repo.Name], t.Name)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &RepoTeamPermissionsError{Violations: violations}
+	}
+
+	return nil
+}
+
+// UnknownRepoTeamsError is the error returned when one or more teams listed on repos don't exist.
+type UnknownRepoTeamsError struct {
+	// Violations is a map of repo names to team names within those repos that violate the constraint.
+	Violations map[string][]string
+}
+
+// Description implements RuleError.
+func (e *UnknownRepoTeamsError) Description() string {
+	return "Teams listed on repos must exist in the GitHub org"
+}
+
+// ConstraintViolations implements RuleError.
+func (e *UnknownRepoTeamsError) ConstraintViolations() string {
+	var msgs []string
+	for r, teams := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("'%s': %s", r, quoteJoin(teams)))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Link implements RuleError.
+func (e *UnknownRepoTeamsError) Link() string {
+	return docURL + "#unknown-repo-teams"
+}
+
+// Error implements RuleError.
+func (e *UnknownRepoTeamsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
+}
+
+// unknownRepoTeamsRule provides an implementation of RepoRule that verifies that
+// all teams listed on repos exist within the GitHub org.
+type unknownRepoTeamsRule struct {
+	gitHubService GitHubService
+}
+
+// Run implements RepoRule.
+func (r *unknownRepoTeamsRule) Run(ctx context.Context, manifest *RepoManifest) error {
+	teams, err := r.gitHubService.ListTeams(ctx, manifest.Name)
+	if err != nil {
+		return err
+	}
+
+	haveTeamNames := set.NewSet()
+	for _, t := range teams {
+		haveTeamNames.Add(t.Name)
+	}
+
+	violations := map[string][]string{}
+	for _, repo := range manifest.Repos {
+		for _, t := range repo.Teams {
+			if !haveTeamNames.Contains(t.Name) {
+				violations[repo.Name] = append(violations[repo.Name], t.Name)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &UnknownRepoTeamsError{Violations: violations}
+	}
+
+	return nil
+}
+
+// ManagedRepoTopicsError is the error returned when topics that are managed by orgbot are listed on repos.
+type ManagedRepoTopicsError struct {
+	// Prefixes is an array of the prefixes of the topics that are managed by orgbot.
+	Prefixes []string
+
+	// Violations is a map of repo names to topics within those repos that violate the constraint.
+	Violations map[string][]string
+}
+
+// Description implements RuleError.
+func (e *ManagedRepoTopicsError) Description() string {
+	return fmt.Sprintf("Topics starting with %s are derived from the repo's teams and must not be listed", quoteJoin(e.Prefixes))
+}
+
+// ConstraintViolations implements RuleError.
+func (e *ManagedRepoTopicsError) ConstraintViolations() string {
+	var msgs []string
+	for r, topics := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("'%s': %s", r, quoteJoin(topics)))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Link implements RuleError.
+func (e *ManagedRepoTopicsError) Link() string {
+	return docURL + "#managed-repo-topics"
+}
+
+// Error implements RuleError.
+func (e *ManagedRepoTopicsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
+}
+
+// managedRepoTopicsRule provides an implementation of RepoRule that verifies that
+// repos don't list the topics that are managed by orgbot's topic schemes.
+type managedRepoTopicsRule struct {
+	schemes []*TopicScheme
+}
+
+// Run implements RepoRule.
+func (r *managedRepoTopicsRule) Run(ctx context.Context, manifest *RepoManifest) error {
+	rectifier := newTopicRectifierForTeams(r.schemes, nil)
+	violations := map[string][]string{}
+
+	for _, repo := range manifest.Repos {
+		for _, topic := range repo.Topics {
+			if rectifier.ownsTopic(topic) {
+				violations[repo.Name] = append(violations[repo.Name], topic)
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ManagedRepoTopicsError{Prefixes: rectifier.prefixes(), Violations: violations}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package orgbot
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// timeNow provides a means of overriding the current time in tests.
+	timeNow = time.Now
+)
+
+// RepoSelector describes the criteria used to select the repos that bulk repo operations
+// apply to. A repo is selected when it satisfies all of the criteria that have been specified;
+// criteria that are left empty select all repos.
+type RepoSelector struct {
+	NameGlobs       []string         // Glob patterns of which the repo name must match at least one, ignoring case
+	NameRegexps     []string         // Regular expressions of which the repo name must match at least one, ignoring case
+	WithTopics      []string         // Topics that must all be present on the repo
+	WithoutTopics   []string         // Topics that must all be absent from the repo
+	AdminTeams      []string         // Teams of which at least one must have admin permission on the repo
+	Visibilities    []RepoVisibility // Visibilities of which the repo must have one
+	Archived        *bool            // Archive state that the repo must have (nil selects both)
+	PushedWithin    time.Duration    // Maximum time since the repo was last pushed to
+	NotPushedWithin time.Duration    // Minimum time since the repo was last pushed to
+}
+
+// repoFilter is the type of the function that returns whether a repo has been selected.
+type repoFilter func(r *Repo) bool
+
+// newRepoFilter returns a repoFilter for the specified selector. A nil selector selects all repos.
+func newRepoFilter(selector *RepoSelector) (repoFilter, error) {
+	if selector == nil {
+		return func(r *Repo) bool { return true }, nil
+	}
+
+	// Validate the glob patterns up front as path.Match only reports bad patterns when used
+	for _, g := range selector.NameGlobs {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid repo name glob '%s'", g)
+		}
+	}
+
+	var nameRegexps []*regexp.Regexp
+	for _, re := range selector.NameRegexps {
+		// Repo names are case-insensitive on GitHub so they're matched like the globs
+		compiled, err := regexp.Compile("(?i)" + re)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid repo name regular expression '%s'", re)
+		}
+		nameRegexps = append(nameRegexps, compiled)
+	}
+
+	now := timeNow()
+
+	// nameSelected returns whether the repo name matches the name globs and regular expressions.
+	nameSelected := func(r *Repo) bool {
+		if len(selector.NameGlobs) == 0 && len(nameRegexps) == 0 {
+			return true
+		}
+
+		for _, g := range selector.NameGlobs {
+			if matched, _ := path.Match(strings.ToLower(g), strings.ToLower(r.Name)); matched {
+				return true
+			}
+		}
+
+		for _, re := range nameRegexps {
+			if re.MatchString(r.Name) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// topicsSelected returns whether the repo has all of the wanted topics and none of the unwanted ones.
+	topicsSelected := func(r *Repo) bool {
+		topics := newStringSet(r.Topics)
+		for _, t := range selector.WithTopics {
+			if !topics.Contains(t) {
+				return false
+			}
+		}
+
+		for _, t := range selector.WithoutTopics {
+			if topics.Contains(t) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	// adminTeamsSelected returns whether at least one of the admin teams administers the repo.
+	adminTeamsSelected := func(r *Repo) bool {
+		if len(selector.AdminTeams) == 0 {
+			return true
+		}
+
+		for _, tp := range r.Teams {
+			if tp.Permission != RepoPermissionAdmin {
+				continue
+			}
+
+			for _, tn := range selector.AdminTeams {
+				if strings.EqualFold(tp.TeamName, tn) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	// visibilitySelected returns whether the repo has one of the wanted visibilities.
+	visibilitySelected := func(r *Repo) bool {
+		if len(selector.Visibilities) == 0 {
+			return true
+		}
+
+		for _, v := range selector.Visibilities {
+			if r.Visibility == v {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// pushedSelected returns whether the repo was last pushed to within the wanted time frame. Repos
+	// that have never been pushed to are treated as if they were pushed to when they were created.
+	pushedSelected := func(r *Repo) bool {
+		if selector.PushedWithin == 0 && selector.NotPushedWithin == 0 {
+			return true
+		}
+
+		pushedAt := r.PushedAt
+		if pushedAt == nil {
+			pushedAt = r.CreatedAt
+		}
+		if pushedAt == nil {
+			return false
+		}
+
+		age := now.Sub(*pushedAt)
+		if selector.PushedWithin != 0 && age > selector.PushedWithin {
+			return false
+		}
+
+		if selector.NotPushedWithin != 0 && age < selector.NotPushedWithin {
+			return false
+		}
+
+		return true
+	}
+
+	return func(r *Repo) bool {
+		if selector.Archived != nil && r.Archived != *selector.Archived {
+			return false
+		}
+
+		return nameSelected(r) &&
+			topicsSelected(r) &&
+			adminTeamsSelected(r) &&
+			visibilitySelected(r) &&
+			pushedSelected(r)
+	}, nil
+}
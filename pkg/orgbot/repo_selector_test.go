@@ -0,0 +1,137 @@
+package orgbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRepoSelector(t *testing.T) {
+	now := time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	daysAgo := func(days int) *time.Time {
+		t := now.Add(-time.Duration(days) * 24 * time.Hour)
+		return &t
+	}
+
+	repos := []*Repo{
+		{
+			Name:       "payments-api",
+			Visibility: RepoVisibilityPrivate,
+			PushedAt:   daysAgo(1),
+			Topics:     []string{"admin-payments", "go"},
+			Teams: []*TeamPermission{
+				{TeamName: "Payments", Permission: RepoPermissionAdmin},
+			},
+		},
+		{
+			Name:       "payments-infra",
+			Visibility: RepoVisibilityInternal,
+			PushedAt:   daysAgo(100),
+			Topics:     []string{"admin-payments"},
+			Teams: []*TeamPermission{
+				{TeamName: "Payments", Permission: RepoPermissionAdmin},
+				{TeamName: "Platform", Permission: RepoPermissionWrite},
+			},
+		},
+		{
+			Name:       "search-infra",
+			Visibility: RepoVisibilityPublic,
+			Archived:   true,
+			CreatedAt:  daysAgo(400),
+			Teams: []*TeamPermission{
+				{TeamName: "Platform", Permission: RepoPermissionAdmin},
+			},
+		},
+	}
+
+	archived := true
+
+	tests := []struct {
+		name      string
+		selector  *RepoSelector
+		wantNames []string
+	}{
+		{
+			name:      "Nil selector",
+			selector:  nil,
+			wantNames: []string{"payments-api", "payments-infra", "search-infra"},
+		},
+		{
+			name:      "Name glob",
+			selector:  &RepoSelector{NameGlobs: []string{"*-INFRA"}},
+			wantNames: []string{"payments-infra", "search-infra"},
+		},
+		{
+			name:      "Name glob or regular expression",
+			selector:  &RepoSelector{NameGlobs: []string{"search-*"}, NameRegexps: []string{`-api$`}},
+			wantNames: []string{"payments-api", "search-infra"},
+		},
+		{
+			name:      "Name regular expression ignores case",
+			selector:  &RepoSelector{NameRegexps: []string{`^PAYMENTS-`}},
+			wantNames: []string{"payments-api", "payments-infra"},
+		},
+		{
+			name:      "Topic present and absent",
+			selector:  &RepoSelector{WithTopics: []string{"admin-payments"}, WithoutTopics: []string{"go"}},
+			wantNames: []string{"payments-infra"},
+		},
+		{
+			name:      "Admin team",
+			selector:  &RepoSelector{AdminTeams: []string{"platform"}},
+			wantNames: []string{"search-infra"},
+		},
+		{
+			name:      "Visibility",
+			selector:  &RepoSelector{Visibilities: []RepoVisibility{RepoVisibilityPrivate, RepoVisibilityInternal}},
+			wantNames: []string{"payments-api", "payments-infra"},
+		},
+		{
+			name:      "Archived",
+			selector:  &RepoSelector{Archived: &archived},
+			wantNames: []string{"search-infra"},
+		},
+		{
+			name:      "Pushed within",
+			selector:  &RepoSelector{PushedWithin: 30 * 24 * time.Hour},
+			wantNames: []string{"payments-api"},
+		},
+		{
+			name:      "Not pushed within falls back to creation time",
+			selector:  &RepoSelector{NotPushedWithin: 365 * 24 * time.Hour},
+			wantNames: []string{"search-infra"},
+		},
+	}
+
+	for _, test := range tests {
+		selected, err := newRepoFilter(test.selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, r := range repos {
+			if selected(r) {
+				names = append(names, r.Name)
+			}
+		}
+
+		if diff := cmp.Diff(test.wantNames, names); diff != "" {
+			t.Errorf("Test case '%s': (-want +got)\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestRepoSelectorInvalidPatterns(t *testing.T) {
+	if _, err := newRepoFilter(&RepoSelector{NameGlobs: []string{"["}}); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+
+	if _, err := newRepoFilter(&RepoSelector{NameRegexps: []string{"("}}); err == nil {
+		t.Error("expected error for invalid regular expression")
+	}
+}
@@ -5,22 +5,33 @@ import (
 	"fmt"
 	"strings"
 
-	set "github.com/deckarep/golang-set"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
-// RepoTeamsChangeSet describes a set of changes to repo team permissions.
+// RepoTeamsChangeSet describes a set of changes to repo team permissions. Teams in AddTeams
+// that already have a different permission on a repo are updated to the specified permission.
+// Teams in RemoveTeams with an empty permission are removed regardless of their permission.
 type RepoTeamsChangeSet struct {
-	AddTeams     []*TeamPermission // Teams with associated permissions to be added
+	AddTeams     []*TeamPermission // Teams with associated permissions to be added or updated
 	RemoveTeams  []*TeamPermission // Teams with associated permissions to be removed
 	ExcludeRepos []string          // Names of repos to exclude from the change
 	OnlyRepos    []string          // Names of repos to limit the update to
+	Selector     *RepoSelector     // Criteria that repos must satisfy to be updated (archived repos are skipped unless selected)
+
+	// ContinueOnError records repos that fail to update in the result rather than aborting the update.
+	ContinueOnError bool
 }
 
-// ApplyOrgResult describes the complete set of operations taken by the UpdateRepoTeams function.
+// UpdateRepoTeamsResult describes the complete set of operations taken by the UpdateRepoTeams function.
 type UpdateRepoTeamsResult struct {
 	TeamPermissionsRemoved int `json:"teamPermissionsRemoved,omitempty" yaml:"teamPermissionsRemoved,omitempty"`
 	TeamPermissionsAdded   int `json:"teamPermissionsAdded,omitempty" yaml:"teamPermissionsAdded,omitempty"`
+	TeamPermissionsUpdated int `json:"teamPermissionsUpdated,omitempty" yaml:"teamPermissionsUpdated,omitempty"`
+	ReposFailed            int `json:"reposFailed,omitempty" yaml:"reposFailed,omitempty"`
+
+	// Repos describes the outcome for each repo that was changed, skipped or failed.
+	Repos []*RepoReport `json:"repos,omitempty" yaml:"repos,omitempty"`
 }
 
 // UpdateRepoTeams updates all repos in the org according to the specified change set.
@@ -42,14 +53,23 @@ func UpdateRepoTeams(ctx context.Context, plat Platform, orgName string, changeS
 		return nil, err
 	}
 
-	// teamPermissionExists returns whether the specified TeamPermission exists.
-	teamPermissionExists := func(haveTeamPermissions []*TeamPermission, wantTeamPermission *TeamPermission) bool {
+	selected, err := newRepoFilter(changeSet.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Archived repos are skipped unless the selector explicitly asks for them
+	skipArchived := changeSet.Selector == nil || changeSet.Selector.Archived == nil
+
+	// findTeamPermission returns the TeamPermission for the specified team name or nil if the team
+	// has no permissions.
+	findTeamPermission := func(haveTeamPermissions []*TeamPermission, teamName string) *TeamPermission {
 		for _, haveTeamPermission := range haveTeamPermissions {
-			if *haveTeamPermission == *wantTeamPermission {
-				return true
+			if haveTeamPermission.TeamName == teamName {
+				return haveTeamPermission
 			}
 		}
-		return false
+		return nil
 	}
 
 	// repoExcluded returns whether the specified repo should be excluded from the change.
@@ -65,42 +85,101 @@ func UpdateRepoTeams(ctx context.Context, plat Platform, orgName string, changeS
 	// Result set that we'll accumulate below
 	res := UpdateRepoTeamsResult{}
 
-	// walkFn is the function that we execute on each repository in the update list.
-	walkFn := func(r *Repo) error {
-		if repoExcluded(r) {
-			return nil // Skip
-		}
+	// updateRepo applies the change-set to the specified repo and returns a description of each
+	// change made. Changes made before any error occurred are returned alongside the error.
+	updateRepo := func(r *Repo) ([]string, error) {
+		var changes []string
 
-		// Calculate the set of teams currently assigned to the repo
-		haveTeams := set.NewSet()
-		for _, tp := range r.Teams {
-			haveTeams.Add(tp.TeamName)
-		}
+		// Teams that remain on the repo as the change-set is applied, so that a team that's removed and
+		// added back is re-added rather than being taken to already have its permission
+		teams := append([]*TeamPermission(nil), r.Teams...)
 
 		// Iterate over the teams that need to be removed, deleting each one from the repo
 		for _, tp := range changeSet.RemoveTeams {
-			if teamPermissionExists(r.Teams, tp) {
-				if err := plat.GitHubService().DeleteTeamRepoPermission(ctx, orgName, r.Name, teamsByName[tp.TeamName].ID); err != nil {
-					return err
-				}
+			have := findTeamPermission(teams, tp.TeamName)
+			if have == nil || (tp.Permission != "" && have.Permission != tp.Permission) {
+				continue
+			}
+
+			if err := plat.GitHubService().DeleteTeamRepoPermission(ctx, orgName, r.Name, teamsByName[tp.TeamName].ID); err != nil {
+				return changes, err
+			}
 
-				zerolog.Ctx(ctx).Debug().Msgf("Removed team '%s' from repository '%s' which had permission '%s'", tp.TeamName, r.Name, tp.Permission)
-				res.TeamPermissionsRemoved++
+			for i, t := range teams {
+				if t == have {
+					teams = append(teams[:i], teams[i+1:]...)
+					break
+				}
 			}
+
+			zerolog.Ctx(ctx).Debug().Msgf("Removed team '%s' from repository '%s' which had permission '%s'", tp.TeamName, r.Name, have.Permission)
+			changes = append(changes, fmt.Sprintf("removed team '%s' which had permission '%s'", tp.TeamName, have.Permission))
+			res.TeamPermissionsRemoved++
 		}
 
-		// Iterate over each of the teams that need to be added and add them to the repo with the specified permissions
+		// Iterate over each of the teams that need to be added and add them to the repo with the specified
+		// permissions. Teams that already have a different permission are upgraded or downgraded in place.
 		for _, tp := range changeSet.AddTeams {
-			if !teamPermissionExists(r.Teams, tp) {
-				if err := plat.GitHubService().AddTeamRepoPermission(ctx, orgName, r.Name, teamsByName[tp.TeamName].ID, tp.Permission); err != nil {
-					return err
-				}
+			have := findTeamPermission(teams, tp.TeamName)
+			if have != nil && have.Permission == tp.Permission {
+				continue
+			}
+
+			if err := plat.GitHubService().AddTeamRepoPermission(ctx, orgName, r.Name, teamsByName[tp.TeamName].ID, tp.Permission); err != nil {
+				return changes, err
+			}
 
+			if have != nil {
+				zerolog.Ctx(ctx).Debug().Msgf("Updated team '%s' on repository '%s' from permission '%s' to '%s'", tp.TeamName, r.Name, have.Permission, tp.Permission)
+				changes = append(changes, fmt.Sprintf("updated team '%s' from permission '%s' to '%s'", tp.TeamName, have.Permission, tp.Permission))
+				res.TeamPermissionsUpdated++
+			} else {
 				zerolog.Ctx(ctx).Debug().Msgf("Added team '%s' to repository '%s' with permission '%s'", tp.TeamName, r.Name, tp.Permission)
+				changes = append(changes, fmt.Sprintf("added team '%s' with permission '%s'", tp.TeamName, tp.Permission))
 				res.TeamPermissionsAdded++
 			}
 		}
 
+		return changes, nil
+	}
+
+	// repoFailed records the failure of the specified repo, returning the error if the walk should be aborted.
+	repoFailed := func(repoName string, changes []string, err error) error {
+		if !changeSet.ContinueOnError {
+			return err
+		}
+
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("Failed to update teams of repository '%s'", repoName)
+		res.ReposFailed++
+		res.Repos = append(res.Repos, newFailedRepoReport(repoName, changes, err))
+		return nil
+	}
+
+	// walkFn is the function that we execute on each repository in the update list.
+	walkFn := func(r *Repo) error {
+		if !selected(r) {
+			return nil // Skip
+		}
+
+		if skipArchived && r.Archived {
+			res.Repos = append(res.Repos, newSkippedRepoReport(r.Name, "repo is archived"))
+			return nil
+		}
+
+		if repoExcluded(r) {
+			res.Repos = append(res.Repos, newSkippedRepoReport(r.Name, "repo is excluded"))
+			return nil
+		}
+
+		changes, err := updateRepo(r)
+		if err != nil {
+			return repoFailed(r.Name, changes, err)
+		}
+
+		if report := newChangedRepoReport(r.Name, changes); report != nil {
+			res.Repos = append(res.Repos, report)
+		}
+
 		return nil
 	}
 
@@ -112,7 +191,10 @@ func UpdateRepoTeams(ctx context.Context, plat Platform, orgName string, changeS
 			for _, repoName := range changeSet.OnlyRepos {
 				repo, err := plat.GitHubService().RepoByName(ctx, orgName, repoName)
 				if err != nil {
-					return err
+					if err := repoFailed(repoName, nil, err); err != nil {
+						return err
+					}
+					continue
 				}
 
 				if err := walkFn(repo); err != nil {
@@ -133,7 +215,7 @@ func UpdateRepoTeams(ctx context.Context, plat Platform, orgName string, changeS
 	return &res, nil
 }
 
-// validateRepoTeamsChangeSet checks that the specified change-set contains valid teams.
+// validateRepoTeamsChangeSet checks that the specified change-set contains valid teams and permissions.
 func validateRepoTeamsChangeSet(changeSet *RepoTeamsChangeSet, teamsByName map[string]*GitHubTeam) error {
 	for _, tp := range append(changeSet.AddTeams, changeSet.RemoveTeams...) {
 		if _, ok := teamsByName[tp.TeamName]; !ok {
@@ -141,5 +223,11 @@ func validateRepoTeamsChangeSet(changeSet *RepoTeamsChangeSet, teamsByName map[s
 		}
 	}
 
+	for _, tp := range changeSet.AddTeams {
+		if _, err := ParseRepoPermission(string(tp.Permission)); err != nil {
+			return errors.Wrapf(err, "invalid permission for team '%s'", tp.TeamName)
+		}
+	}
+
 	return nil
 }
@@ -2,6 +2,7 @@ package orgbot
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -74,6 +75,18 @@ func TestUpdateRepoTeams(t *testing.T) {
 	wantRes := &UpdateRepoTeamsResult{
 		TeamPermissionsRemoved: 1,
 		TeamPermissionsAdded:   1,
+		Repos: []*RepoReport{
+			{
+				Repo:    "repo1",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{
+					"removed team 'Baz' which had permission 'pull'",
+					"added team 'Qux' with permission 'pull'",
+				},
+			},
+			{Repo: "repo2", Outcome: RepoOutcomeSkipped, Reason: "repo is excluded"},
+			{Repo: "repo4", Outcome: RepoOutcomeSkipped, Reason: "repo is archived"},
+		},
 	}
 
 	if diff := cmp.Diff(wantRes, res); diff != "" {
@@ -156,6 +169,262 @@ func TestUpdateRepoTeamsOnlyRepos(t *testing.T) {
 	wantRes := &UpdateRepoTeamsResult{
 		TeamPermissionsRemoved: 1,
 		TeamPermissionsAdded:   1,
+		Repos: []*RepoReport{
+			{
+				Repo:    "repo1",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{
+					"removed team 'Baz' which had permission 'pull'",
+					"added team 'Qux' with permission 'pull'",
+				},
+			},
+			{Repo: "repo2", Outcome: RepoOutcomeSkipped, Reason: "repo is excluded"},
+		},
+	}
+
+	if diff := cmp.Diff(wantRes, res); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestUpdateRepoTeamsChangePermissions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	haveRepos := testRepos()
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(ctx, "SEEK-Jobs").
+		Return([]*GitHubTeam{
+			{ID: 100, ParentID: 0, Name: "Foo"},
+			{ID: 101, ParentID: 100, Name: "Bar"},
+			{ID: 102, ParentID: 100, Name: "Baz"},
+			{ID: 103, ParentID: 100, Name: "Qux"},
+		}, nil)
+
+	plat.MockGitHubService.
+		EXPECT().
+		WalkRepos(ctx, "SEEK-Jobs", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName string, walkFn WalkReposFunc) error {
+			for _, r := range haveRepos {
+				if err := walkFn(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	// Expect team Baz to be removed from repo1 and repo3 regardless of its permission
+	plat.MockGitHubService.
+		EXPECT().
+		DeleteTeamRepoPermission(ctx, "SEEK-Jobs", "repo1", GitHubTeamID(102)).
+		Return(nil)
+	plat.MockGitHubService.
+		EXPECT().
+		DeleteTeamRepoPermission(ctx, "SEEK-Jobs", "repo3", GitHubTeamID(102)).
+		Return(nil)
+
+	// Expect team Qux to be added to repo1 and repo2, and upgraded in place on repo3
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo1", GitHubTeamID(103), RepoPermissionMaintain).
+		Return(nil)
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo2", GitHubTeamID(103), RepoPermissionMaintain).
+		Return(nil)
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo3", GitHubTeamID(103), RepoPermissionMaintain).
+		Return(nil)
+
+	changeSet := &RepoTeamsChangeSet{
+		RemoveTeams: []*TeamPermission{
+			{TeamName: "Baz"},
+		},
+		AddTeams: []*TeamPermission{
+			{TeamName: "Qux", Permission: RepoPermissionMaintain},
+		},
+	}
+
+	// Run the SUT
+	res, err := UpdateRepoTeams(ctx, plat, "SEEK-Jobs", changeSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRes := &UpdateRepoTeamsResult{
+		TeamPermissionsRemoved: 2,
+		TeamPermissionsAdded:   2,
+		TeamPermissionsUpdated: 1,
+		Repos: []*RepoReport{
+			{
+				Repo:    "repo1",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{
+					"removed team 'Baz' which had permission 'pull'",
+					"added team 'Qux' with permission 'maintain'",
+				},
+			},
+			{
+				Repo:    "repo2",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{"added team 'Qux' with permission 'maintain'"},
+			},
+			{
+				Repo:    "repo3",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{
+					"removed team 'Baz' which had permission 'admin'",
+					"updated team 'Qux' from permission 'pull' to 'maintain'",
+				},
+			},
+			{Repo: "repo4", Outcome: RepoOutcomeSkipped, Reason: "repo is archived"},
+		},
+	}
+
+	if diff := cmp.Diff(wantRes, res); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestUpdateRepoTeamsRemoveAndAddSameTeam(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	haveRepos := testRepos()
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(ctx, "SEEK-Jobs").
+		Return([]*GitHubTeam{
+			{ID: 100, ParentID: 0, Name: "Foo"},
+			{ID: 102, ParentID: 100, Name: "Baz"},
+		}, nil)
+
+	plat.MockGitHubService.
+		EXPECT().
+		WalkRepos(ctx, "SEEK-Jobs", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orgName string, walkFn WalkReposFunc) error {
+			for _, r := range haveRepos {
+				if err := walkFn(r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	// Expect team Baz to be removed and then added back with read permission, including on repo1
+	// where it already had read permission
+	for _, repoName := range []string{"repo1", "repo3"} {
+		gomock.InOrder(
+			plat.MockGitHubService.
+				EXPECT().
+				DeleteTeamRepoPermission(ctx, "SEEK-Jobs", repoName, GitHubTeamID(102)).
+				Return(nil),
+			plat.MockGitHubService.
+				EXPECT().
+				AddTeamRepoPermission(ctx, "SEEK-Jobs", repoName, GitHubTeamID(102), RepoPermissionRead).
+				Return(nil))
+	}
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo2", GitHubTeamID(102), RepoPermissionRead).
+		Return(nil)
+
+	changeSet := &RepoTeamsChangeSet{
+		RemoveTeams: []*TeamPermission{
+			{TeamName: "Baz"},
+		},
+		AddTeams: []*TeamPermission{
+			{TeamName: "Baz", Permission: RepoPermissionRead},
+		},
+	}
+
+	// Run the SUT
+	res, err := UpdateRepoTeams(ctx, plat, "SEEK-Jobs", changeSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.TeamPermissionsRemoved != 2 || res.TeamPermissionsAdded != 3 || res.TeamPermissionsUpdated != 0 {
+		t.Errorf("Expected 2 removals and 3 additions, got %+v", res)
+	}
+}
+
+func TestUpdateRepoTeamsContinueOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(ctx, "SEEK-Jobs").
+		Return([]*GitHubTeam{
+			{ID: 100, ParentID: 0, Name: "Foo"},
+			{ID: 103, ParentID: 100, Name: "Qux"},
+		}, nil)
+
+	// repo1 can't be retrieved, repo2 fails to update and repo3 is updated
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(ctx, "SEEK-Jobs", "repo1").
+		Return(nil, errors.New("not found"))
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(ctx, "SEEK-Jobs", "repo2").
+		Return(&Repo{Name: "repo2"}, nil)
+	plat.MockGitHubService.
+		EXPECT().
+		RepoByName(ctx, "SEEK-Jobs", "repo3").
+		Return(&Repo{Name: "repo3"}, nil)
+
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo2", GitHubTeamID(103), RepoPermissionRead).
+		Return(errors.New("forbidden"))
+	plat.MockGitHubService.
+		EXPECT().
+		AddTeamRepoPermission(ctx, "SEEK-Jobs", "repo3", GitHubTeamID(103), RepoPermissionRead).
+		Return(nil)
+
+	changeSet := &RepoTeamsChangeSet{
+		AddTeams: []*TeamPermission{
+			{TeamName: "Qux", Permission: RepoPermissionRead},
+		},
+		OnlyRepos: []string{
+			"repo1", "repo2", "repo3",
+		},
+		ContinueOnError: true,
+	}
+
+	// Run the SUT
+	res, err := UpdateRepoTeams(ctx, plat, "SEEK-Jobs", changeSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRes := &UpdateRepoTeamsResult{
+		TeamPermissionsAdded: 1,
+		ReposFailed:          2,
+		Repos: []*RepoReport{
+			{Repo: "repo1", Outcome: RepoOutcomeFailed, Error: "not found"},
+			{Repo: "repo2", Outcome: RepoOutcomeFailed, Error: "forbidden"},
+			{
+				Repo:    "repo3",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{"added team 'Qux' with permission 'pull'"},
+			},
+		},
 	}
 
 	if diff := cmp.Diff(wantRes, res); diff != "" {
@@ -2,10 +2,8 @@ package orgbot
 
 import (
 	"context"
-	"sort"
 	"strings"
 
-	set "github.com/deckarep/golang-set"
 	"github.com/rs/zerolog"
 )
 
@@ -14,18 +12,52 @@ import (
 const adminTopicPrefix = "admin-"
 const maxTopicLength = 35
 
+// UpdateAdminTopicsResult describes the complete set of operations taken by the admin topic functions.
 type UpdateAdminTopicsResult struct {
 	ReposUpdated int `json:"reposUpdated,omitempty" yaml:"reposUpdated,omitempty"`
+	ReposFailed  int `json:"reposFailed,omitempty" yaml:"reposFailed,omitempty"`
+
+	// Repos describes the outcome for each repo that was changed, skipped or failed.
+	Repos []*RepoReport `json:"repos,omitempty" yaml:"repos,omitempty"`
 }
 
-// UpdateAdminTopics updates all repositories in the specified organisation to include topics
-// that specify the administrator teams of the repository.
-func UpdateAdminTopics(ctx context.Context, plat Platform, orgName string) (*UpdateAdminTopicsResult, error) {
-	// Collect the repositories that need to be updated
+// UpdateAdminTopics updates the repositories in the specified organisation that are selected by the
+// specified selector (or all repositories if it is nil) to include topics that are derived from the
+// teams of the repository according to the configured topic schemes (by default, topics that specify
+// the administrator teams). Archived repositories are never updated as their topics are read-only. If
+// continueOnError is set, repositories that fail to update are recorded in the result rather than
+// aborting the update.
+func UpdateAdminTopics(ctx context.Context, plat Platform, orgName string, selector *RepoSelector, continueOnError bool) (*UpdateAdminTopicsResult, error) {
+	selected, err := newRepoFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	rectifier, err := newTopicRectifier(ctx, plat, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Result set that we'll accumulate below
+	res := UpdateAdminTopicsResult{}
+
+	// Collect the repositories that need to be updated along with the changes to their topics
 	var repos []*Repo
+	changes := map[string][]string{}
 	if err := plat.GitHubService().WalkRepos(ctx, orgName, func(r *Repo) error {
-		if rectifyRepoAdminTopics(r) {
+		if !selected(r) {
+			return nil
+		}
+
+		if r.Archived {
+			res.Repos = append(res.Repos, newSkippedRepoReport(r.Name, "repo is archived"))
+			return nil
+		}
+
+		haveTopics := r.Topics
+		if rectifier.rectify(r) {
 			repos = append(repos, r)
+			changes[r.Name] = topicChanges(haveTopics, r.Topics)
 		}
 		return nil
 	}); err != nil {
@@ -37,51 +69,21 @@ func UpdateAdminTopics(ctx context.Context, plat Platform, orgName string) (*Upd
 		zerolog.Ctx(ctx).Info().Msgf("Updating repo %s/%s to have topics %s", orgName, repo.Name, strings.Join(repo.Topics, ", "))
 		err := plat.GitHubService().UpdateRepoTopics(ctx, orgName, repo.Name, repo.Topics)
 		if err != nil {
-			return nil, err
-		}
-	}
-
-	return &UpdateAdminTopicsResult{
-		ReposUpdated: len(repos),
-	}, nil
-}
-
-// rectifyRepoAdminTopics updates the specified repo to include topics that specify the
-// administrator teams. It returns true if changes were made to the repo, otherwise false.
-func rectifyRepoAdminTopics(r *Repo) bool {
-	haveTopics := newStringSet(r.Topics)
-
-	// Create a set of topics that we want applied, starting with all of the existing
-	// topics minus any admin topics. We'll add the admin topics to the set below.
-	wantTopics := set.NewSet()
-	for _, topic := range r.Topics {
-		if !strings.HasPrefix(topic, adminTopicPrefix) {
-			wantTopics.Add(topic)
-		}
-	}
-
-	// Add the admin topics to the desired set
-	for _, team := range r.Teams {
-		if team.Permission == RepoPermissionAdmin {
-			adminTopic := adminTopicPrefix + normaliseName(team.TeamName)
-			if len(adminTopic) >= maxTopicLength {
-				adminTopic = adminTopic[:maxTopicLength-1]
+			if !continueOnError {
+				return nil, err
 			}
-			wantTopics.Add(adminTopic)
+
+			zerolog.Ctx(ctx).Warn().Err(err).Msgf("Failed to update topics of repository '%s'", repo.Name)
+			res.ReposFailed++
+			res.Repos = append(res.Repos, newFailedRepoReport(repo.Name, nil, err))
+			continue
 		}
-	}
 
-	// If the sets are equal then we don't need to do anything
-	if haveTopics.Equal(wantTopics) {
-		return false
+		res.ReposUpdated++
+		res.Repos = append(res.Repos, newChangedRepoReport(repo.Name, changes[repo.Name]))
 	}
 
-	r.Topics = stringSetToSlice(wantTopics)
-
-	// Sort the topics so that they can be predictably tested
-	sort.Strings(r.Topics)
-
-	return true
+	return &res, nil
 }
 
 // UpdateRepoAdminTopics updates the admin topics for the given repo
@@ -91,8 +93,13 @@ func UpdateRepoAdminTopics(ctx context.Context, plat Platform, orgName, repoName
 		return nil, err
 	}
 
+	rectifier, err := newTopicRectifier(ctx, plat, orgName)
+	if err != nil {
+		return nil, err
+	}
+
 	// Update repo topics
-	if rectifyRepoAdminTopics(repo) {
+	if rectifier.rectify(repo) {
 		// Update repo
 		err = plat.GitHubService().UpdateRepoTopics(ctx, orgName, repoName, repo.Topics)
 		if err != nil {
@@ -107,15 +114,22 @@ func UpdateRepoAdminTopics(ctx context.Context, plat Platform, orgName, repoName
 
 // UpdateTeamAdminTopics updates all the topics of all repos the given team has admin permission for
 func UpdateTeamAdminTopics(ctx context.Context, plat Platform, orgName string, teamID GitHubTeamID) (*UpdateAdminTopicsResult, error) {
-	updatedRepos := 0
-	err := plat.GitHubService().WalkReposByTeam(ctx, orgName, teamID, func(r *Repo) error {
-		if rectifyRepoAdminTopics(r) {
+	rectifier, err := newTopicRectifier(ctx, plat, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := UpdateAdminTopicsResult{}
+	err = plat.GitHubService().WalkReposByTeam(ctx, orgName, teamID, func(r *Repo) error {
+		haveTopics := r.Topics
+		if !r.Archived && rectifier.rectify(r) {
 			// Update repo
 			err := plat.GitHubService().UpdateRepoTopics(ctx, orgName, r.Name, r.Topics)
 			if err != nil {
 				return err
 			}
-			updatedRepos++
+			res.ReposUpdated++
+			res.Repos = append(res.Repos, newChangedRepoReport(r.Name, topicChanges(haveTopics, r.Topics)))
 		}
 
 		return nil
@@ -125,7 +139,40 @@ func UpdateTeamAdminTopics(ctx context.Context, plat Platform, orgName string, t
 		return nil, err
 	}
 
-	return &UpdateAdminTopicsResult{
-		ReposUpdated: updatedRepos,
-	}, nil
+	return &res, nil
+}
+
+// UpdateDeletedTeamTopics updates the repos in the org that still have topics derived from the
+// specified deleted team. As a deleted team no longer has any repos, all repos are walked over.
+func UpdateDeletedTeamTopics(ctx context.Context, plat Platform, orgName string, teamName string) (*UpdateAdminTopicsResult, error) {
+	rectifier, err := newTopicRectifier(ctx, plat, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	staleTopics := newStringSet(rectifier.teamTopics(teamName))
+
+	res := UpdateAdminTopicsResult{}
+	err = plat.GitHubService().WalkRepos(ctx, orgName, func(r *Repo) error {
+		haveTopics := r.Topics
+		if r.Archived || newStringSet(haveTopics).Intersect(staleTopics).Cardinality() == 0 {
+			return nil
+		}
+
+		if rectifier.rectify(r) {
+			if err := plat.GitHubService().UpdateRepoTopics(ctx, orgName, r.Name, r.Topics); err != nil {
+				return err
+			}
+			res.ReposUpdated++
+			res.Repos = append(res.Repos, newChangedRepoReport(r.Name, topicChanges(haveTopics, r.Topics)))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
 }
@@ -36,7 +36,31 @@ func testRepos() []*Repo {
 				{TeamName: "Qux", Permission: RepoPermissionRead},
 			},
 		},
+		{
+			Name:       "repo4",
+			Visibility: RepoVisibilityPrivate,
+			Archived:   true,
+			Topics:     []string{"old"}, // Archived repos are never updated
+			Teams: []*TeamPermission{
+				{TeamName: "Foo", Permission: RepoPermissionAdmin},
+				{TeamName: "Baz", Permission: RepoPermissionRead},
+			},
+		},
+	}
+}
+
+// expectListTeams expects the teams with the specified names to be listed, such as to disambiguate
+// truncated topics.
+func expectListTeams(gitHubService *MockGitHubService, teamNames ...string) {
+	var teams []*GitHubTeam
+	for i, name := range teamNames {
+		teams = append(teams, &GitHubTeam{ID: GitHubTeamID(i + 1), Name: name})
 	}
+
+	gitHubService.
+		EXPECT().
+		ListTeams(gomock.Any(), "SEEK-Jobs").
+		Return(teams, nil)
 }
 
 func TestUpdateAdminTopics(t *testing.T) {
@@ -46,6 +70,8 @@ func TestUpdateAdminTopics(t *testing.T) {
 	plat := NewTestPlatform(ctrl)
 	ctx := context.Background()
 
+	expectListTeams(plat.MockGitHubService, "Foo", "Bar", "Baz", "Qux")
+
 	haveRepos := testRepos()
 
 	plat.MockGitHubService.
@@ -71,12 +97,27 @@ func TestUpdateAdminTopics(t *testing.T) {
 		Return(nil)
 
 	// Run the SUT
-	res, err := UpdateAdminTopics(ctx, plat, "SEEK-Jobs")
+	res, err := UpdateAdminTopics(ctx, plat, "SEEK-Jobs", nil, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	wantRes := &UpdateAdminTopicsResult{ReposUpdated: 2}
+	wantRes := &UpdateAdminTopicsResult{
+		ReposUpdated: 2,
+		Repos: []*RepoReport{
+			{Repo: "repo4", Outcome: RepoOutcomeSkipped, Reason: "repo is archived"},
+			{
+				Repo:    "repo1",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{"added topic 'admin-bar'", "added topic 'admin-foo'"},
+			},
+			{
+				Repo:    "repo2",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{"added topic 'admin-bar'", "removed topic 'admin-old'"},
+			},
+		},
+	}
 
 	if diff := cmp.Diff(wantRes, res); diff != "" {
 		t.Errorf("(-want +got)\n%s", diff)
@@ -90,6 +131,8 @@ func TestUpdateRepoAdminTopics(t *testing.T) {
 	plat := NewTestPlatform(ctrl)
 	ctx := context.Background()
 
+	expectListTeams(plat.MockGitHubService, "Foo", "Bar", "Baz", "Qux")
+
 	plat.MockGitHubService.
 		EXPECT().
 		RepoByName(ctx, "SEEK-Jobs", "repo1").
@@ -128,6 +171,8 @@ func TestUpdateTeamAdminTopics(t *testing.T) {
 	plat := NewTestPlatform(ctrl)
 	ctx := context.Background()
 
+	expectListTeams(plat.MockGitHubService, "Foo", "Bar", "Baz", "Qux")
+
 	haveRepos := testRepos()
 
 	plat.MockGitHubService.
@@ -157,8 +202,71 @@ func TestUpdateTeamAdminTopics(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	wantRes := &UpdateAdminTopicsResult{ReposUpdated: 2}
+	wantRes := &UpdateAdminTopicsResult{
+		ReposUpdated: 2,
+		Repos: []*RepoReport{
+			{
+				Repo:    "repo1",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{"added topic 'admin-bar'", "added topic 'admin-foo'"},
+			},
+			{
+				Repo:    "repo2",
+				Outcome: RepoOutcomeChanged,
+				Changes: []string{"added topic 'admin-bar'", "removed topic 'admin-old'"},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(wantRes, res); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestUpdateDeletedTeamTopics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatform(ctrl)
+	ctx := context.Background()
+
+	expectListTeams(plat.MockGitHubService, "Foo", "Bar", "Baz", "Qux")
+
+	expectWalkRepos(plat.MockGitHubService, []*Repo{
+		{
+			Name:   "repo1",
+			Topics: []string{"admin-foo", "admin-old", "go"}, // Still has the deleted team's topic
+			Teams: []*TeamPermission{
+				{TeamName: "Foo", Permission: RepoPermissionAdmin},
+			},
+		},
+		{
+			Name:   "repo2",
+			Topics: []string{"admin-bar"}, // Stale but unrelated to the deleted team so left for a full sweep
+		},
+		{
+			Name:     "repo3",
+			Archived: true,
+			Topics:   []string{"admin-old"},
+		},
+	})
+
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateRepoTopics(ctx, "SEEK-Jobs", "repo1", []string{"admin-foo", "go"}).
+		Return(nil)
 
+	res, err := UpdateDeletedTeamTopics(ctx, plat, "SEEK-Jobs", "Old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRes := &UpdateAdminTopicsResult{
+		ReposUpdated: 1,
+		Repos: []*RepoReport{
+			{Repo: "repo1", Outcome: RepoOutcomeChanged, Changes: []string{"removed topic 'admin-old'"}},
+		},
+	}
 	if diff := cmp.Diff(wantRes, res); diff != "" {
 		t.Errorf("(-want +got)\n%s", diff)
 	}
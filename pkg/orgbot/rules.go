@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	set "github.com/deckarep/golang-set"
@@ -20,10 +21,12 @@ const (
 )
 
 // RuleEngine provides an extensible interface for running business rules against
-// a potential org structure.
+// a potential org structure or repo manifest.
 type RuleEngine interface {
 	Run(ctx context.Context, org *Org) error
 	Add(r Rule)
+	RunRepos(ctx context.Context, manifest *RepoManifest) error
+	AddRepoRule(r RepoRule)
 }
 
 // Rule provides an interface for encapsulating a single business rule that is
@@ -32,6 +35,12 @@ type Rule interface {
 	Run(ctx context.Context, org *Org) error
 }
 
+// RepoRule provides an interface for encapsulating a single business rule that is
+// run against a potential repo manifest.
+type RepoRule interface {
+	Run(ctx context.Context, manifest *RepoManifest) error
+}
+
 // RuleError is the interface implemented by all errors returned in response
 // to rule violations.
 type RuleError interface {
@@ -41,6 +50,16 @@ type RuleError interface {
 	Error() string
 }
 
+// TeamRuleError is implemented by RuleErrors whose violations can be attributed to individual teams
+// so that they can be reported against the files of those teams.
+type TeamRuleError interface {
+	RuleError
+
+	// TeamViolations returns a map of the names of the teams that violate the constraint to the
+	// details of their violations (empty when the description of the constraint says it all).
+	TeamViolations() map[string]string
+}
+
 // CompositeRuleError is the error returned by a RuleEngine when one or more
 // rule violations have occurred; it is used to aggregate multiple violations.
 type CompositeRuleError struct {
@@ -63,11 +82,17 @@ func (e *CompositeRuleError) Error() string {
 
 // ruleEngine provides the implementation of RuleEngine.
 type ruleEngine struct {
-	rules []Rule
+	rules     []Rule
+	repoRules []RepoRule
 }
 
-// NewRuleEngine returns an instance of RuleEngine with all business rules added.
-func NewRuleEngine(gitHubService GitHubService) RuleEngine {
+// NewRuleEngine returns an instance of RuleEngine with all business rules added. Repo topics
+// that are owned by the specified topic schemes (or the default schemes if nil) are managed.
+func NewRuleEngine(gitHubService GitHubService, topicSchemes []*TopicScheme) RuleEngine {
+	if len(topicSchemes) == 0 {
+		topicSchemes = DefaultTopicSchemes
+	}
+
 	ruleEngine := &ruleEngine{}
 	ruleEngine.Add(&unknownUsersRule{gitHubService: gitHubService})
 	ruleEngine.Add(&teamNamesUniqueRule{})
@@ -75,6 +100,12 @@ func NewRuleEngine(gitHubService GitHubService) RuleEngine {
 	ruleEngine.Add(&teamNameLengthRule{maxLength: maxTeamNameLength})
 	ruleEngine.Add(&activeTeamDeletionsRule{gitHubService: gitHubService})
 	ruleEngine.Add(&crossOrgMembershipsRule{})
+	ruleEngine.Add(&teamNameCollisionsRule{schemes: topicSchemes})
+	ruleEngine.AddRepoRule(&repoNamesUniqueRule{})
+	ruleEngine.AddRepoRule(&repoTeamsUniqueRule{})
+	ruleEngine.AddRepoRule(&repoTeamPermissionsRule{})
+	ruleEngine.AddRepoRule(&unknownRepoTeamsRule{gitHubService: gitHubService})
+	ruleEngine.AddRepoRule(&managedRepoTopicsRule{schemes: topicSchemes})
 
 	return ruleEngine
 }
@@ -106,6 +137,33 @@ func (o *ruleEngine) Add(r Rule) {
 	o.rules = append(o.rules, r)
 }
 
+// RunRepos implements RuleEngine.
+func (o *ruleEngine) RunRepos(ctx context.Context, manifest *RepoManifest) error {
+	var ruleErrors []RuleError
+
+	for _, rule := range o.repoRules {
+		if err := rule.Run(ctx, manifest); err != nil {
+			// If the error is a RuleError collect it, otherwise return immediately
+			ruleError, ok := err.(RuleError)
+			if !ok {
+				return err
+			}
+			ruleErrors = append(ruleErrors, ruleError)
+		}
+	}
+
+	if len(ruleErrors) > 0 {
+		return &CompositeRuleError{OrgName: manifest.Name, Errors: ruleErrors}
+	}
+
+	return nil
+}
+
+// AddRepoRule implements RuleEngine.
+func (o *ruleEngine) AddRepoRule(r RepoRule) {
+	o.repoRules = append(o.repoRules, r)
+}
+
 // UnknownUsersError is the error returned when one or more users are not part of the GitHub org.
 type UnknownUsersError struct {
 	// Violations is a map of team names to user email addresses that violate the constraint.
@@ -131,6 +189,11 @@ func (e *UnknownUsersError) Link() string {
 	return docURL + "#unknown-users"
 }
 
+// TeamViolations implements TeamRuleError.
+func (e *UnknownUsersError) TeamViolations() map[string]string {
+	return teamViolationsFromEmails(e.Violations)
+}
+
 // Error implements RuleError.
 func (e *UnknownUsersError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
@@ -150,7 +213,9 @@ func (r *unknownUsersRule) Run(ctx context.Context, org *Org) error {
 	var run func([]*Team) error
 	run = func(teams []*Team) error {
 		for _, t := range teams {
-			for _, email := range append(t.Maintainers, t.Members...) {
+			// Owners needn't be members of the team but must still be members of the org
+			emails := append(append([]string{}, t.Maintainers...), t.Members...)
+			for _, email := range append(emails, t.Owners...) {
 				_, err := r.gitHubService.UserByEmail(ctx, org.Name, email)
 				if err != nil {
 					if _, ok := err.(*GitHubUserNotFoundError); !ok {
@@ -198,6 +263,11 @@ func (e *TeamNamesUniqueError) Link() string {
 	return docURL + "#duplicate-team-names"
 }
 
+// TeamViolations implements TeamRuleError.
+func (e *TeamNamesUniqueError) TeamViolations() map[string]string {
+	return teamViolationsFromNames(e.Violations)
+}
+
 // Error implements RuleError.
 func (e *TeamNamesUniqueError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
@@ -257,6 +327,11 @@ func (e *UsersUniqueWithinTeamError) Link() string {
 	return docURL + "#duplicate-team-members"
 }
 
+// TeamViolations implements TeamRuleError.
+func (e *UsersUniqueWithinTeamError) TeamViolations() map[string]string {
+	return teamViolationsFromEmails(e.Violations)
+}
+
 // Error implements RuleError.
 func (e *UsersUniqueWithinTeamError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
@@ -321,6 +396,11 @@ func (e *CrossOrgMembershipsError) Link() string {
 	return docURL + "#cross-organisation-memberships"
 }
 
+// TeamViolations implements TeamRuleError.
+func (e *CrossOrgMembershipsError) TeamViolations() map[string]string {
+	return teamViolationsFromEmails(e.Violations)
+}
+
 // Error implements RuleError.
 func (e *CrossOrgMembershipsError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
@@ -494,10 +574,13 @@ func (r *activeTeamDeletionsRule) Run(ctx context.Context, org *Org) error {
 
 }
 
-// listTeamRepoNames returns a slice of all repository names directly accessible to the specified team.
+// listTeamRepoNames returns a slice of all unarchived repository names directly accessible to the specified team.
 func listTeamRepoNames(ctx context.Context, gitHubService GitHubService, orgName string, teamID GitHubTeamID) ([]string, error) {
 	var repoNames []string
 	if err := gitHubService.WalkReposByTeam(ctx, orgName, teamID, func(r *Repo) error {
+		if r.Archived {
+			return nil // Archived repos don't prevent deletion
+		}
 		repoNames = append(repoNames, r.Name)
 		return nil
 	}); err != nil {
@@ -517,6 +600,37 @@ func quoteJoin(ss []string) string {
 	return strings.Join(quoted, ", ")
 }
 
+// teamViolationsFromEmails returns the team violations for a map of team names to the email
+// addresses of the users within those teams that violate a constraint.
+func teamViolationsFromEmails(violations map[string][]string) map[string]string {
+	teamViolations := map[string]string{}
+	for t, emails := range violations {
+		teamViolations[t] = quoteJoin(emails)
+	}
+	return teamViolations
+}
+
+// teamViolationsFromNames returns the team violations for an array of the names of teams that
+// violate a constraint.
+func teamViolationsFromNames(names []string) map[string]string {
+	teamViolations := map[string]string{}
+	for _, t := range names {
+		teamViolations[t] = ""
+	}
+	return teamViolations
+}
+
+// others returns the strings in ss other than s.
+func others(ss []string, s string) []string {
+	var o []string
+	for _, v := range ss {
+		if v != s {
+			o = append(o, v)
+		}
+	}
+	return o
+}
+
 // TeamNameLengthError is the error returned when a team's name is too long.
 type TeamNameLengthError struct {
 	// Violations is a map of team names that violate the constraint.
@@ -540,6 +654,11 @@ func (e *TeamNameLengthError) Link() string {
 	return docURL + "#team-name-length"
 }
 
+// TeamViolations implements TeamRuleError.
+func (e *TeamNameLengthError) TeamViolations() map[string]string {
+	return teamViolationsFromNames(e.Violations)
+}
+
 // Error implements RuleError.
 func (e *TeamNameLengthError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
@@ -576,3 +695,114 @@ func (r *teamNameLengthRule) Run(ctx context.Context, org *Org) error {
 
 	return nil
 }
+
+// TeamNameCollisionsError is the error returned when the names of different teams produce the same
+// normalised name or generated topic.
+type TeamNameCollisionsError struct {
+	// Violations is a map of normalised names and generated topics to the teams that produce them.
+	Violations map[string][]string
+}
+
+// Description implements RuleError.
+func (e *TeamNameCollisionsError) Description() string {
+	return "Team names (including previous names) must not produce the same normalised name or topic as other teams"
+}
+
+// ConstraintViolations implements RuleError.
+func (e *TeamNameCollisionsError) ConstraintViolations() string {
+	var msgs []string
+	for name, teams := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("'%s': %s", name, quoteJoin(teams)))
+	}
+	sort.Strings(msgs)
+	return strings.Join(msgs, "; ")
+}
+
+// Link implements RuleError.
+func (e *TeamNameCollisionsError) Link() string {
+	return docURL + "#team-name-collisions"
+}
+
+// TeamViolations implements TeamRuleError.
+func (e *TeamNameCollisionsError) TeamViolations() map[string]string {
+	details := map[string][]string{}
+	for generated, teams := range e.Violations {
+		for _, t := range teams {
+			details[t] = append(details[t], fmt.Sprintf("'%s' is also produced by %s", generated, quoteJoin(others(teams, t))))
+		}
+	}
+
+	violations := map[string]string{}
+	for t, msgs := range details {
+		sort.Strings(msgs)
+		violations[t] = strings.Join(msgs, "; ")
+	}
+	return violations
+}
+
+// Error implements RuleError.
+func (e *TeamNameCollisionsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Description(), e.ConstraintViolations())
+}
+
+// teamNameCollisionsRule provides an implementation of Rule that verifies that the current and
+// previous names of different teams don't normalise to the same directory name or produce the
+// same topic under any of the topic schemes (e.g. "Data_Eng" and "Data Eng"). Truncated topics
+// are disambiguated so only collide when the names produce them in full.
+type teamNameCollisionsRule struct {
+	schemes []*TopicScheme
+}
+
+// Run implements Rule.
+func (r *teamNameCollisionsRule) Run(ctx context.Context, org *Org) error {
+	// Map of each generated name to the set of teams that produce it
+	producers := map[string]set.Set{}
+	produce := func(generated string, teamName string) {
+		if _, ok := producers[generated]; !ok {
+			producers[generated] = set.NewSet()
+		}
+		producers[generated].Add(teamName)
+	}
+
+	// Topics are disambiguated against the current names of the teams, as they are when derived
+	var teamNames []string
+	var collect func([]*Team)
+	collect = func(teams []*Team) {
+		for _, t := range teams {
+			teamNames = append(teamNames, t.Name)
+			collect(t.Children)
+		}
+	}
+	collect(org.Teams)
+
+	// run recursively descends into the team hierarchy generating names for each team
+	var run func([]*Team)
+	run = func(teams []*Team) {
+		for _, t := range teams {
+			for _, name := range append([]string{t.Name}, t.Previously...) {
+				produce(normaliseName(name), t.Name)
+				for _, s := range r.schemes {
+					produce(newTopic(s.prefix(), name, teamNames), t.Name)
+				}
+			}
+			run(t.Children)
+		}
+	}
+
+	run(org.Teams)
+
+	violations := map[string][]string{}
+	for generated, teams := range producers {
+		if teams.Cardinality() > 1 {
+			teamNames := stringSetToSlice(teams)
+			sort.Strings(teamNames)
+			violations[generated] = teamNames
+		}
+	}
+
+	if len(violations) > 0 {
+		return &TeamNameCollisionsError{Violations: violations}
+	}
+
+	return nil
+}
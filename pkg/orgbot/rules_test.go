@@ -255,7 +255,7 @@ func TestRuleEngineValidOrg(t *testing.T) {
 	defer ctrl.Finish()
 
 	gitHubService := NewMockGitHubService(ctrl)
-	ruleEngine := NewRuleEngine(gitHubService)
+	ruleEngine := NewRuleEngine(gitHubService, nil)
 	ctx := context.Background()
 
 	// Expect unknownUsersRule to query users by email
@@ -311,7 +311,7 @@ func TestRuleEngineCompositeRuleError(t *testing.T) {
 	defer ctrl.Finish()
 
 	gitHubService := NewMockGitHubService(ctrl)
-	ruleEngine := NewRuleEngine(gitHubService)
+	ruleEngine := NewRuleEngine(gitHubService, nil)
 	ctx := context.Background()
 
 	// Return GitHubUserNotFoundError for mary@seek.com.au and success for anything else
@@ -442,3 +442,135 @@ func TestTeamNamesLengthRule(t *testing.T) {
 		t.Errorf("(-want +got)\n%s", diff)
 	}
 }
+
+func TestRepoTeamsRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	gitHubService := NewMockGitHubService(ctrl)
+	ctx := context.Background()
+
+	gitHubService.
+		EXPECT().
+		ListTeams(ctx, "SEEK-Jobs").
+		Return([]*GitHubTeam{{ID: 100, Name: "Foo"}}, nil)
+
+	manifest := RepoManifest{
+		Name: "SEEK-Jobs",
+		Repos: []*RepoSpec{
+			{
+				Name:   "repo1",
+				Topics: []string{"go", "admin-bar"},
+				Teams: []*RepoTeam{
+					{Name: "Foo", Permission: RepoPermissionAdmin},
+					{Name: "Foo", Permission: "owner"},
+					{Name: "Bar", Permission: RepoPermissionRead},
+				},
+			},
+			{Name: "Repo1"},
+		},
+	}
+
+	engine := NewRuleEngine(gitHubService, nil)
+	err := engine.RunRepos(ctx, &manifest)
+	wantError := &CompositeRuleError{
+		OrgName: "SEEK-Jobs",
+		Errors: []RuleError{
+			&RepoNamesUniqueError{Violations: []string{"Repo1"}},
+			&RepoTeamsUniqueError{Violations: map[string][]string{"repo1": {"Foo"}}},
+			&RepoTeamPermissionsError{Violations: map[string][]string{"repo1": {"Foo"}}},
+			&UnknownRepoTeamsError{Violations: map[string][]string{"repo1": {"Bar"}}},
+			&ManagedRepoTopicsError{Prefixes: []string{"admin-"}, Violations: map[string][]string{"repo1": {"admin-bar"}}},
+		},
+	}
+	if diff := cmp.Diff(wantError, err); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestTeamNameCollisionsRule(t *testing.T) {
+	longName := "Candidate Experience Platform Team"
+
+	org := Org{
+		Name: "SEEK-Jobs",
+		Teams: []*Team{
+			{
+				Name: "Data Eng",
+				Children: []*Team{
+					{Name: "Data_Eng"}, // Same normalised name and topic as its parent
+				},
+			},
+			{Name: "Search", Previously: []string{"Search Team"}}, // Renames don't collide with themselves
+			{Name: "Search-Team"}, // Collides with the previous name of Search
+			{Name: longName},
+			{Name: longName + "s"}, // Truncates to the same topic, which is disambiguated
+		},
+	}
+
+	rule := teamNameCollisionsRule{schemes: DefaultTopicSchemes}
+	err := rule.Run(context.Background(), &org)
+	wantError := &TeamNameCollisionsError{
+		Violations: map[string][]string{
+			"data-eng":          {"Data Eng", "Data_Eng"},
+			"admin-data-eng":    {"Data Eng", "Data_Eng"},
+			"search-team":       {"Search", "Search-Team"},
+			"admin-search-team": {"Search", "Search-Team"},
+		},
+	}
+	if diff := cmp.Diff(wantError, err); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestTeamNameCollisionsTeamViolations(t *testing.T) {
+	err := &TeamNameCollisionsError{
+		Violations: map[string][]string{
+			"data-eng":       {"Data Eng", "Data_Eng"},
+			"admin-data-eng": {"Data Eng", "Data_Eng"},
+		},
+	}
+
+	want := map[string]string{
+		"Data Eng": "'admin-data-eng' is also produced by 'Data_Eng'; 'data-eng' is also produced by 'Data_Eng'",
+		"Data_Eng": "'admin-data-eng' is also produced by 'Data Eng'; 'data-eng' is also produced by 'Data Eng'",
+	}
+	if diff := cmp.Diff(want, err.TeamViolations()); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestNewTopicTruncation(t *testing.T) {
+	name := strings.Repeat("Candidate Experience ", 3)
+	topic := newTopic(adminTopicPrefix, name, []string{name, "Search"})
+
+	// Truncated topics aren't otherwise changed so existing topics stay stable
+	want := (adminTopicPrefix + normaliseName(name))[:maxTopicLength-1]
+	if topic != want {
+		t.Errorf("expected topic '%s' but got '%s'", want, topic)
+	}
+}
+
+func TestNewTopicDisambiguatesTruncatedCollisions(t *testing.T) {
+	names := []string{"Candidate Experience Platform Team", "Candidate Experience Platform Tribe", "Search"}
+
+	topics := map[string]string{}
+	for _, name := range names {
+		topic := newTopic(adminTopicPrefix, name, names)
+		if len(topic) >= maxTopicLength {
+			t.Errorf("expected topic '%s' to be shorter than %d", topic, maxTopicLength)
+		}
+		if other, ok := topics[topic]; ok {
+			t.Errorf("expected '%s' and '%s' to produce different topics, both produced '%s'", other, name, topic)
+		}
+		topics[topic] = name
+	}
+
+	want := map[string]string{
+		"admin-candidate-experience-" + shortHash(names[0]): names[0],
+		"admin-candidate-experience-" + shortHash(names[1]): names[1],
+		"admin-search": "Search",
+	}
+	if diff := cmp.Diff(want, topics); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
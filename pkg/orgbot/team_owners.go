@@ -0,0 +1,43 @@
+package orgbot
+
+import (
+	"sort"
+	"strings"
+)
+
+// TeamOwners returns a map of the names of the teams within the specified org to the email addresses
+// of the users that may approve changes to them, i.e. the owners of each team and of its ancestors.
+// Teams that neither have owners nor inherit them are omitted.
+func TeamOwners(org *Org) map[string][]string {
+	owners := map[string][]string{}
+
+	// descend walks down the team hierarchy accumulating the owners of the ancestors of each team
+	var descend func([]*Team, []string)
+	descend = func(teams []*Team, inherited []string) {
+		for _, t := range teams {
+			emails := mergeEmails(inherited, t.Owners)
+			if len(emails) > 0 {
+				owners[t.Name] = emails
+			}
+			descend(t.Children, emails)
+		}
+	}
+	descend(org.Teams, nil)
+
+	return owners
+}
+
+// mergeEmails returns the sorted union of the specified email addresses, which are compared case
+// insensitively.
+func mergeEmails(a, b []string) []string {
+	seen := map[string]bool{}
+	var emails []string
+	for _, e := range append(append([]string{}, a...), b...) {
+		if k := strings.ToLower(e); !seen[k] {
+			seen[k] = true
+			emails = append(emails, e)
+		}
+	}
+	sort.Strings(emails)
+	return emails
+}
@@ -0,0 +1,46 @@
+package orgbot
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTeamOwners(t *testing.T) {
+	org := &Org{
+		Name: "SEEK-Jobs",
+		Teams: []*Team{
+			{
+				Name:   "Platform",
+				Owners: []string{"jbloggs@seek.com.au"},
+				Children: []*Team{
+					{
+						Name:   "Security",
+						Owners: []string{"asmith@seek.com.au", "JBloggs@seek.com.au"}, // Duplicates the inherited owner
+						Children: []*Team{
+							{Name: "Red Team"},
+						},
+					},
+					{Name: "Tooling"},
+				},
+			},
+			{
+				Name: "Unowned",
+				Children: []*Team{
+					{Name: "Owned", Owners: []string{"bsmith@seek.com.au"}},
+				},
+			},
+		},
+	}
+
+	want := map[string][]string{
+		"Platform": {"jbloggs@seek.com.au"},
+		"Security": {"asmith@seek.com.au", "jbloggs@seek.com.au"},
+		"Red Team": {"asmith@seek.com.au", "jbloggs@seek.com.au"},
+		"Tooling":  {"jbloggs@seek.com.au"},
+		"Owned":    {"bsmith@seek.com.au"},
+	}
+	if diff := cmp.Diff(want, TeamOwners(org)); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
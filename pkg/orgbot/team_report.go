@@ -0,0 +1,64 @@
+package orgbot
+
+import (
+	"sort"
+)
+
+// TeamReport describes the changes made to a single team by ApplyOrg.
+type TeamReport struct {
+	Team        string   `json:"team" yaml:"team"`
+	Created     bool     `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated     bool     `json:"updated,omitempty" yaml:"updated,omitempty"` // The name, description or parent changed
+	Deleted     bool     `json:"deleted,omitempty" yaml:"deleted,omitempty"`
+	RenamedFrom string   `json:"renamedFrom,omitempty" yaml:"renamedFrom,omitempty"` // Previous name of a renamed team
+	Added       []string `json:"added,omitempty" yaml:"added,omitempty"`             // Emails of users added to the team
+	Removed     []string `json:"removed,omitempty" yaml:"removed,omitempty"`         // Emails of users removed from the team
+}
+
+// hasChanges returns whether the report describes any changes.
+func (r *TeamReport) hasChanges() bool {
+	return r.Created || r.Updated || r.Deleted || len(r.Added) > 0 || len(r.Removed) > 0
+}
+
+// teamReports collects TeamReports in the order that teams are first reported on.
+type teamReports struct {
+	reports []*TeamReport
+	byName  map[string]*TeamReport
+}
+
+// newTeamReports returns an empty teamReports.
+func newTeamReports() *teamReports {
+	return &teamReports{byName: map[string]*TeamReport{}}
+}
+
+// team returns the report for the team with the specified name, creating it if necessary.
+func (tr *teamReports) team(name string) *TeamReport {
+	if r, ok := tr.byName[name]; ok {
+		return r
+	}
+
+	r := &TeamReport{Team: name}
+	tr.reports = append(tr.reports, r)
+	tr.byName[name] = r
+	return r
+}
+
+// changed returns the reports that describe changes. Users whose role within a team changed
+// appear as both added and removed so they are left out; a role change alters neither access
+// nor membership.
+func (tr *teamReports) changed() []*TeamReport {
+	var changed []*TeamReport
+	for _, r := range tr.reports {
+		added := newStringSet(r.Added)
+		removed := newStringSet(r.Removed)
+		r.Added = stringSetToSlice(added.Difference(removed))
+		r.Removed = stringSetToSlice(removed.Difference(added))
+		sort.Strings(r.Added)
+		sort.Strings(r.Removed)
+
+		if r.hasChanges() {
+			changed = append(changed, r)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,32 @@
+package orgbot
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTeamReportsChanged(t *testing.T) {
+	reports := newTeamReports()
+
+	// A user moving from member to maintainer is both added and removed
+	a := reports.team("a")
+	a.Added = []string{"zoe@seek.com.au", "alice@seek.com.au"}
+	a.Removed = []string{"alice@seek.com.au", "bob@seek.com.au"}
+
+	// Role changes alone aren't reported
+	b := reports.team("b")
+	b.Added = []string{"carol@seek.com.au"}
+	b.Removed = []string{"carol@seek.com.au"}
+
+	reports.team("c").Deleted = true
+	reports.team("a").Updated = true
+
+	want := []*TeamReport{
+		{Team: "a", Updated: true, Added: []string{"zoe@seek.com.au"}, Removed: []string{"bob@seek.com.au"}},
+		{Team: "c", Deleted: true},
+	}
+	if diff := cmp.Diff(want, reports.changed()); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,298 @@
+package orgbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	set "github.com/deckarep/golang-set"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TopicPlaceholderTeam is replaced by the normalised name of the team with access to the repo.
+	TopicPlaceholderTeam = "<team>"
+
+	// TopicPlaceholderTopLevelAncestor is replaced by the normalised name of the top-level ancestor
+	// of the team with access to the repo (or the team itself if it is a top-level team).
+	TopicPlaceholderTopLevelAncestor = "<top-level-ancestor>"
+)
+
+// TopicScheme describes how repository topics are derived from the teams that have access to
+// a repository. The template consists of a prefix followed by a single placeholder; all topics
+// that start with the prefix are owned by the scheme and are removed when no longer derived.
+type TopicScheme struct {
+	Template    string           `json:"template" yaml:"template"`                           // e.g. "admin-<team>"
+	Permissions []RepoPermission `json:"permissions,omitempty" yaml:"permissions,omitempty"` // Permissions of the teams the scheme applies to (empty for all)
+}
+
+// DefaultTopicSchemes are the topic schemes that are used when none have been configured.
+var DefaultTopicSchemes = []*TopicScheme{
+	{Template: adminTopicPrefix + TopicPlaceholderTeam, Permissions: []RepoPermission{RepoPermissionAdmin}},
+}
+
+// ParseTopicSchemes decodes the specified JSON array of topic schemes and validates them.
+// Permissions are converted to their canonical form.
+func ParseTopicSchemes(buf []byte) ([]*TopicScheme, error) {
+	var schemes []*TopicScheme
+	if err := json.Unmarshal(buf, &schemes); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal topic schemes")
+	}
+
+	prefixes := set.NewSet()
+	for _, s := range schemes {
+		prefix, placeholder := s.split()
+		if prefix == "" || placeholder == "" {
+			return nil, fmt.Errorf("topic scheme template '%s' must be a prefix followed by %s or %s",
+				s.Template, TopicPlaceholderTeam, TopicPlaceholderTopLevelAncestor)
+		}
+
+		if normaliseName(prefix) != strings.TrimRight(prefix, "-") {
+			return nil, fmt.Errorf("topic scheme template '%s' has a prefix that is not a valid topic", s.Template)
+		}
+
+		// Prefixes determine which topics a scheme owns so they must not overlap
+		for _, p := range prefixes.ToSlice() {
+			if strings.HasPrefix(prefix, p.(string)) || strings.HasPrefix(p.(string), prefix) {
+				return nil, fmt.Errorf("topic scheme prefixes '%s' and '%s' overlap", p, prefix)
+			}
+		}
+		prefixes.Add(prefix)
+
+		for i, p := range s.Permissions {
+			canonical, err := ParseRepoPermission(string(p))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid permission in topic scheme '%s'", s.Template)
+			}
+			s.Permissions[i] = canonical
+		}
+	}
+
+	return schemes, nil
+}
+
+// topicSchemes returns the topic schemes configured for the specified platform or the
+// default topic schemes if none have been configured.
+func topicSchemes(plat Platform) []*TopicScheme {
+	if c := plat.Config(); c != nil && len(c.TopicSchemes) > 0 {
+		return c.TopicSchemes
+	}
+	return DefaultTopicSchemes
+}
+
+// split returns the prefix and placeholder of the scheme's template. Empty strings are returned
+// if the template does not end with a known placeholder.
+func (s *TopicScheme) split() (string, string) {
+	for _, placeholder := range []string{TopicPlaceholderTeam, TopicPlaceholderTopLevelAncestor} {
+		if strings.HasSuffix(s.Template, placeholder) {
+			return strings.TrimSuffix(s.Template, placeholder), placeholder
+		}
+	}
+	return "", ""
+}
+
+// prefix returns the prefix of the topics owned by the scheme.
+func (s *TopicScheme) prefix() string {
+	prefix, _ := s.split()
+	return prefix
+}
+
+// appliesTo returns whether the scheme applies to teams with the specified permission.
+func (s *TopicScheme) appliesTo(permission RepoPermission) bool {
+	if len(s.Permissions) == 0 {
+		return true
+	}
+
+	for _, p := range s.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// needsAncestry returns whether any of the specified schemes require the team hierarchy.
+func needsAncestry(schemes []*TopicScheme) bool {
+	for _, s := range schemes {
+		if _, placeholder := s.split(); placeholder == TopicPlaceholderTopLevelAncestor {
+			return true
+		}
+	}
+	return false
+}
+
+// topicRectifier derives repository topics from the teams that have access to repositories
+// according to a set of topic schemes.
+type topicRectifier struct {
+	schemes []*TopicScheme
+
+	// topLevelAncestors maps team names to the names of their top-level ancestors and is only
+	// populated when one of the schemes requires it.
+	topLevelAncestors map[string]string
+
+	// teamNames are the names of the teams in the org, which disambiguate truncated topics.
+	teamNames []string
+}
+
+// newTopicRectifier returns a topicRectifier for the topic schemes configured for the
+// specified platform, retrieving the teams of the org to disambiguate truncated topics and
+// determine the team hierarchy.
+func newTopicRectifier(ctx context.Context, plat Platform, orgName string) (*topicRectifier, error) {
+	schemes := topicSchemes(plat)
+	teams, err := plat.GitHubService().ListTeams(ctx, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTopicRectifierForTeams(schemes, teams), nil
+}
+
+// newTopicRectifierForTeams returns a topicRectifier for the specified topic schemes using the
+// specified teams to disambiguate truncated topics and determine the team hierarchy.
+func newTopicRectifierForTeams(schemes []*TopicScheme, teams []*GitHubTeam) *topicRectifier {
+	var teamNames []string
+	for _, t := range teams {
+		teamNames = append(teamNames, t.Name)
+	}
+
+	tr := topicRectifier{schemes: schemes, teamNames: teamNames}
+	if needsAncestry(schemes) {
+		tr.topLevelAncestors = topLevelAncestors(teams)
+	}
+	return &tr
+}
+
+// topLevelAncestors returns a map of team names to the names of their top-level ancestors.
+func topLevelAncestors(teams []*GitHubTeam) map[string]string {
+	teamsByID := map[GitHubTeamID]*GitHubTeam{}
+	for _, t := range teams {
+		teamsByID[t.ID] = t
+	}
+
+	ancestors := map[string]string{}
+	for _, t := range teams {
+		ancestor := t
+		for ancestor.ParentID != 0 {
+			parent, ok := teamsByID[ancestor.ParentID]
+			if !ok {
+				break
+			}
+			ancestor = parent
+		}
+		ancestors[t.Name] = ancestor.Name
+	}
+
+	return ancestors
+}
+
+// ownsTopic returns whether the specified topic is owned by one of the schemes.
+func (tr *topicRectifier) ownsTopic(topic string) bool {
+	for _, s := range tr.schemes {
+		if strings.HasPrefix(topic, s.prefix()) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixes returns the prefixes of the topics owned by the schemes.
+func (tr *topicRectifier) prefixes() []string {
+	var prefixes []string
+	for _, s := range tr.schemes {
+		prefixes = append(prefixes, s.prefix())
+	}
+	return prefixes
+}
+
+// teamTopics returns the topics that the specified team name can produce under any of the schemes,
+// including if the team no longer exists.
+func (tr *topicRectifier) teamTopics(teamName string) []string {
+	var topics []string
+	for _, s := range tr.schemes {
+		topics = append(topics, newTopic(s.prefix(), teamName, tr.teamNames))
+	}
+	return topics
+}
+
+// rectify updates the specified repo to include the topics derived from its teams, removing
+// any owned topics that are no longer derived. It returns true if changes were made to the
+// repo, otherwise false.
+func (tr *topicRectifier) rectify(r *Repo) bool {
+	haveTopics := newStringSet(r.Topics)
+
+	// Create a set of topics that we want applied, starting with all of the existing topics
+	// minus any owned topics. We'll add the derived topics to the set below.
+	wantTopics := set.NewSet()
+	for _, topic := range r.Topics {
+		if !tr.ownsTopic(topic) {
+			wantTopics.Add(topic)
+		}
+	}
+
+	// Add the derived topics to the desired set
+	for _, team := range r.Teams {
+		for _, s := range tr.schemes {
+			if !s.appliesTo(team.Permission) {
+				continue
+			}
+
+			prefix, placeholder := s.split()
+			name := team.TeamName
+			if placeholder == TopicPlaceholderTopLevelAncestor {
+				ancestor, ok := tr.topLevelAncestors[team.TeamName]
+				if !ok {
+					continue // Secret teams aren't listed so their ancestry is unknown
+				}
+				name = ancestor
+			}
+
+			wantTopics.Add(newTopic(prefix, name, tr.teamNames))
+		}
+	}
+
+	// If the sets are equal then we don't need to do anything
+	if haveTopics.Equal(wantTopics) {
+		return false
+	}
+
+	r.Topics = stringSetToSlice(wantTopics)
+
+	// Sort the topics so that they can be predictably tested
+	sort.Strings(r.Topics)
+
+	return true
+}
+
+// newTopic returns the topic with the specified prefix for the specified name, truncated to the
+// maximum topic length. A truncated topic that is the same as that of any other of the specified
+// team names has a short hash of the name appended to keep them apart, whereas other topics are
+// left as they are so that the topics of existing teams stay stable.
+func newTopic(prefix string, name string, teamNames []string) string {
+	topic, truncated := truncatedTopic(prefix, name)
+	if !truncated {
+		return topic
+	}
+
+	for _, other := range teamNames {
+		if other == name {
+			continue
+		}
+		if otherTopic, _ := truncatedTopic(prefix, other); otherTopic == topic {
+			suffix := "-" + shortHash(name)
+			return strings.TrimRight(topic[:maxTopicLength-1-len(suffix)], "-") + suffix
+		}
+	}
+	return topic
+}
+
+// truncatedTopic returns the topic with the specified prefix for the specified name, truncated to
+// the maximum topic length, and whether it was truncated.
+func truncatedTopic(prefix string, name string) (string, bool) {
+	topic := prefix + normaliseName(name)
+	if len(topic) >= maxTopicLength {
+		return topic[:maxTopicLength-1], true
+	}
+	return topic, false
+}
@@ -0,0 +1,99 @@
+package orgbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTopicSchemes(t *testing.T) {
+	schemes, err := ParseTopicSchemes([]byte(`[
+		{"template": "admin-<team>", "permissions": ["admin"]},
+		{"template": "write-<team>", "permissions": ["write"]},
+		{"template": "tribe-<top-level-ancestor>"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*TopicScheme{
+		{Template: "admin-<team>", Permissions: []RepoPermission{RepoPermissionAdmin}},
+		{Template: "write-<team>", Permissions: []RepoPermission{RepoPermissionWrite}},
+		{Template: "tribe-<top-level-ancestor>"},
+	}
+	if diff := cmp.Diff(want, schemes); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+}
+
+func TestParseTopicSchemesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		schemes string
+	}{
+		{name: "No placeholder", schemes: `[{"template": "admin-"}]`},
+		{name: "No prefix", schemes: `[{"template": "<team>"}]`},
+		{name: "Placeholder not at end", schemes: `[{"template": "<team>-admin"}]`},
+		{name: "Invalid prefix", schemes: `[{"template": "Admin_<team>"}]`},
+		{name: "Overlapping prefixes", schemes: `[{"template": "admin-<team>"}, {"template": "admin-tribe-<top-level-ancestor>"}]`},
+		{name: "Invalid permission", schemes: `[{"template": "admin-<team>", "permissions": ["owner"]}]`},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseTopicSchemes([]byte(test.schemes)); err == nil {
+			t.Errorf("Test case '%s': expected error", test.name)
+		}
+	}
+}
+
+func TestUpdateAdminTopicsTopicSchemes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plat := NewTestPlatform(ctrl)
+	plat.config.TopicSchemes = []*TopicScheme{
+		{Template: "admin-<team>", Permissions: []RepoPermission{RepoPermissionAdmin}},
+		{Template: "write-<team>", Permissions: []RepoPermission{RepoPermissionWrite}},
+		{Template: "tribe-<top-level-ancestor>", Permissions: []RepoPermission{RepoPermissionAdmin}},
+	}
+	ctx := context.Background()
+
+	// The team hierarchy is needed to determine tribes
+	plat.MockGitHubService.
+		EXPECT().
+		ListTeams(ctx, "SEEK-Jobs").
+		Return([]*GitHubTeam{
+			{ID: 100, ParentID: 0, Name: "Candidate Tribe"},
+			{ID: 101, ParentID: 100, Name: "Profiles"},
+			{ID: 102, ParentID: 101, Name: "Profiles Data"},
+			{ID: 103, ParentID: 0, Name: "Platform"},
+		}, nil)
+
+	expectWalkRepos(plat.MockGitHubService, []*Repo{
+		{
+			Name:   "profiles-api",
+			Topics: []string{"go", "tribe-old", "write-old"}, // Stale owned topics are cleaned up
+			Teams: []*TeamPermission{
+				{TeamName: "Profiles Data", Permission: RepoPermissionAdmin},
+				{TeamName: "Platform", Permission: RepoPermissionWrite},
+				{TeamName: "Secret", Permission: RepoPermissionAdmin}, // Unlisted teams have no known tribe
+			},
+		},
+	})
+
+	plat.MockGitHubService.
+		EXPECT().
+		UpdateRepoTopics(ctx, "SEEK-Jobs", "profiles-api", []string{"admin-profiles-data", "admin-secret", "go", "tribe-candidate-tribe", "write-platform"}).
+		Return(nil)
+
+	res, err := UpdateAdminTopics(ctx, plat, "SEEK-Jobs", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.ReposUpdated != 1 {
+		t.Errorf("want 1 repo updated, got %d", res.ReposUpdated)
+	}
+}
@@ -0,0 +1,269 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/aws"
+)
+
+// archiveDateLayout is the layout of the dates that group the objects of the S3 archive by delivery day
+const archiveDateLayout = "2006-01-02"
+
+// Archive holds the messages of the events delivered to the hook so that they can be inspected and
+// replayed. Archived messages are identified by their delivery IDs.
+type Archive interface {
+	// Put adds the specified message to the archive, replacing any with the same delivery ID.
+	Put(ctx context.Context, m *Message) error
+
+	// Get returns the archived message with the specified delivery ID.
+	Get(ctx context.Context, deliveryID string) (*Message, error)
+
+	// List returns the archived messages delivered at or after the specified time, oldest first.
+	List(ctx context.Context, since time.Time) ([]*Message, error)
+}
+
+// sortMessages sorts the specified messages oldest first, dropping those delivered before the specified time.
+func sortMessages(messages []*Message, since time.Time) []*Message {
+	var sorted []*Message
+	for _, m := range messages {
+		if !m.DeliveredAt.Before(since) {
+			sorted = append(sorted, m)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].DeliveredAt.Before(sorted[j].DeliveredAt)
+	})
+	return sorted
+}
+
+// archiveMessage returns the specified message as it's archived, without its receive state.
+func archiveMessage(m *Message) *Message {
+	archived := *m
+	archived.Attempts = 0
+	return &archived
+}
+
+// memoryArchive provides the in-memory implementation of Archive.
+type memoryArchive struct {
+	mu       sync.Mutex
+	messages map[string]*Message
+}
+
+// NewMemoryArchive returns an Archive that holds messages in memory. Messages are lost when the process exits.
+func NewMemoryArchive() Archive {
+	return &memoryArchive{messages: map[string]*Message{}}
+}
+
+// Put implements Archive.
+func (a *memoryArchive) Put(ctx context.Context, m *Message) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.messages[m.DeliveryID] = archiveMessage(m)
+	return nil
+}
+
+// Get implements Archive.
+func (a *memoryArchive) Get(ctx context.Context, deliveryID string) (*Message, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m, ok := a.messages[deliveryID]
+	if !ok {
+		return nil, errors.Errorf("no archived delivery with ID %s", deliveryID)
+	}
+	return archiveMessage(m), nil
+}
+
+// List implements Archive.
+func (a *memoryArchive) List(ctx context.Context, since time.Time) ([]*Message, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var messages []*Message
+	for _, m := range a.messages {
+		messages = append(messages, archiveMessage(m))
+	}
+
+	return sortMessages(messages, since), nil
+}
+
+// fileArchive provides the local directory implementation of Archive. Each message is a file in the
+// directory named after its delivery ID.
+type fileArchive struct {
+	dir string
+}
+
+// NewFileArchive returns an Archive that holds messages as files in the specified directory, creating it
+// if necessary.
+func NewFileArchive(dir string) (Archive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "could not create archive directory %s", dir)
+	}
+
+	return &fileArchive{dir: dir}, nil
+}
+
+// Put implements Archive.
+func (a *fileArchive) Put(ctx context.Context, m *Message) error {
+	buf, err := json.Marshal(archiveMessage(m))
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(a.path(m.DeliveryID), buf, 0644)
+}
+
+// Get implements Archive.
+func (a *fileArchive) Get(ctx context.Context, deliveryID string) (*Message, error) {
+	m, err := a.read(a.path(deliveryID))
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, errors.Errorf("no archived delivery with ID %s", deliveryID)
+	}
+	return m, err
+}
+
+// List implements Archive.
+func (a *fileArchive) List(ctx context.Context, since time.Time) ([]*Message, error) {
+	files, err := filepath.Glob(filepath.Join(a.dir, "*"+messageFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*Message
+	for _, f := range files {
+		m, err := a.read(f)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+
+	return sortMessages(messages, since), nil
+}
+
+// read returns the archived message in the specified file.
+func (a *fileArchive) read(path string) (*Message, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Message
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal archived delivery %s", path)
+	}
+	return &m, nil
+}
+
+// path returns the path of the file of the message with the specified delivery ID.
+func (a *fileArchive) path(deliveryID string) string {
+	return filepath.Join(a.dir, unsafeFileChars.ReplaceAllString(deliveryID, "_")+messageFileExt)
+}
+
+// s3Archive provides the S3 implementation of Archive. Each message is an object in the bucket named
+// after the day it was delivered and its delivery ID, so that listing recent messages doesn't require
+// every message to be read.
+type s3Archive struct {
+	s3     *aws.S3
+	bucket string
+	prefix string
+}
+
+// NewS3Archive returns an Archive that holds messages as objects with the specified key prefix in the
+// specified bucket.
+func NewS3Archive(s3 *aws.S3, bucket, prefix string) Archive {
+	return &s3Archive{
+		s3:     s3,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// Put implements Archive.
+func (a *s3Archive) Put(ctx context.Context, m *Message) error {
+	buf, err := json.Marshal(archiveMessage(m))
+	if err != nil {
+		return err
+	}
+
+	key := path.Join(a.prefix, m.DeliveredAt.UTC().Format(archiveDateLayout), a.name(m.DeliveryID))
+	if err := a.s3.PutObject(ctx, a.bucket, key, buf); err != nil {
+		return errors.Wrap(err, "failed to put archived delivery in s3")
+	}
+	return nil
+}
+
+// Get implements Archive.
+func (a *s3Archive) Get(ctx context.Context, deliveryID string) (*Message, error) {
+	keys, err := a.s3.ListKeys(ctx, a.bucket, a.prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list archived deliveries in s3")
+	}
+
+	name := a.name(deliveryID)
+	for _, k := range keys {
+		if path.Base(k) == name {
+			return a.read(ctx, k)
+		}
+	}
+
+	return nil, errors.Errorf("no archived delivery with ID %s", deliveryID)
+}
+
+// List implements Archive.
+func (a *s3Archive) List(ctx context.Context, since time.Time) ([]*Message, error) {
+	keys, err := a.s3.ListKeys(ctx, a.bucket, a.prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list archived deliveries in s3")
+	}
+
+	// Dates sort lexically, so days before the one containing since can be skipped without being read
+	sinceDate := since.UTC().Format(archiveDateLayout)
+
+	var messages []*Message
+	for _, k := range keys {
+		if !strings.HasSuffix(k, messageFileExt) || path.Base(path.Dir(k)) < sinceDate {
+			continue
+		}
+
+		m, err := a.read(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+
+	return sortMessages(messages, since), nil
+}
+
+// read returns the archived message in the object with the specified key.
+func (a *s3Archive) read(ctx context.Context, key string) (*Message, error) {
+	buf, err := a.s3.GetObject(ctx, a.bucket, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get archived delivery %s from s3", key)
+	}
+
+	var m Message
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal archived delivery %s", key)
+	}
+	return &m, nil
+}
+
+// name returns the name of the object of the message with the specified delivery ID.
+func (a *s3Archive) name(deliveryID string) string {
+	return unsafeFileChars.ReplaceAllString(deliveryID, "_") + messageFileExt
+}
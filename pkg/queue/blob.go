@@ -0,0 +1,148 @@
+package queue
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/aws"
+)
+
+// BlobStore holds the payloads of messages that are too large to be queued.
+type BlobStore interface {
+	// Put stores the specified blob under the specified key, replacing any with the same key.
+	Put(ctx context.Context, key string, blob []byte) error
+
+	// Get returns the blob with the specified key.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes the blob with the specified key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// memoryBlobStore provides the in-memory implementation of BlobStore.
+type memoryBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+// NewMemoryBlobStore returns a BlobStore that holds blobs in memory. Blobs are lost when the process exits.
+func NewMemoryBlobStore() BlobStore {
+	return &memoryBlobStore{blobs: map[string][]byte{}}
+}
+
+// Put implements BlobStore.
+func (s *memoryBlobStore) Put(ctx context.Context, key string, blob []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blobs[key] = append([]byte(nil), blob...)
+	return nil
+}
+
+// Get implements BlobStore.
+func (s *memoryBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blob, ok := s.blobs[key]
+	if !ok {
+		return nil, errors.Errorf("no blob with key %s", key)
+	}
+	return append([]byte(nil), blob...), nil
+}
+
+// Delete implements BlobStore.
+func (s *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blobs, key)
+	return nil
+}
+
+// fileBlobStore provides the local directory implementation of BlobStore. Each blob is a file in the
+// directory named after its key.
+type fileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore returns a BlobStore that holds blobs as files in the specified directory, creating it
+// if necessary.
+func NewFileBlobStore(dir string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "could not create blob directory %s", dir)
+	}
+
+	return &fileBlobStore{dir: dir}, nil
+}
+
+// Put implements BlobStore.
+func (s *fileBlobStore) Put(ctx context.Context, key string, blob []byte) error {
+	return ioutil.WriteFile(s.path(key), blob, 0644)
+}
+
+// Get implements BlobStore.
+func (s *fileBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return ioutil.ReadFile(s.path(key))
+}
+
+// Delete implements BlobStore.
+func (s *fileBlobStore) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to delete blob file")
+	}
+	return nil
+}
+
+// path returns the path of the file of the blob with the specified key.
+func (s *fileBlobStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.Base(key))
+}
+
+// s3BlobStore provides the S3 implementation of BlobStore. Each blob is an object in the bucket.
+type s3BlobStore struct {
+	s3     *aws.S3
+	bucket string
+	prefix string
+}
+
+// NewS3BlobStore returns a BlobStore that holds blobs as objects with the specified key prefix in the
+// specified bucket.
+func NewS3BlobStore(s3 *aws.S3, bucket, prefix string) BlobStore {
+	return &s3BlobStore{
+		s3:     s3,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// Put implements BlobStore.
+func (s *s3BlobStore) Put(ctx context.Context, key string, blob []byte) error {
+	if err := s.s3.PutObject(ctx, s.bucket, path.Join(s.prefix, key), blob); err != nil {
+		return errors.Wrap(err, "failed to put blob in s3")
+	}
+	return nil
+}
+
+// Get implements BlobStore.
+func (s *s3BlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	blob, err := s.s3.GetObject(ctx, s.bucket, path.Join(s.prefix, key))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get blob %s from s3", key)
+	}
+	return blob, nil
+}
+
+// Delete implements BlobStore.
+func (s *s3BlobStore) Delete(ctx context.Context, key string) error {
+	if err := s.s3.DeleteObject(ctx, s.bucket, path.Join(s.prefix, key)); err != nil {
+		return errors.Wrap(err, "failed to delete blob from s3")
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/SEEK-Jobs/orgbot/pkg/aws"
+)
+
+// DeadLetter is a message that couldn't be processed within the maximum number of attempts.
+type DeadLetter struct {
+	Message  Message   `json:"message" yaml:"message"`
+	Attempts int       `json:"attempts" yaml:"attempts"`
+	Error    string    `json:"error" yaml:"error"` // Error returned by the last attempt
+	Time     time.Time `json:"time" yaml:"time"`   // Time the message was dead-lettered
+}
+
+// DeadLetterStore holds the messages that couldn't be processed so that they can be inspected and
+// replayed. Dead letters are identified by the delivery IDs of their messages.
+type DeadLetterStore interface {
+	// Put adds the specified dead letter to the store, replacing any with the same delivery ID.
+	Put(ctx context.Context, d *DeadLetter) error
+
+	// List returns the dead letters in the store, oldest first.
+	List(ctx context.Context) ([]*DeadLetter, error)
+
+	// Remove removes the dead letter with the specified delivery ID from the store.
+	Remove(ctx context.Context, deliveryID string) error
+}
+
+// sortDeadLetters sorts the specified dead letters oldest first.
+func sortDeadLetters(dls []*DeadLetter) {
+	sort.SliceStable(dls, func(i, j int) bool {
+		return dls[i].Time.Before(dls[j].Time)
+	})
+}
+
+// memoryDeadLetterStore provides the in-memory implementation of DeadLetterStore.
+type memoryDeadLetterStore struct {
+	mu          sync.Mutex
+	deadLetters map[string]*DeadLetter
+}
+
+// NewMemoryDeadLetterStore returns a DeadLetterStore that holds dead letters in memory. Dead letters
+// are lost when the process exits.
+func NewMemoryDeadLetterStore() DeadLetterStore {
+	return &memoryDeadLetterStore{deadLetters: map[string]*DeadLetter{}}
+}
+
+// Put implements DeadLetterStore.
+func (s *memoryDeadLetterStore) Put(ctx context.Context, d *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *d
+	s.deadLetters[d.Message.DeliveryID] = &copied
+	return nil
+}
+
+// List implements DeadLetterStore.
+func (s *memoryDeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var dls []*DeadLetter
+	for _, d := range s.deadLetters {
+		copied := *d
+		dls = append(dls, &copied)
+	}
+	sortDeadLetters(dls)
+
+	return dls, nil
+}
+
+// Remove implements DeadLetterStore.
+func (s *memoryDeadLetterStore) Remove(ctx context.Context, deliveryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.deadLetters, deliveryID)
+	return nil
+}
+
+// fileDeadLetterStore provides the local directory implementation of DeadLetterStore. Each dead
+// letter is a file in the directory named after its delivery ID.
+type fileDeadLetterStore struct {
+	dir string
+}
+
+// NewFileDeadLetterStore returns a DeadLetterStore that holds dead letters as files in the specified
+// directory, creating it if necessary.
+func NewFileDeadLetterStore(dir string) (DeadLetterStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "could not create dead letter directory %s", dir)
+	}
+
+	return &fileDeadLetterStore{dir: dir}, nil
+}
+
+// Put implements DeadLetterStore.
+func (s *fileDeadLetterStore) Put(ctx context.Context, d *DeadLetter) error {
+	buf, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path(d.Message.DeliveryID), buf, 0644)
+}
+
+// List implements DeadLetterStore.
+func (s *fileDeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+messageFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var dls []*DeadLetter
+	for _, f := range files {
+		buf, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		var d DeadLetter
+		if err := json.Unmarshal(buf, &d); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal dead letter %s", f)
+		}
+		dls = append(dls, &d)
+	}
+	sortDeadLetters(dls)
+
+	return dls, nil
+}
+
+// Remove implements DeadLetterStore.
+func (s *fileDeadLetterStore) Remove(ctx context.Context, deliveryID string) error {
+	if err := os.Remove(s.path(deliveryID)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to delete dead letter file")
+	}
+	return nil
+}
+
+// path returns the path of the file of the dead letter with the specified delivery ID.
+func (s *fileDeadLetterStore) path(deliveryID string) string {
+	return filepath.Join(s.dir, unsafeFileChars.ReplaceAllString(deliveryID, "_")+messageFileExt)
+}
+
+// s3DeadLetterStore provides the S3 implementation of DeadLetterStore. Each dead letter is an object
+// in the bucket named after its delivery ID.
+type s3DeadLetterStore struct {
+	s3     *aws.S3
+	bucket string
+	prefix string
+}
+
+// NewS3DeadLetterStore returns a DeadLetterStore that holds dead letters as objects with the specified
+// key prefix in the specified bucket.
+func NewS3DeadLetterStore(s3 *aws.S3, bucket, prefix string) DeadLetterStore {
+	return &s3DeadLetterStore{
+		s3:     s3,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// Put implements DeadLetterStore.
+func (s *s3DeadLetterStore) Put(ctx context.Context, d *DeadLetter) error {
+	buf, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	if err := s.s3.PutObject(ctx, s.bucket, s.key(d.Message.DeliveryID), buf); err != nil {
+		return errors.Wrap(err, "failed to put dead letter in s3")
+	}
+	return nil
+}
+
+// List implements DeadLetterStore.
+func (s *s3DeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	keys, err := s.s3.ListKeys(ctx, s.bucket, s.prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list dead letters in s3")
+	}
+
+	var dls []*DeadLetter
+	for _, k := range keys {
+		if !strings.HasSuffix(k, messageFileExt) {
+			continue
+		}
+
+		buf, err := s.s3.GetObject(ctx, s.bucket, k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get dead letter %s from s3", k)
+		}
+
+		var d DeadLetter
+		if err := json.Unmarshal(buf, &d); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal dead letter %s", k)
+		}
+		dls = append(dls, &d)
+	}
+	sortDeadLetters(dls)
+
+	return dls, nil
+}
+
+// Remove implements DeadLetterStore.
+func (s *s3DeadLetterStore) Remove(ctx context.Context, deliveryID string) error {
+	if err := s.s3.DeleteObject(ctx, s.bucket, s.key(deliveryID)); err != nil {
+		return errors.Wrap(err, "failed to delete dead letter from s3")
+	}
+	return nil
+}
+
+// key returns the key of the object of the dead letter with the specified delivery ID.
+func (s *s3DeadLetterStore) key(deliveryID string) string {
+	return path.Join(s.prefix, unsafeFileChars.ReplaceAllString(deliveryID, "_")+messageFileExt)
+}
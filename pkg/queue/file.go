@@ -0,0 +1,248 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	messageFileExt  = ".json"     // Extension of the files of pending messages
+	inFlightFileExt = ".inflight" // Extension appended to the files of received messages
+)
+
+// unsafeFileChars matches the characters of delivery IDs that aren't used in file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9-]`)
+
+// fileMessage is the content of a message file.
+type fileMessage struct {
+	Message
+	Attempts   int
+	SentAt     time.Time // Time the message was submitted
+	ReceivedAt time.Time // Time the message was first received (zero until then)
+	ReadyAt    time.Time // Time before which the message isn't received
+}
+
+// fileQueue provides the local directory implementation of Queue. Each message is a file in the
+// directory; files are named after the time they were submitted so that they're received in order.
+type fileQueue struct {
+	mu     sync.Mutex
+	dir    string
+	notify chan struct{}
+}
+
+// NewFileQueue returns a Queue that holds messages as files in the specified directory, creating it
+// if necessary. Messages that were received but not deleted by a previous process are made pending
+// again so that they're not lost.
+func NewFileQueue(dir string) (Queue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "could not create queue directory %s", dir)
+	}
+
+	inFlight, err := filepath.Glob(filepath.Join(dir, "*"+messageFileExt+inFlightFileExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range inFlight {
+		if err := os.Rename(f, strings.TrimSuffix(f, inFlightFileExt)); err != nil {
+			return nil, errors.Wrapf(err, "could not restore in-flight message %s", f)
+		}
+	}
+
+	return &fileQueue{
+		dir:    dir,
+		notify: make(chan struct{}, 1),
+	}, nil
+}
+
+// Submit implements Queue.
+func (q *fileQueue) Submit(ctx context.Context, m *Message) error {
+	now := time.Now()
+	name := fmt.Sprintf("%020d-%s%s", now.UnixNano(), unsafeFileChars.ReplaceAllString(m.DeliveryID, "_"), messageFileExt)
+
+	if err := q.write(filepath.Join(q.dir, name), &fileMessage{Message: *m, SentAt: now}); err != nil {
+		return err
+	}
+
+	q.signal()
+	return nil
+}
+
+// Receive implements Queue.
+func (q *fileQueue) Receive(ctx context.Context) (*Message, ReceiptHandle, error) {
+	m, handle, err := q.pop()
+	if m != nil || err != nil {
+		return m, handle, err
+	}
+
+	wait(ctx, q.notify)
+
+	return q.pop()
+}
+
+// pop moves the oldest ready message whose group isn't blocked in flight and returns it, or nil if there
+// are no such messages.
+func (q *fileQueue) pop() (*Message, ReceiptHandle, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending, err := filepath.Glob(filepath.Join(q.dir, "*"+messageFileExt))
+	if err != nil {
+		return nil, "", err
+	}
+	inFlight, err := filepath.Glob(filepath.Join(q.dir, "*"+messageFileExt+inFlightFileExt))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Names start with the submission time so sorting them puts in flight and pending messages in order
+	files := append(pending, inFlight...)
+	sort.Strings(files)
+
+	now := time.Now()
+	blocked := map[string]bool{}
+	for _, f := range files {
+		fm, err := q.read(f)
+		if os.IsNotExist(err) {
+			// Another receiver claimed or deleted the message
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		key := fm.GroupKey
+		if blocked[key] {
+			continue
+		}
+
+		// Later messages in the same group wait for this one
+		if strings.HasSuffix(f, inFlightFileExt) || fm.ReadyAt.After(now) {
+			blocked[key] = true
+			continue
+		}
+
+		// Renaming claims the message; failure means that another receiver got there first
+		claimed := f + inFlightFileExt
+		if err := os.Rename(f, claimed); err != nil {
+			blocked[key] = true
+			continue
+		}
+
+		// Record the attempt so that it's counted if the process exits before the message is deleted
+		fm.Attempts++
+		if fm.ReceivedAt.IsZero() {
+			fm.ReceivedAt = now
+		}
+		if err := q.write(claimed, fm); err != nil {
+			return nil, "", err
+		}
+
+		m := fm.Message
+		m.Attempts = fm.Attempts
+		m.SentAt = fm.SentAt
+		m.ReceivedAt = fm.ReceivedAt
+		return &m, ReceiptHandle(claimed), nil
+	}
+
+	return nil, "", nil
+}
+
+// Retry implements Queue.
+func (q *fileQueue) Retry(ctx context.Context, handle ReceiptHandle, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	f := string(handle)
+	fm, err := q.read(f)
+	if err != nil {
+		return errors.Wrap(err, "failed to read message file")
+	}
+
+	fm.ReadyAt = time.Now().Add(delay)
+	if err := q.write(strings.TrimSuffix(f, inFlightFileExt), fm); err != nil {
+		return errors.Wrap(err, "failed to retry message file")
+	}
+	if err := os.Remove(f); err != nil {
+		return errors.Wrap(err, "failed to retry message file")
+	}
+
+	q.signal()
+	return nil
+}
+
+// Delete implements Queue.
+func (q *fileQueue) Delete(ctx context.Context, handle ReceiptHandle) error {
+	if handle == "" {
+		return nil
+	}
+
+	if err := os.Remove(string(handle)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to delete message file")
+	}
+
+	q.signal()
+	return nil
+}
+
+// read returns the content of the specified message file.
+func (q *fileQueue) read(path string) (*fileMessage, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fm fileMessage
+	if err := json.Unmarshal(buf, &fm); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal message %s", path)
+	}
+
+	return &fm, nil
+}
+
+// write replaces the content of the specified message file. The content is written to a temporary file
+// first so that receivers never see partially written messages.
+func (q *fileQueue) write(path string, fm *fileMessage) error {
+	buf, err := json.Marshal(fm)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(q.dir, ".write-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// signal wakes a waiting receiver in this process, if any.
+func (q *fileQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
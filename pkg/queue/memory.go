@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a message held by memoryQueue.
+type memoryEntry struct {
+	message    Message
+	attempts   int
+	sentAt     time.Time     // Time the message was submitted
+	receivedAt time.Time     // Time the message was first received (zero until then)
+	readyAt    time.Time     // Time before which the message isn't received
+	handle     ReceiptHandle // Receipt handle of the message while it's in flight
+}
+
+// memoryQueue provides the in-memory implementation of Queue, for running locally and in tests.
+type memoryQueue struct {
+	mu      sync.Mutex
+	entries []*memoryEntry // Pending and in flight messages in the order they were submitted
+	nextID  int
+	notify  chan struct{}
+}
+
+// NewMemoryQueue returns a Queue that holds messages in memory. Messages are lost when the process exits.
+func NewMemoryQueue() Queue {
+	return &memoryQueue{
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Submit implements Queue.
+func (q *memoryQueue) Submit(ctx context.Context, m *Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = append(q.entries, &memoryEntry{message: *m, sentAt: time.Now()})
+	q.signal()
+
+	return nil
+}
+
+// Receive implements Queue.
+func (q *memoryQueue) Receive(ctx context.Context) (*Message, ReceiptHandle, error) {
+	if m, handle := q.pop(); m != nil {
+		return m, handle, nil
+	}
+
+	wait(ctx, q.notify)
+
+	m, handle := q.pop()
+	return m, handle, nil
+}
+
+// pop moves the oldest ready message whose group isn't blocked in flight and returns it, or nil if there
+// are no such messages.
+func (q *memoryQueue) pop() (*Message, ReceiptHandle) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	blocked := map[string]bool{}
+	for _, e := range q.entries {
+		key := e.message.GroupKey
+		if blocked[key] {
+			continue
+		}
+
+		// Later messages in the same group wait for this one
+		if e.handle != "" || e.readyAt.After(now) {
+			blocked[key] = true
+			continue
+		}
+
+		q.nextID++
+		e.handle = ReceiptHandle(strconv.Itoa(q.nextID))
+		e.attempts++
+		if e.receivedAt.IsZero() {
+			e.receivedAt = now
+		}
+
+		m := e.message
+		m.Attempts = e.attempts
+		m.SentAt = e.sentAt
+		m.ReceivedAt = e.receivedAt
+
+		return &m, e.handle
+	}
+
+	return nil, ""
+}
+
+// Retry implements Queue.
+func (q *memoryQueue) Retry(ctx context.Context, handle ReceiptHandle, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if i := q.find(handle); i >= 0 {
+		q.entries[i].handle = ""
+		q.entries[i].readyAt = time.Now().Add(delay)
+		q.signal()
+	}
+
+	return nil
+}
+
+// Delete implements Queue.
+func (q *memoryQueue) Delete(ctx context.Context, handle ReceiptHandle) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if i := q.find(handle); i >= 0 {
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		q.signal()
+	}
+
+	return nil
+}
+
+// find returns the index of the in flight message with the specified receipt handle, or -1 if there's none.
+func (q *memoryQueue) find(handle ReceiptHandle) int {
+	if handle == "" {
+		return -1
+	}
+
+	for i, e := range q.entries {
+		if e.handle == handle {
+			return i
+		}
+	}
+	return -1
+}
+
+// signal wakes a waiting receiver, if any.
+func (q *memoryQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,68 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// offloadingQueue decorates a Queue so that the payloads of messages that are too large to be queued are
+// put in a blob store, and only references to them are queued.
+type offloadingQueue struct {
+	Queue
+	blobs     BlobStore
+	threshold int
+}
+
+// NewOffloadingQueue returns a Queue that submits messages to the specified queue, putting the payloads of
+// messages whose encoded size exceeds the specified number of bytes in the specified blob store. Received
+// messages keep the references to their payloads until resolved by ResolvePayload.
+func NewOffloadingQueue(q Queue, blobs BlobStore, threshold int) Queue {
+	return &offloadingQueue{
+		Queue:     q,
+		blobs:     blobs,
+		threshold: threshold,
+	}
+}
+
+// Submit implements Queue.
+func (q *offloadingQueue) Submit(ctx context.Context, m *Message) error {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if len(buf) <= q.threshold {
+		return q.Queue.Submit(ctx, m)
+	}
+
+	offloaded := *m
+	offloaded.Version = MessageVersion
+	offloaded.Payload = ""
+	offloaded.PayloadRef = unsafeFileChars.ReplaceAllString(m.DeduplicationID(), "_") + messageFileExt
+
+	if err := q.blobs.Put(ctx, offloaded.PayloadRef, []byte(m.Payload)); err != nil {
+		return errors.Wrap(err, "failed to offload payload")
+	}
+	return q.Queue.Submit(ctx, &offloaded)
+}
+
+// ResolvePayload gets the payload referenced by the specified message from the specified blob store and
+// embeds it in the message. Messages that already embed their payloads are left unchanged.
+func ResolvePayload(ctx context.Context, blobs BlobStore, m *Message) error {
+	if m.PayloadRef == "" {
+		return nil
+	}
+	if blobs == nil {
+		return errors.Errorf("no blob store to resolve payload %s", m.PayloadRef)
+	}
+
+	buf, err := blobs.Get(ctx, m.PayloadRef)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve payload %s", m.PayloadRef)
+	}
+
+	m.Payload = string(buf)
+	m.PayloadRef = ""
+	return nil
+}
@@ -0,0 +1,116 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// MessageVersion is the version of the message schema submitted by this version of orgbot. Messages
+// that predate versioning have no version and are version 1; version 2 messages may reference a payload
+// held in a blob store rather than embedding it.
+const MessageVersion = 2
+
+// pollInterval is how long the local queues wait for a message to be submitted before reporting
+// that none is available
+const pollInterval = time.Second
+
+// Message is a GitHub event delivered to the hook that's queued for asynchronous processing.
+type Message struct {
+	Version        int `json:",omitempty"`
+	InstallationID int64
+	EventType      string
+	DeliveryID     string
+	// String is better- base64 encoded if byte[]
+	Payload string
+
+	// PayloadRef is the key of the payload in the blob store when it's too large to embed, in which case
+	// Payload is empty until resolved by ResolvePayload.
+	PayloadRef string `json:",omitempty"`
+
+	// GroupKey identifies the messages that are received in the order they were submitted. A message
+	// isn't received while an earlier message with the same key is in flight or waiting to be retried,
+	// but messages with different keys may be received in parallel.
+	GroupKey string `json:",omitempty"`
+
+	// DeliveredAt is the time the event was delivered to the hook, or zero if unknown.
+	DeliveredAt time.Time
+
+	// ReplayID distinguishes a replay of the delivery from the original and from other replays so that
+	// it isn't deduplicated by the queue. It's empty unless the message was replayed.
+	ReplayID string `json:",omitempty"`
+
+	// Attempts is the number of times the message has been received, including this time. It's set
+	// by Receive and isn't submitted.
+	Attempts int `json:"-"`
+
+	// SentAt is the time the queue accepted the message and ReceivedAt the time it was first received,
+	// both by the clock of the queue so that they can be compared with each other regardless of the clocks
+	// of the hook and the workers. They're set by Receive and aren't submitted.
+	SentAt     time.Time `json:"-"`
+	ReceivedAt time.Time `json:"-"`
+}
+
+// DeduplicationID returns the ID by which queues recognise duplicate submissions of the message, i.e. the
+// delivery ID qualified by the replay ID, if any.
+func (m *Message) DeduplicationID() string {
+	if m.ReplayID == "" {
+		return m.DeliveryID
+	}
+	return m.DeliveryID + "-replay-" + m.ReplayID
+}
+
+// Replay marks the message as a replay of its delivery at the specified time, so that it's submitted afresh.
+func (m *Message) Replay(t time.Time) {
+	m.ReplayID = strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// IsSupported returns whether the schema version of the message is understood by this version of orgbot.
+// Messages with later versions were submitted by a newer orgbot, such as during a deploy.
+func (m *Message) IsSupported() bool {
+	return m.Version <= MessageVersion
+}
+
+// ReceiptHandle identifies a received message so that it can be deleted once processed.
+type ReceiptHandle string
+
+// Queue provides the means of passing messages from the hook to the event listener.
+type Queue interface {
+	// Submit puts the specified message on the queue.
+	Submit(ctx context.Context, m *Message) error
+
+	// Receive fetches the next message from the queue, waiting a short time for one to arrive. A nil
+	// message is returned if no message is available or the context is done. Received messages remain
+	// on the queue, hidden from other receivers, until deleted.
+	Receive(ctx context.Context) (*Message, ReceiptHandle, error)
+
+	// Retry makes the received message with the specified receipt handle available to receivers again
+	// once the specified delay has elapsed, signalling that processing it failed.
+	Retry(ctx context.Context, handle ReceiptHandle, delay time.Duration) error
+
+	// Delete removes the received message with the specified receipt handle from the queue, signalling
+	// that processing it has successfully completed.
+	Delete(ctx context.Context, handle ReceiptHandle) error
+}
+
+// Backend is the type used for the names of the queue implementations.
+type Backend string
+
+const (
+	BackendSQS    Backend = "sqs"    // Messages are queued on an SQS FIFO queue
+	BackendMemory Backend = "memory" // Messages are queued in memory and lost when the process exits
+	BackendFile   Backend = "file"   // Messages are queued as files in a local directory
+)
+
+// wait blocks until the specified channel is signalled, the poll interval elapses or the specified
+// context is done, whichever comes first.
+func wait(ctx context.Context, notify <-chan struct{}) {
+	t := time.NewTimer(pollInterval)
+	defer t.Stop()
+
+	select {
+	case <-notify:
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
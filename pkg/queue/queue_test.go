@@ -0,0 +1,568 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocalQueues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileQueue, err := NewFileQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		queue Queue
+	}{
+		{"memory", NewMemoryQueue()},
+		{"file", fileQueue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			want := []*Message{
+				{InstallationID: 1, EventType: "team", DeliveryID: "a", Payload: `{"action":"created"}`, GroupKey: "team:SEEK-Jobs/1"},
+				{InstallationID: 1, EventType: "push", DeliveryID: "b", Payload: `{"ref":"refs/heads/master"}`, GroupKey: "repo:SEEK-Jobs/org"},
+			}
+			for _, m := range want {
+				if err := tt.queue.Submit(ctx, m); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Messages in different groups are received in parallel, in the order they were submitted
+			var got []*Message
+			var handles []ReceiptHandle
+			for range want {
+				m, handle, err := tt.queue.Receive(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, m)
+				handles = append(handles, handle)
+			}
+
+			// Receiving a message counts as the first attempt at processing it
+			for _, m := range want {
+				m.Attempts = 1
+			}
+			clearQueueTimes(t, got...)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+
+			// Received messages aren't received again
+			m, _, err := tt.queue.Receive(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != nil {
+				t.Errorf("Received message %s twice", m.DeliveryID)
+			}
+
+			for _, h := range handles {
+				if err := tt.queue.Delete(ctx, h); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalQueuesGroupOrdering(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileQueue, err := NewFileQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		queue Queue
+	}{
+		{"memory", NewMemoryQueue()},
+		{"file", fileQueue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for _, m := range []*Message{
+				{DeliveryID: "a", GroupKey: "repo:SEEK-Jobs/repo1"},
+				{DeliveryID: "b", GroupKey: "repo:SEEK-Jobs/repo1"},
+				{DeliveryID: "c", GroupKey: "repo:SEEK-Jobs/repo2"},
+			} {
+				if err := tt.queue.Submit(ctx, m); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			receive := func() (string, ReceiptHandle) {
+				m, handle, err := tt.queue.Receive(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m == nil {
+					return "", ""
+				}
+				return m.DeliveryID, handle
+			}
+
+			// The second message of repo1 waits for the first, which is retried, but repo2 proceeds
+			id, handleA := receive()
+			if id != "a" {
+				t.Fatalf("Expected a, received %q", id)
+			}
+			id, handleC := receive()
+			if id != "c" {
+				t.Fatalf("Expected c while a is in flight, received %q", id)
+			}
+			if err := tt.queue.Delete(ctx, handleC); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.queue.Retry(ctx, handleA, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			id, handleA = receive()
+			if id != "a" {
+				t.Fatalf("Expected retried a before b, received %q", id)
+			}
+			if err := tt.queue.Delete(ctx, handleA); err != nil {
+				t.Fatal(err)
+			}
+
+			id, _ = receive()
+			if id != "b" {
+				t.Fatalf("Expected b once a is deleted, received %q", id)
+			}
+		})
+	}
+}
+
+func TestFileQueueRestoresInFlightMessages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+
+	q, err := NewFileQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Message{InstallationID: 1, EventType: "team", DeliveryID: "a", Payload: "{}"}
+	if err := q.Submit(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// The message is received but not deleted before the process exits
+	first, _, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = NewFileQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, handle, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The receive before the restart counts as an attempt, and is when the message was first received
+	if !got.ReceivedAt.Equal(first.ReceivedAt) {
+		t.Errorf("Expected the message to have been first received at %v, got %v", first.ReceivedAt, got.ReceivedAt)
+	}
+	want.Attempts = 2
+	clearQueueTimes(t, got)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	if err := q.Delete(ctx, handle); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected deleted message to be removed, found %d files", len(files))
+	}
+}
+
+func TestLocalQueuesRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileQueue, err := NewFileQueue(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		queue Queue
+	}{
+		{"memory", NewMemoryQueue()},
+		{"file", fileQueue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := tt.queue.Submit(ctx, &Message{EventType: "team", DeliveryID: "a"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Each receive counts as an attempt
+			for attempt := 1; attempt <= 2; attempt++ {
+				m, handle, err := tt.queue.Receive(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if m == nil {
+					t.Fatalf("Expected retried message to be received on attempt %d", attempt)
+				}
+				if m.Attempts != attempt {
+					t.Errorf("Expected attempt %d, got %d", attempt, m.Attempts)
+				}
+
+				if err := tt.queue.Retry(ctx, handle, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Delayed messages aren't received until the delay has elapsed
+			m, handle, err := tt.queue.Receive(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.queue.Retry(ctx, handle, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+
+			m, _, err = tt.queue.Receive(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != nil {
+				t.Errorf("Received message %s before its retry delay elapsed", m.DeliveryID)
+			}
+		})
+	}
+}
+
+func TestLocalDeadLetterStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dead-letters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileStore, err := NewFileDeadLetterStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		store DeadLetterStore
+	}{
+		{"memory", NewMemoryDeadLetterStore()},
+		{"file", fileStore},
+	}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			older := &DeadLetter{
+				Message:  Message{InstallationID: 1, EventType: "team", DeliveryID: "a", Payload: "{}"},
+				Attempts: 5,
+				Error:    "boom",
+				Time:     now.Add(-time.Minute),
+			}
+			newer := &DeadLetter{
+				Message:  Message{InstallationID: 1, EventType: "push", DeliveryID: "b", Payload: "{}"},
+				Attempts: 5,
+				Error:    "bang",
+				Time:     now,
+			}
+
+			for _, d := range []*DeadLetter{newer, older} {
+				if err := tt.store.Put(ctx, d); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := tt.store.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]*DeadLetter{older, newer}, got); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+
+			if err := tt.store.Remove(ctx, "a"); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err = tt.store.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]*DeadLetter{newer}, got); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOffloadingQueue(t *testing.T) {
+	ctx := context.Background()
+
+	q := NewMemoryQueue()
+	blobs := NewMemoryBlobStore()
+	offloading := NewOffloadingQueue(q, blobs, 200)
+
+	small := &Message{Version: MessageVersion, EventType: "team", DeliveryID: "a", Payload: "{}", GroupKey: "a"}
+	large := &Message{Version: MessageVersion, EventType: "push", DeliveryID: "b/c", Payload: `{"commits": "` + strings.Repeat("x", 200) + `"}`, GroupKey: "b"}
+
+	for _, m := range []*Message{small, large} {
+		if err := offloading.Submit(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []*Message{small, large} {
+		m, _, err := offloading.Receive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want == large && (m.Payload != "" || m.PayloadRef != "b_c.json") {
+			t.Errorf("Expected the large payload to be queued as a reference, got %+v", m)
+		}
+		if want == small && m.PayloadRef != "" {
+			t.Errorf("Expected the small payload to be queued as is, got %+v", m)
+		}
+
+		if err := ResolvePayload(ctx, blobs, m); err != nil {
+			t.Fatal(err)
+		}
+
+		wantReceived := *want
+		wantReceived.Attempts = 1
+		clearQueueTimes(t, m)
+		if diff := cmp.Diff(&wantReceived, m); diff != "" {
+			t.Errorf("(-want +got)\n%s", diff)
+		}
+	}
+}
+
+func TestLocalBlobStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileStore, err := NewFileBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		store BlobStore
+	}{
+		{"memory", NewMemoryBlobStore()},
+		{"file", fileStore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := tt.store.Put(ctx, "a.json", []byte("{}")); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tt.store.Get(ctx, "a.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "{}" {
+				t.Errorf("Expected {}, got %s", got)
+			}
+
+			if err := tt.store.Delete(ctx, "a.json"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tt.store.Get(ctx, "a.json"); err == nil {
+				t.Error("Expected deleted blob not to be found")
+			}
+		})
+	}
+}
+
+func TestMessageVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want bool
+	}{
+		{"unversioned", `{"EventType": "team", "Payload": "{}"}`, true},
+		{"current", fmt.Sprintf(`{"Version": %d, "EventType": "team", "PayloadRef": "a.json"}`, MessageVersion), true},
+		{"newer", fmt.Sprintf(`{"Version": %d, "EventType": "team"}`, MessageVersion+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Message
+			if err := json.Unmarshal([]byte(tt.json), &m); err != nil {
+				t.Fatal(err)
+			}
+			if got := m.IsSupported(); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLocalArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileArchive, err := NewFileArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		archive Archive
+	}{
+		{"memory", NewMemoryArchive()},
+		{"file", fileArchive},
+	}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			older := &Message{InstallationID: 1, EventType: "team", DeliveryID: "a", Payload: "{}", DeliveredAt: now.Add(-time.Hour)}
+			newer := &Message{InstallationID: 1, EventType: "push", DeliveryID: "b", Payload: "{}", DeliveredAt: now}
+
+			for _, m := range []*Message{newer, older} {
+				if err := tt.archive.Put(ctx, m); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := tt.archive.List(ctx, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]*Message{older, newer}, got); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+
+			got, err = tt.archive.List(ctx, now.Add(-time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]*Message{newer}, got); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+
+			m, err := tt.archive.Get(ctx, "a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(older, m); diff != "" {
+				t.Errorf("(-want +got)\n%s", diff)
+			}
+
+			if _, err := tt.archive.Get(ctx, "c"); err == nil {
+				t.Error("Expected an error getting a delivery that wasn't archived")
+			}
+		})
+	}
+}
+
+func TestMessageDeduplicationID(t *testing.T) {
+	m := Message{DeliveryID: "delivery-1"}
+	if got := m.DeduplicationID(); got != "delivery-1" {
+		t.Errorf("Expected original delivery to be deduplicated by its delivery ID, got %s", got)
+	}
+
+	now := time.Now()
+	m.Replay(now)
+	first := m.DeduplicationID()
+	if first == "delivery-1" {
+		t.Error("Expected replay to be deduplicated separately from the original delivery")
+	}
+
+	m.Replay(now.Add(time.Second))
+	if m.DeduplicationID() == first {
+		t.Error("Expected replays to be deduplicated separately from each other")
+	}
+}
+
+// clearQueueTimes checks that the specified received messages have the times they were sent and first
+// received, and clears them so that the messages can be compared with those submitted.
+func clearQueueTimes(t *testing.T, ms ...*Message) {
+	t.Helper()
+
+	for _, m := range ms {
+		if m.SentAt.IsZero() || m.ReceivedAt.Before(m.SentAt) {
+			t.Errorf("Expected message %s to be received after it was sent, got sent at %v and received at %v",
+				m.DeliveryID, m.SentAt, m.ReceivedAt)
+		}
+		m.SentAt = time.Time{}
+		m.ReceivedAt = time.Time{}
+	}
+}
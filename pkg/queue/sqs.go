@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxSQSVisibilityTimeout is the longest time SQS can hide a received message for
+	maxSQSVisibilityTimeout = 12 * time.Hour
+
+	// sqsWaitTimeSeconds is how long SQS waits for a message to arrive before reporting that none is
+	// available, which is the longest SQS supports so that the queue is polled as rarely as possible
+	sqsWaitTimeSeconds = 20
+)
+
+// sqsQueue provides the SQS implementation of Queue. Group keys are used as the SQS message group IDs.
+type sqsQueue struct {
+	queueURL       string
+	defaultGroupID string
+	sqsClient      sqsiface.SQSAPI
+}
+
+// NewSQSQueue returns a Queue backed by the SQS FIFO queue with the specified URL. Messages without a
+// group key are submitted to the specified default message group.
+func NewSQSQueue(sqsClient sqsiface.SQSAPI, queueURL, defaultGroupID string) Queue {
+	return &sqsQueue{
+		queueURL:       queueURL,
+		defaultGroupID: defaultGroupID,
+		sqsClient:      sqsClient,
+	}
+}
+
+// Submit implements Queue.
+func (s *sqsQueue) Submit(ctx context.Context, m *Message) error {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	groupID := m.GroupKey
+	if groupID == "" {
+		groupID = s.defaultGroupID
+	}
+
+	input := sqs.SendMessageInput{
+		MessageBody:            aws.String(string(buf)),
+		MessageDeduplicationId: aws.String(m.DeduplicationID()),
+		MessageGroupId:         aws.String(groupID),
+		QueueUrl:               aws.String(s.queueURL),
+	}
+
+	_, err = s.sqsClient.SendMessageWithContext(ctx, &input)
+	return err
+}
+
+// Receive implements Queue.
+func (s *sqsQueue) Receive(ctx context.Context) (*Message, ReceiptHandle, error) {
+	receiveInput := &sqs.ReceiveMessageInput{
+		AttributeNames: []*string{
+			aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount),
+			aws.String(sqs.MessageSystemAttributeNameSentTimestamp),
+			aws.String(sqs.MessageSystemAttributeNameApproximateFirstReceiveTimestamp),
+		},
+		MaxNumberOfMessages: aws.Int64(1),
+		QueueUrl:            aws.String(s.queueURL),
+		WaitTimeSeconds:     aws.Int64(sqsWaitTimeSeconds),
+	}
+
+	messageOutput, err := s.sqsClient.ReceiveMessageWithContext(ctx, receiveInput)
+	if err != nil {
+		// Cancelling the context aborts the long poll, which isn't a failure
+		if ctx.Err() != nil {
+			return nil, "", nil
+		}
+		return nil, "", errors.Wrap(err, "failed to receive message from sqs")
+	}
+
+	if len(messageOutput.Messages) == 0 {
+		return nil, "", nil
+	}
+
+	if len(messageOutput.Messages) > 1 {
+		return nil, "", fmt.Errorf("received too many messages from sqs (expected 1, received %d)", len(messageOutput.Messages))
+	}
+
+	var m Message
+	receivedMessage := messageOutput.Messages[0]
+	if err := json.Unmarshal([]byte(*receivedMessage.Body), &m); err != nil {
+		return nil, "", errors.Wrap(err, "failed to unmarshal sqs message")
+	}
+
+	if v, ok := receivedMessage.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]; ok {
+		if m.Attempts, err = strconv.Atoi(aws.StringValue(v)); err != nil {
+			return nil, "", errors.Wrap(err, "bad sqs message receive count")
+		}
+	}
+	if m.SentAt, err = sqsTimestamp(receivedMessage.Attributes, sqs.MessageSystemAttributeNameSentTimestamp); err != nil {
+		return nil, "", err
+	}
+	if m.ReceivedAt, err = sqsTimestamp(receivedMessage.Attributes, sqs.MessageSystemAttributeNameApproximateFirstReceiveTimestamp); err != nil {
+		return nil, "", err
+	}
+
+	return &m, ReceiptHandle(*receivedMessage.ReceiptHandle), nil
+}
+
+// sqsTimestamp returns the time held by the specified attribute of an sqs message, in milliseconds since
+// the epoch, or zero if the message doesn't have the attribute.
+func sqsTimestamp(attributes map[string]*string, name string) (time.Time, error) {
+	v, ok := attributes[name]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	ms, err := strconv.ParseInt(aws.StringValue(v), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "bad sqs message %s", name)
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
+}
+
+// Retry implements Queue.
+func (s *sqsQueue) Retry(ctx context.Context, handle ReceiptHandle, delay time.Duration) error {
+	if delay > maxSQSVisibilityTimeout {
+		delay = maxSQSVisibilityTimeout
+	}
+
+	input := &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.queueURL),
+		ReceiptHandle:     aws.String(string(handle)),
+		VisibilityTimeout: aws.Int64(int64(delay / time.Second)),
+	}
+
+	if _, err := s.sqsClient.ChangeMessageVisibilityWithContext(ctx, input); err != nil {
+		return errors.Wrap(err, "failed to change visibility of sqs message")
+	}
+
+	return nil
+}
+
+// Delete implements Queue.
+func (s *sqsQueue) Delete(ctx context.Context, handle ReceiptHandle) error {
+	if handle == "" {
+		return nil
+	}
+	deleteInput := &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(s.queueURL),
+		ReceiptHandle: aws.String(string(handle)),
+	}
+
+	_, err := s.sqsClient.DeleteMessageWithContext(ctx, deleteInput)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete message from sqs")
+	}
+
+	return nil
+}
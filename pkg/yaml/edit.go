@@ -0,0 +1,94 @@
+package yaml
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RenameItems renames the items of the sequence with the specified key at the top level of the specified
+// document whose nameKey field has the specified old name, compared case insensitively. The names are edited
+// in place so that the rest of the document, including comments and formatting, is left untouched. The edited
+// document is returned along with whether any items were renamed.
+func RenameItems(in []byte, seqKey, nameKey, oldName, newName string) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(in, &doc); err != nil {
+		return nil, false, err
+	}
+	if len(doc.Content) == 0 {
+		return in, false, nil
+	}
+
+	var names []*yaml.Node
+	for _, seq := range mappingValues(doc.Content[0], seqKey) {
+		if seq.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range seq.Content {
+			for _, name := range mappingValues(item, nameKey) {
+				if name.Kind == yaml.ScalarNode && strings.EqualFold(name.Value, oldName) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		return in, false, nil
+	}
+
+	lines := strings.Split(string(in), "\n")
+	for _, n := range names {
+		if err := replaceScalar(lines, n, newName); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n")), true, nil
+}
+
+// mappingValues returns the values of the specified mapping node that have the specified key.
+func mappingValues(mapping *yaml.Node, key string) []*yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var values []*yaml.Node
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			values = append(values, mapping.Content[i+1])
+		}
+	}
+	return values
+}
+
+// replaceScalar replaces the text of the specified scalar node within the specified lines of the document it
+// was decoded from with the specified value, quoted in the same style. Only scalars whose text is their value,
+// quoted or otherwise, can be replaced.
+func replaceScalar(lines []string, n *yaml.Node, value string) error {
+	old, repl := n.Value, value
+	switch n.Style {
+	case yaml.DoubleQuotedStyle:
+		old, repl = `"`+old+`"`, `"`+repl+`"`
+	case yaml.SingleQuotedStyle:
+		old, repl = "'"+old+"'", "'"+repl+"'"
+	case 0, yaml.FlowStyle:
+	default:
+		return fmt.Errorf("can't edit %s at line %d in place", n.Value, n.Line)
+	}
+
+	if n.Line < 1 || n.Line > len(lines) {
+		return fmt.Errorf("can't find %s at line %d", n.Value, n.Line)
+	}
+
+	// Columns count characters rather than bytes
+	line := []rune(lines[n.Line-1])
+	start := n.Column - 1
+	end := start + len([]rune(old))
+	if start < 0 || end > len(line) || string(line[start:end]) != old {
+		return fmt.Errorf("can't find %s at line %d, column %d", n.Value, n.Line, n.Column)
+	}
+
+	lines[n.Line-1] = string(line[:start]) + repl + string(line[end:])
+	return nil
+}
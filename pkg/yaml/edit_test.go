@@ -0,0 +1,55 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenameItems(t *testing.T) {
+	in := `# Repos managed by orgbot
+name: SEEK-Jobs
+repos:
+  # The old name is still referenced below
+  - name: Old-Name # Renamed in 2020
+    topics: [go]
+    teams:
+      - name: old-name
+        permission: admin
+  - name: "old-name"
+  - {name: 'old-name', topics: [java]}
+  - name: other
+`
+	want := `# Repos managed by orgbot
+name: SEEK-Jobs
+repos:
+  # The old name is still referenced below
+  - name: new-name # Renamed in 2020
+    topics: [go]
+    teams:
+      - name: old-name
+        permission: admin
+  - name: "new-name"
+  - {name: 'new-name', topics: [java]}
+  - name: other
+`
+
+	got, renamed, err := RenameItems([]byte(in), "repos", "name", "old-name", "new-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !renamed {
+		t.Error("Expected items to be renamed")
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("(-want +got)\n%s", diff)
+	}
+
+	got, renamed, err = RenameItems([]byte(in), "repos", "name", "unknown", "new-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed || string(got) != in {
+		t.Error("Expected document to be unchanged")
+	}
+}